@@ -0,0 +1,302 @@
+package bip39
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/chacha20poly1305"
+	"golang.org/x/crypto/scrypt"
+)
+
+// BackupVersion version of backups written by EncryptBackup
+const BackupVersion = 1
+
+// KDF backup password key derivation function
+type KDF string
+
+// Supported key derivation functions
+const (
+	KDFScrypt   KDF = "scrypt"
+	KDFArgon2id KDF = "argon2id"
+)
+
+const backupCipher = "xchacha20-poly1305"
+
+// Key derivation parameters of new backups, argon2id follows the second
+// recommended option of RFC 9106, scrypt matches the memory cost
+const (
+	backupSaltLen       = 16
+	backupScryptN       = 1 << 16
+	backupScryptR       = 8
+	backupScryptP       = 1
+	backupArgon2Time    = 3
+	backupArgon2Memory  = 64 * 1024
+	backupArgon2Threads = 4
+)
+
+// upper bounds of stored parameters, rejects files crafted to exhaust memory
+// or cpu time of mobile devices
+const (
+	maxScryptMemory = 256 << 20 // bytes, scrypt allocates 128·N·r
+	maxScryptWork   = 1 << 22   // N·r·p, 8 times the default cost
+	maxArgon2Time   = 16
+	maxArgon2Memory = 256 * 1024 // KiB
+)
+
+// Backup errors
+var (
+	ErrBackupVersion   = errors.New("backup version is not supported")
+	ErrBackupDecrypt   = errors.New("backup password incorrect or backup data tampered")
+	ErrBackupMalformed = errors.New("backup data is malformed")
+)
+
+// Backup mnemonic and metadata stored in encrypted backup
+type Backup struct {
+	Mnemonic       string   `json:"mnemonic"`
+	PassphraseHint string   `json:"passphrase_hint,omitempty"`
+	Language       Language `json:"language,omitempty"`
+	DerivationPath string   `json:"derivation_path,omitempty"`
+}
+
+type backupKDFParams struct {
+	Salt    string `json:"salt"`
+	N       int    `json:"n,omitempty"`
+	R       int    `json:"r,omitempty"`
+	P       int    `json:"p,omitempty"`
+	Time    uint32 `json:"time,omitempty"`
+	Memory  uint32 `json:"memory,omitempty"`
+	Threads uint8  `json:"threads,omitempty"`
+}
+
+// backupFile backup container, every field except the ciphertext is
+// authenticated as AEAD associated data
+type backupFile struct {
+	Version    int             `json:"version"`
+	KDF        KDF             `json:"kdf"`
+	KDFParams  backupKDFParams `json:"kdfparams"`
+	Cipher     string          `json:"cipher"`
+	Nonce      string          `json:"nonce"`
+	Ciphertext string          `json:"ciphertext,omitempty"`
+}
+
+// EncryptBackup encrypt backup with password into versioned json container
+func EncryptBackup(backup *Backup, password string, kdf KDF) ([]byte, error) {
+	if _, err := EntropyFromMnemonic(backup.Mnemonic); err != nil {
+		return nil, err
+	}
+
+	salt := make([]byte, backupSaltLen)
+
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+
+	file := &backupFile{
+		Version: BackupVersion,
+		KDF:     kdf,
+		Cipher:  backupCipher,
+		KDFParams: backupKDFParams{
+			Salt: hex.EncodeToString(salt),
+		},
+	}
+
+	switch kdf {
+	case KDFScrypt:
+		file.KDFParams.N = backupScryptN
+		file.KDFParams.R = backupScryptR
+		file.KDFParams.P = backupScryptP
+	case KDFArgon2id:
+		file.KDFParams.Time = backupArgon2Time
+		file.KDFParams.Memory = backupArgon2Memory
+		file.KDFParams.Threads = backupArgon2Threads
+	default:
+		return nil, fmt.Errorf("unsupported backup kdf :%s", kdf)
+	}
+
+	nonce := make([]byte, chacha20poly1305.NonceSizeX)
+
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+
+	file.Nonce = hex.EncodeToString(nonce)
+
+	key, err := file.deriveKey(password)
+
+	if err != nil {
+		return nil, err
+	}
+
+	aead, err := chacha20poly1305.NewX(key)
+
+	if err != nil {
+		return nil, err
+	}
+
+	plaintext, err := json.Marshal(backup)
+
+	if err != nil {
+		return nil, err
+	}
+
+	ad, err := json.Marshal(file)
+
+	if err != nil {
+		return nil, err
+	}
+
+	file.Ciphertext = hex.EncodeToString(aead.Seal(nil, nonce, plaintext, ad))
+
+	return json.Marshal(file)
+}
+
+// DecryptBackup decrypt backup container of this or any previous version,
+// ErrBackupDecrypt is returned for wrong password or modified data
+func DecryptBackup(data []byte, password string) (*Backup, error) {
+	var version struct {
+		Version int `json:"version"`
+	}
+
+	if err := json.Unmarshal(data, &version); err != nil {
+		return nil, ErrBackupMalformed
+	}
+
+	switch version.Version {
+	case 1:
+		return decryptBackupV1(data, password)
+	}
+
+	return nil, ErrBackupVersion
+}
+
+// MigrateBackup re-encrypt backup of previous version with the current
+// version, keeping its kdf. Current version backups are returned unchanged
+func MigrateBackup(data []byte, password string) ([]byte, error) {
+	var file backupFile
+
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, ErrBackupMalformed
+	}
+
+	backup, err := DecryptBackup(data, password)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if file.Version == BackupVersion {
+		return data, nil
+	}
+
+	return EncryptBackup(backup, password, file.KDF)
+}
+
+func decryptBackupV1(data []byte, password string) (*Backup, error) {
+	var file backupFile
+
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, ErrBackupMalformed
+	}
+
+	if file.Cipher != backupCipher {
+		return nil, fmt.Errorf("unsupported backup cipher :%s", file.Cipher)
+	}
+
+	nonce, err := hex.DecodeString(file.Nonce)
+
+	if err != nil || len(nonce) != chacha20poly1305.NonceSizeX {
+		return nil, ErrBackupMalformed
+	}
+
+	ciphertext, err := hex.DecodeString(file.Ciphertext)
+
+	if err != nil {
+		return nil, ErrBackupMalformed
+	}
+
+	file.Ciphertext = ""
+
+	ad, err := json.Marshal(&file)
+
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := file.deriveKey(password)
+
+	if err != nil {
+		return nil, err
+	}
+
+	aead, err := chacha20poly1305.NewX(key)
+
+	if err != nil {
+		return nil, err
+	}
+
+	plaintext, err := aead.Open(nil, nonce, ciphertext, ad)
+
+	if err != nil {
+		return nil, ErrBackupDecrypt
+	}
+
+	var backup Backup
+
+	if err := json.Unmarshal(plaintext, &backup); err != nil {
+		return nil, ErrBackupMalformed
+	}
+
+	return &backup, nil
+}
+
+// deriveKey derive 32 bytes encryption key with the kdf parameters of file
+func (file *backupFile) deriveKey(password string) ([]byte, error) {
+	params := file.KDFParams
+
+	salt, err := hex.DecodeString(params.Salt)
+
+	if err != nil || len(salt) == 0 {
+		return nil, ErrBackupMalformed
+	}
+
+	switch file.KDF {
+	case KDFScrypt:
+		if !validScryptParams(params.N, params.R, params.P) {
+			return nil, ErrBackupMalformed
+		}
+
+		return scrypt.Key([]byte(normalize(password)), salt, params.N, params.R, params.P, chacha20poly1305.KeySize)
+	case KDFArgon2id:
+		if !validArgon2Params(params.Time, params.Memory, params.Threads) {
+			return nil, ErrBackupMalformed
+		}
+
+		return argon2.IDKey([]byte(normalize(password)), salt, params.Time, params.Memory, params.Threads, chacha20poly1305.KeySize), nil
+	}
+
+	return nil, fmt.Errorf("unsupported backup kdf :%s", file.KDF)
+}
+
+// validScryptParams check n is a power of two greater than 1 and the memory
+// and work of scrypt are within bounds, the products are bounded by division
+// to avoid overflows
+func validScryptParams(n, r, p int) bool {
+	if n <= 1 || n&(n-1) != 0 || r <= 0 || p <= 0 {
+		return false
+	}
+
+	if n > maxScryptMemory/128 || r > maxScryptMemory/128/n {
+		return false
+	}
+
+	return p <= maxScryptWork/n/r
+}
+
+// validArgon2Params check argon2id time, memory and threads are within bounds
+func validArgon2Params(time uint32, memory uint32, threads uint8) bool {
+	return time > 0 && time <= maxArgon2Time && memory > 0 && memory <= maxArgon2Memory && threads > 0
+}
@@ -0,0 +1,156 @@
+package bip39
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+)
+
+func TestBackup(t *testing.T) {
+	backup := &Backup{
+		Mnemonic:       testMnemonic,
+		PassphraseHint: "first pet",
+		Language:       LanguageEnglish,
+		DerivationPath: "m/44'/60'/0'/0/0",
+	}
+
+	for _, kdf := range []KDF{KDFScrypt, KDFArgon2id} {
+		data, err := EncryptBackup(backup, "password", kdf)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if bytes.Contains(data, []byte("legal")) || bytes.Contains(data, []byte("first pet")) {
+			t.Fatal("backup contains plaintext")
+		}
+
+		restored, err := DecryptBackup(data, "password")
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if *restored != *backup {
+			t.Fatalf("expect %v got %v", backup, restored)
+		}
+
+		if _, err := DecryptBackup(data, "wrong password"); err != ErrBackupDecrypt {
+			t.Fatalf("expect decrypt error got %v", err)
+		}
+
+		migrated, err := MigrateBackup(data, "password")
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !bytes.Equal(migrated, data) {
+			t.Fatal("expect current version backup unchanged")
+		}
+	}
+}
+
+func TestBackupTampered(t *testing.T) {
+	data, err := EncryptBackup(&Backup{Mnemonic: testMnemonic}, "password", KDFScrypt)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var file backupFile
+
+	if err := json.Unmarshal(data, &file); err != nil {
+		t.Fatal(err)
+	}
+
+	tampered := file
+	tampered.Ciphertext = "00" + file.Ciphertext[2:]
+
+	if file.Ciphertext[:2] == "00" {
+		tampered.Ciphertext = "01" + file.Ciphertext[2:]
+	}
+
+	if _, err := DecryptBackup(mustMarshal(t, tampered), "password"); err != ErrBackupDecrypt {
+		t.Fatalf("expect decrypt error got %v", err)
+	}
+
+	tampered = file
+	tampered.Version = BackupVersion + 1
+
+	if _, err := DecryptBackup(mustMarshal(t, tampered), "password"); err != ErrBackupVersion {
+		t.Fatalf("expect version error got %v", err)
+	}
+
+	// one step over the 256 MiB memory, the work bound or a non power of two n
+	for _, params := range []backupKDFParams{{N: 1 << 22, R: 1, P: 1}, {N: 1 << 16, R: 33, P: 1}, {N: 1 << 16, R: 8, P: 9}, {N: 3, R: 8, P: 1}, {N: 1, R: 8, P: 1}} {
+		tampered = file
+		tampered.KDFParams.N = params.N
+		tampered.KDFParams.R = params.R
+		tampered.KDFParams.P = params.P
+
+		if _, err := DecryptBackup(mustMarshal(t, tampered), "password"); err != ErrBackupMalformed {
+			t.Fatalf("%v: expect malformed error got %v", params, err)
+		}
+	}
+
+	data, err = EncryptBackup(&Backup{Mnemonic: testMnemonic}, "password", KDFArgon2id)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	file = backupFile{}
+
+	if err := json.Unmarshal(data, &file); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, params := range []backupKDFParams{{Time: maxArgon2Time + 1, Memory: backupArgon2Memory}, {Time: backupArgon2Time, Memory: maxArgon2Memory + 1}} {
+		tampered = file
+		tampered.KDFParams.Time = params.Time
+		tampered.KDFParams.Memory = params.Memory
+
+		if _, err := DecryptBackup(mustMarshal(t, tampered), "password"); err != ErrBackupMalformed {
+			t.Fatalf("%v: expect malformed error got %v", params, err)
+		}
+	}
+
+	if _, err := EncryptBackup(&Backup{Mnemonic: "legal winner"}, "password", KDFScrypt); err == nil {
+		t.Fatal("expect invalid mnemonic error")
+	}
+}
+
+func TestBackupKDFBounds(t *testing.T) {
+	for _, test := range []struct {
+		n, r, p int
+		valid   bool
+	}{
+		{backupScryptN, backupScryptR, backupScryptP, true},
+		{1 << 21, 1, 1, true},
+		{1 << 16, 32, 2, true},
+		{1 << 16, 32, 3, false},
+		{1 << 21, 2, 1, false},
+		{1 << 22, 1, 1, false},
+		{1 << 62, 1 << 62, 1, false},
+		{1 << 16, 8, 0, false},
+	} {
+		if validScryptParams(test.n, test.r, test.p) != test.valid {
+			t.Fatalf("scrypt n %d r %d p %d: expect valid %v", test.n, test.r, test.p, test.valid)
+		}
+	}
+
+	if !validArgon2Params(maxArgon2Time, maxArgon2Memory, backupArgon2Threads) || validArgon2Params(maxArgon2Time, maxArgon2Memory+1, backupArgon2Threads) {
+		t.Fatal("unexpected argon2id memory bound")
+	}
+}
+
+func mustMarshal(t *testing.T, v interface{}) []byte {
+	data, err := json.Marshal(v)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return data
+}
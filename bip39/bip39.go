@@ -10,9 +10,26 @@ import (
 	"math/big"
 	"strings"
 
+	"github.com/goany/secure"
 	"golang.org/x/crypto/pbkdf2"
 )
 
+// Mnemonic decoding errors
+var (
+	ErrInvalidWordCount  = errors.New("mnemonic must have 12, 15, 18, 21 or 24 words")
+	ErrChecksumIncorrect = errors.New("mnemonic checksum incorrect")
+)
+
+// WordError mnemonic word not found in the word list
+type WordError struct {
+	Position int
+	Word     string
+}
+
+func (e *WordError) Error() string {
+	return fmt.Sprintf("Word `%v` at position %d not found in word list", e.Word, e.Position)
+}
+
 // Some bitwise operands for working with big.Ints
 var (
 	Last11BitsMask          = big.NewInt(2047)
@@ -38,6 +55,10 @@ func NewEntropy(bitSize int) ([]byte, error) {
 // the given entropy.
 // If the provide entropy is invalid, an error will be returned.
 func NewMnemonic(entropy []byte) (string, error) {
+	return newMnemonic(entropy, WordList, " ")
+}
+
+func newMnemonic(entropy []byte, wordList []string, separator string) (string, error) {
 	// Compute some lengths for convenience
 	entropyBitLength := len(entropy) * 8
 	checksumBitLength := entropyBitLength / 32
@@ -74,96 +95,85 @@ func NewMnemonic(entropy []byte) (string, error) {
 		wordBytes := padByteSlice(word.Bytes(), 2)
 
 		// Convert bytes to an index and add that word to the list
-		words[i] = WordList[binary.BigEndian.Uint16(wordBytes)]
+		words[i] = wordList[binary.BigEndian.Uint16(wordBytes)]
 	}
 
-	return strings.Join(words, " "), nil
+	return strings.Join(words, separator), nil
 }
 
 // MnemonicToByteArray takes a mnemonic string and turns it into a byte array
-// suitable for creating another mnemonic.
+// holding the entropy followed by the checksum bits.
 // An error is returned if the mnemonic is invalid.
-// FIXME
-// This does not work for all values in
-// the test vectors.  Namely
-// Vectors 0, 4, and 8.
-// This is not really important because BIP39 doesnt really define a conversion
-// from string to bytes.
+// Use EntropyFromMnemonic to get the original entropy.
 func MnemonicToByteArray(mnemonic string) ([]byte, error) {
 	if IsMnemonicValid(mnemonic) == false {
 		return nil, fmt.Errorf("Invalid mnemonic")
 	}
-	mnemonicSlice := strings.Split(mnemonic, " ")
-
-	bitSize := len(mnemonicSlice) * 11
-	err := validateEntropyWithChecksumBitSize(bitSize)
-	if err != nil {
-		return nil, err
-	}
-	checksumSize := bitSize % 32
+	mnemonicSlice := strings.Fields(mnemonic)
 
-	b := big.NewInt(0)
-	modulo := big.NewInt(2048)
-	for _, v := range mnemonicSlice {
+	indexes := make([]int, len(mnemonicSlice))
+	for i, v := range mnemonicSlice {
 		index, found := ReverseWordMap[v]
 		if found == false {
-			return nil, fmt.Errorf("Word `%v` not found in reverse map", v)
-		}
-		add := big.NewInt(int64(index))
-		b = b.Mul(b, modulo)
-		b = b.Add(b, add)
-	}
-	hex := b.Bytes()
-	checksumModulo := big.NewInt(0).Exp(big.NewInt(2), big.NewInt(int64(checksumSize)), nil)
-	entropy, _ := big.NewInt(0).DivMod(b, checksumModulo, big.NewInt(0))
-
-	entropyHex := entropy.Bytes()
-
-	byteSize := bitSize/8 + 1
-	if len(hex) != byteSize {
-		tmp := make([]byte, byteSize)
-		diff := byteSize - len(hex)
-		for i := 0; i < len(hex); i++ {
-			tmp[i+diff] = hex[i]
+			return nil, &WordError{Position: i, Word: v}
 		}
-		hex = tmp
+		indexes[i] = index
 	}
 
-	validationHex := addChecksum(entropyHex)
-	if len(validationHex) != byteSize {
-		tmp2 := make([]byte, byteSize)
-		diff2 := byteSize - len(validationHex)
-		for i := 0; i < len(validationHex); i++ {
-			tmp2[i+diff2] = validationHex[i]
-		}
-		validationHex = tmp2
+	entropy, err := entropyFromIndexes(indexes)
+	if err != nil {
+		return nil, err
 	}
 
-	if len(hex) != len(validationHex) {
-		panic("[]byte len mismatch - it shouldn't happen")
-	}
-	for i := range validationHex {
-		if hex[i] != validationHex[i] {
-			return nil, fmt.Errorf("Invalid byte at position %v", i)
-		}
+	byteSize := len(mnemonicSlice)*11/8 + 1
+
+	return padByteSlice(addChecksum(entropy), byteSize), nil
+}
+
+// EntropyFromMnemonic converts a mnemonic back to the original entropy.
+// The word list is detected from the mnemonic, the checksum is verified and
+// ErrInvalidWordCount, ErrChecksumIncorrect or *WordError is returned on failure.
+func EntropyFromMnemonic(mnemonic string) ([]byte, error) {
+	wordlist, err := DetectWordlist(mnemonic)
+	if err != nil {
+		wordlist = WordlistEnglish
 	}
-	return hex, nil
+
+	return EntropyFromMnemonicWithWordlist(mnemonic, wordlist)
 }
 
 // NewSeedWithErrorChecking creates a hashed seed output given the mnemonic string and a password.
-// An error is returned if the mnemonic is not convertible to a byte array.
+// An error is returned if the mnemonic is not convertible to entropy.
 func NewSeedWithErrorChecking(mnemonic string, password string) ([]byte, error) {
-	_, err := MnemonicToByteArray(mnemonic)
+	entropy, err := EntropyFromMnemonic(mnemonic)
 	if err != nil {
 		return nil, err
 	}
+	secure.Wipe(entropy)
 	return NewSeed(mnemonic, password), nil
 }
 
+// NewSecretSeed is NewSeedWithErrorChecking with the seed kept in wipeable
+// memory, the caller should Wipe the seed after use.
+func NewSecretSeed(mnemonic string, password string) (*secure.SecretBytes, error) {
+	seed, err := NewSeedWithErrorChecking(mnemonic, password)
+	if err != nil {
+		return nil, err
+	}
+	return secure.SecretBytesFrom(seed), nil
+}
+
 // NewSeed creates a hashed seed output given a provided string and password.
+// Both are NFKD normalized as required by bip39.
 // No checking is performed to validate that the string provided is a valid mnemonic.
 func NewSeed(mnemonic string, password string) []byte {
-	return pbkdf2.Key([]byte(mnemonic), []byte("mnemonic"+password), 2048, 64, sha512.New)
+	words := []byte(normalize(mnemonic))
+	salt := []byte(normalize("mnemonic" + password))
+
+	defer secure.Wipe(words)
+	defer secure.Wipe(salt)
+
+	return pbkdf2.Key(words, salt, 2048, 64, sha512.New)
 }
 
 // Appends to data the first (len(data) / 32)bits of the result of sha256(data)
@@ -195,6 +205,38 @@ func addChecksum(data []byte) []byte {
 	return dataBigInt.Bytes()
 }
 
+// entropyFromIndexes rebuild entropy from 11 bits word indexes and verify the checksum
+func entropyFromIndexes(indexes []int) ([]byte, error) {
+	bitSize := len(indexes) * 11
+
+	if validateEntropyWithChecksumBitSize(bitSize) != nil {
+		return nil, ErrInvalidWordCount
+	}
+
+	checksumBitLength := uint(bitSize / 33)
+	entropyBitLength := bitSize - int(checksumBitLength)
+
+	b := big.NewInt(0)
+	for _, index := range indexes {
+		b.Lsh(b, 11)
+		b.Or(b, big.NewInt(int64(index)))
+	}
+
+	checksumMask := new(big.Int).Sub(new(big.Int).Lsh(BigOne, checksumBitLength), BigOne)
+	checksum := new(big.Int).And(b, checksumMask)
+
+	entropy := padByteSlice(new(big.Int).Rsh(b, checksumBitLength).Bytes(), entropyBitLength/8)
+
+	hash := sha256.Sum256(entropy)
+	expected := uint64(hash[0] >> (8 - checksumBitLength))
+
+	if checksum.Uint64() != expected {
+		return nil, ErrChecksumIncorrect
+	}
+
+	return entropy, nil
+}
+
 func padByteSlice(slice []byte, length int) []byte {
 	newSlice := make([]byte, length-len(slice))
 	return append(newSlice, slice...)
@@ -231,19 +273,10 @@ func IsMnemonicValid(mnemonic string) bool {
 
 	// Check if all words belong in the wordlist
 	for i := 0; i < numOfWords; i++ {
-		if !contains(WordList, words[i]) {
+		if _, ok := ReverseWordMap[words[i]]; !ok {
 			return false
 		}
 	}
 
 	return true
 }
-
-func contains(s []string, e string) bool {
-	for _, a := range s {
-		if a == e {
-			return true
-		}
-	}
-	return false
-}
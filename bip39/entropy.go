@@ -0,0 +1,156 @@
+package bip39
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"unicode"
+)
+
+// EntropyBase kind of user supplied entropy
+type EntropyBase int
+
+// User entropy sources
+const (
+	EntropyBaseCoin EntropyBase = 2 // coin flips written as 0 and 1
+	EntropyBaseDice EntropyBase = 6 // dice rolls written as 1 to 6
+)
+
+// EntropyError user input is too short for the requested strength
+type EntropyError struct {
+	Base     EntropyBase
+	Symbols  int // symbols given
+	Required int // symbols required
+}
+
+func (e *EntropyError) Error() string {
+	return fmt.Sprintf("%d %s symbols give %d bits of entropy, %d symbols required",
+		e.Symbols, e.Base, int(float64(e.Symbols)*e.Base.BitsPerSymbol()), e.Required)
+}
+
+// UserEntropy entropy generated from dice rolls or coin flips
+type UserEntropy struct {
+	Entropy []byte
+	Bits    int  // bits of entropy contributed by user input
+	Mixed   bool // mixed with system randomness, the mnemonic can not be reproduced from the input
+}
+
+// BitsPerSymbol entropy of one fair roll or flip
+func (base EntropyBase) BitsPerSymbol() float64 {
+	return math.Log2(float64(base))
+}
+
+// SymbolsRequired count of rolls or flips needed for bitSize bits of entropy
+func (base EntropyBase) SymbolsRequired(bitSize int) int {
+	return int(math.Ceil(float64(bitSize) / base.BitsPerSymbol()))
+}
+
+func (base EntropyBase) String() string {
+	switch base {
+	case EntropyBaseCoin:
+		return "coin"
+	case EntropyBaseDice:
+		return "dice"
+	}
+
+	return fmt.Sprintf("base %d", int(base))
+}
+
+// NewEntropyFromUserInput create bitSize bits of entropy from dice rolls or coin
+// flips, whitespace in input is ignored. The entropy is the leading bitSize bits
+// of sha256 of the input digits, so without mixing the mnemonic can be checked
+// offline, e.g. printf %s 1625... | sha256sum. With mixSystem the entropy is
+// additionally xored with crypto/rand output
+func NewEntropyFromUserInput(input string, base EntropyBase, bitSize int, mixSystem bool) (*UserEntropy, error) {
+	if err := validateEntropyBitSize(bitSize); err != nil || bitSize > sha256.Size*8 {
+		return nil, errors.New("Entropy length must be [128, 256] and a multiple of 32")
+	}
+
+	symbols, err := userEntropySymbols(input, base)
+
+	if err != nil {
+		return nil, err
+	}
+
+	bits := int(float64(len(symbols)) * base.BitsPerSymbol())
+
+	if bits < bitSize {
+		return nil, &EntropyError{Base: base, Symbols: len(symbols), Required: base.SymbolsRequired(bitSize)}
+	}
+
+	hash := sha256.Sum256([]byte(symbols))
+
+	entropy := &UserEntropy{
+		Entropy: hash[:bitSize/8],
+		Bits:    bits,
+		Mixed:   mixSystem,
+	}
+
+	if mixSystem {
+		random := make([]byte, bitSize/8)
+
+		if _, err := rand.Read(random); err != nil {
+			return nil, err
+		}
+
+		for i := range entropy.Entropy {
+			entropy.Entropy[i] ^= random[i]
+		}
+	}
+
+	return entropy, nil
+}
+
+// NewMnemonicFromUserInput create mnemonic from dice rolls or coin flips with
+// special word list, see NewEntropyFromUserInput
+func NewMnemonicFromUserInput(input string, base EntropyBase, bitSize int, mixSystem bool, wordlist *Wordlist) (string, *UserEntropy, error) {
+	entropy, err := NewEntropyFromUserInput(input, base, bitSize, mixSystem)
+
+	if err != nil {
+		return "", nil, err
+	}
+
+	mnemonic, err := NewMnemonicWithWordlist(entropy.Entropy, wordlist)
+
+	if err != nil {
+		return "", nil, err
+	}
+
+	return mnemonic, entropy, nil
+}
+
+// userEntropySymbols strip whitespace and check every symbol belongs to base
+func userEntropySymbols(input string, base EntropyBase) (string, error) {
+	var first, last rune
+
+	switch base {
+	case EntropyBaseCoin:
+		first, last = '0', '1'
+	case EntropyBaseDice:
+		first, last = '1', '6'
+	default:
+		return "", fmt.Errorf("unsupported entropy base :%d", int(base))
+	}
+
+	var symbols strings.Builder
+
+	position := 0
+
+	for _, r := range input {
+		if unicode.IsSpace(r) {
+			continue
+		}
+
+		if r < first || r > last {
+			return "", fmt.Errorf("invalid %s symbol `%c` at position %d", base, r, position)
+		}
+
+		symbols.WriteRune(r)
+		position++
+	}
+
+	return symbols.String(), nil
+}
@@ -0,0 +1,76 @@
+package bip39
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"strings"
+	"testing"
+)
+
+func TestNewEntropyFromDice(t *testing.T) {
+	rolls := strings.Repeat("1625 3", 10)
+
+	entropy, err := NewEntropyFromUserInput(rolls, EntropyBaseDice, 128, false)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	hash := sha256.Sum256([]byte(strings.Repeat("16253", 10)))
+
+	if !bytes.Equal(entropy.Entropy, hash[:16]) {
+		t.Fatalf("expect %x got %x", hash[:16], entropy.Entropy)
+	}
+
+	if entropy.Bits != 129 || entropy.Mixed {
+		t.Fatalf("expect 129 unmixed bits got %d", entropy.Bits)
+	}
+
+	_, err = NewEntropyFromUserInput(rolls, EntropyBaseDice, 256, false)
+
+	if e, ok := err.(*EntropyError); !ok || e.Symbols != 50 || e.Required != 100 {
+		t.Fatalf("expect entropy error got %v", err)
+	}
+
+	if _, err = NewEntropyFromUserInput(rolls+"7", EntropyBaseDice, 128, false); err == nil {
+		t.Fatal("expect invalid symbol error")
+	}
+}
+
+func TestNewMnemonicFromCoins(t *testing.T) {
+	flips := strings.Repeat("01", 64)
+
+	mnemonic, entropy, err := NewMnemonicFromUserInput(flips, EntropyBaseCoin, 128, false, WordlistEnglish)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expect, err := NewEntropyFromUserInput(flips, EntropyBaseCoin, 128, false)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Equal(entropy.Entropy, expect.Entropy) || entropy.Bits != 128 {
+		t.Fatalf("expect deterministic entropy got %x", entropy.Entropy)
+	}
+
+	if !IsMnemonicValidWithWordlist(mnemonic, WordlistEnglish) {
+		t.Fatalf("invalid mnemonic %s", mnemonic)
+	}
+
+	mixed, err := NewEntropyFromUserInput(flips, EntropyBaseCoin, 128, true)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !mixed.Mixed || bytes.Equal(mixed.Entropy, expect.Entropy) {
+		t.Fatal("expect entropy mixed with system randomness")
+	}
+
+	if _, err = NewEntropyFromUserInput(flips[1:], EntropyBaseCoin, 128, false); err == nil {
+		t.Fatal("expect insufficient entropy error")
+	}
+}
@@ -0,0 +1,187 @@
+package bip39
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// UniquePrefixLen bip39 word lists are designed so that the first four
+// letters identify a word, shorter prefixes are never expanded
+const UniquePrefixLen = 4
+
+// WordStatus validation result of one mnemonic word
+type WordStatus int
+
+// Word validation results
+const (
+	WordValid      WordStatus = iota // word is in the word list
+	WordExpanded                     // unique prefix expanded to the full word
+	WordIncomplete                   // prefix of more than one word, or too short to expand
+	WordUnknown                      // no word starts with the input
+)
+
+func (status WordStatus) String() string {
+	switch status {
+	case WordValid:
+		return "valid"
+	case WordExpanded:
+		return "expanded from unique prefix"
+	case WordIncomplete:
+		return "incomplete word"
+	case WordUnknown:
+		return "unknown word"
+	}
+
+	return "invalid status"
+}
+
+// Complete get words starting with prefix in word list order, at most limit
+// words are returned if limit > 0
+func (wordlist *Wordlist) Complete(prefix string, limit int) []string {
+	node := wordlist.prefixes.find(normalize(strings.TrimSpace(prefix)))
+
+	if node == nil {
+		return nil
+	}
+
+	indexes := node.indexes(limit)
+
+	words := make([]string, len(indexes))
+
+	for i, index := range indexes {
+		words[i] = wordlist.words[index]
+	}
+
+	return words
+}
+
+// Resolve get the full word for an exact word or a unique prefix of at least
+// UniquePrefixLen letters
+func (wordlist *Wordlist) Resolve(input string) (string, WordStatus) {
+	input = normalize(strings.TrimSpace(input))
+
+	if index, ok := wordlist.index[input]; ok {
+		return wordlist.words[index], WordValid
+	}
+
+	node := wordlist.prefixes.find(input)
+
+	if node == nil || input == "" {
+		return "", WordUnknown
+	}
+
+	if node.count != 1 || utf8.RuneCountInString(input) < UniquePrefixLen {
+		return "", WordIncomplete
+	}
+
+	return wordlist.words[node.indexes(1)[0]], WordExpanded
+}
+
+// WordCheck validation result of the word at Position
+type WordCheck struct {
+	Position    int
+	Input       string
+	Word        string // resolved full word, empty if Status is incomplete or unknown
+	Status      WordStatus
+	Completions []string // candidate words of incomplete input
+}
+
+// MnemonicCheck validation result of a whole mnemonic
+type MnemonicCheck struct {
+	Words     []WordCheck
+	Err       error // nil if mnemonic is valid
+	separator string
+}
+
+// maxCompletions completion candidates reported for incomplete words
+const maxCompletions = 8
+
+// CheckMnemonic validate mnemonic typed by user word by word, words may be
+// given in the unique prefix form. Err is a *WordError for the first word that
+// can not be resolved, ErrInvalidWordCount or ErrChecksumIncorrect
+func CheckMnemonic(mnemonic string, wordlist *Wordlist) *MnemonicCheck {
+	inputs := splitMnemonic(mnemonic)
+
+	check := &MnemonicCheck{
+		Words:     make([]WordCheck, len(inputs)),
+		separator: wordlist.separator,
+	}
+
+	indexes := make([]int, 0, len(inputs))
+
+	for i, input := range inputs {
+		word, status := wordlist.Resolve(input)
+
+		check.Words[i] = WordCheck{
+			Position: i,
+			Input:    input,
+			Word:     word,
+			Status:   status,
+		}
+
+		switch status {
+		case WordValid, WordExpanded:
+			index, _ := wordlist.Index(word)
+			indexes = append(indexes, index)
+		case WordIncomplete:
+			check.Words[i].Completions = wordlist.Complete(input, maxCompletions)
+			fallthrough
+		default:
+			if check.Err == nil {
+				check.Err = &WordError{Position: i, Word: input}
+			}
+		}
+	}
+
+	if check.Err == nil {
+		_, check.Err = entropyFromIndexes(indexes)
+	}
+
+	return check
+}
+
+// Valid check if mnemonic is complete and has valid checksum
+func (check *MnemonicCheck) Valid() bool {
+	return check.Err == nil
+}
+
+// InvalidPositions positions of words that are incomplete or unknown
+func (check *MnemonicCheck) InvalidPositions() []int {
+	var positions []int
+
+	for _, word := range check.Words {
+		if word.Status == WordIncomplete || word.Status == WordUnknown {
+			positions = append(positions, word.Position)
+		}
+	}
+
+	return positions
+}
+
+// Mnemonic get mnemonic with prefixes expanded to full words, unresolved
+// words are kept as typed
+func (check *MnemonicCheck) Mnemonic() string {
+	words := make([]string, len(check.Words))
+
+	for i, word := range check.Words {
+		if word.Word != "" {
+			words[i] = word.Word
+		} else {
+			words[i] = word.Input
+		}
+	}
+
+	return strings.Join(words, check.separator)
+}
+
+// ExpandMnemonic convert mnemonic in the unique prefix form into full words,
+// the mnemonic must be valid
+func ExpandMnemonic(mnemonic string, wordlist *Wordlist) (string, error) {
+	check := CheckMnemonic(mnemonic, wordlist)
+
+	if check.Err != nil {
+		return "", check.Err
+	}
+
+	return check.Mnemonic(), nil
+}
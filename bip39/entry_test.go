@@ -0,0 +1,117 @@
+package bip39
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestComplete(t *testing.T) {
+	words := WordlistEnglish.Complete("aba", 0)
+
+	if strings.Join(words, " ") != "abandon" {
+		t.Fatalf("expect [abandon] got %v", words)
+	}
+
+	words = WordlistEnglish.Complete("ab", 0)
+
+	if len(words) != 10 || words[0] != "abandon" || words[9] != "abuse" {
+		t.Fatalf("unexpected completions %v", words)
+	}
+
+	if words = WordlistEnglish.Complete("ab", 3); len(words) != 3 {
+		t.Fatalf("expect 3 completions got %v", words)
+	}
+
+	if words = WordlistEnglish.Complete("xyz", 0); len(words) != 0 {
+		t.Fatalf("expect no completions got %v", words)
+	}
+
+	if len(WordlistEnglish.Complete("", 0)) != 2048 {
+		t.Fatal("expect empty prefix completes all words")
+	}
+}
+
+func TestResolve(t *testing.T) {
+	tests := []struct {
+		input  string
+		word   string
+		status WordStatus
+	}{
+		{"act", "act", WordValid},
+		{"acti", "action", WordExpanded},
+		{"actr", "actress", WordExpanded},
+		{"sausag", "sausage", WordExpanded},
+		{"abo", "", WordIncomplete},
+		{"acto", "actor", WordExpanded},
+		{"zz", "", WordUnknown},
+		{"", "", WordUnknown},
+	}
+
+	for _, test := range tests {
+		word, status := WordlistEnglish.Resolve(test.input)
+
+		if word != test.word || status != test.status {
+			t.Fatalf("%s: expect %s %s got %s %s", test.input, test.word, test.status, word, status)
+		}
+	}
+}
+
+func TestCheckMnemonic(t *testing.T) {
+	var prefixes []string
+
+	for _, word := range strings.Fields(testMnemonic) {
+		if len(word) > UniquePrefixLen {
+			word = word[:UniquePrefixLen]
+		}
+
+		prefixes = append(prefixes, word)
+	}
+
+	mnemonic, err := ExpandMnemonic(strings.Join(prefixes, " "), WordlistEnglish)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if mnemonic != testMnemonic {
+		t.Fatalf("expect %s got %s", testMnemonic, mnemonic)
+	}
+
+	check := CheckMnemonic("legal winn thank yea wave sausage xxxx useful legal winner thank yellow", WordlistEnglish)
+
+	if check.Valid() {
+		t.Fatal("expect invalid mnemonic")
+	}
+
+	if positions := check.InvalidPositions(); len(positions) != 2 || positions[0] != 3 || positions[1] != 6 {
+		t.Fatalf("expect invalid positions [3 6] got %v", positions)
+	}
+
+	if check.Words[1].Status != WordExpanded || check.Words[1].Word != "winner" {
+		t.Fatalf("expect winner expanded got %v", check.Words[1])
+	}
+
+	if check.Words[3].Status != WordIncomplete || !containsWord(check.Words[3].Completions, "year") {
+		t.Fatalf("expect year in completions got %v", check.Words[3])
+	}
+
+	if check.Words[6].Status != WordUnknown {
+		t.Fatalf("expect unknown word got %v", check.Words[6])
+	}
+
+	if e, ok := check.Err.(*WordError); !ok || e.Position != 3 {
+		t.Fatalf("expect word error at 3 got %v", check.Err)
+	}
+
+	check = CheckMnemonic(strings.Replace(testMnemonic, "yellow", "wave", 1), WordlistEnglish)
+
+	if check.Err != ErrChecksumIncorrect || len(check.InvalidPositions()) != 0 {
+		t.Fatalf("expect checksum error got %v", check.Err)
+	}
+
+	check = CheckMnemonic("legal winner thank", WordlistEnglish)
+
+	if check.Err != ErrInvalidWordCount {
+		t.Fatalf("expect word count error got %v", check.Err)
+	}
+}
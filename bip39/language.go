@@ -0,0 +1,211 @@
+package bip39
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// Language bip39 word list language name
+type Language string
+
+// Supported languages
+const (
+	LanguageEnglish            Language = "english"
+	LanguageChineseSimplified  Language = "chinese_simplified"
+	LanguageChineseTraditional Language = "chinese_traditional"
+	LanguageJapanese           Language = "japanese"
+	LanguageKorean             Language = "korean"
+	LanguageSpanish            Language = "spanish"
+	LanguageFrench             Language = "french"
+	LanguageItalian            Language = "italian"
+	LanguageCzech              Language = "czech"
+)
+
+// ErrUnknownLanguage mnemonic words not belong to any supported word list
+var ErrUnknownLanguage = errors.New("mnemonic language can not be detected")
+
+// Wordlist immutable bip39 word list, unlike WordList and SetWordList
+// it is safe for concurrent use
+type Wordlist struct {
+	language  Language
+	words     []string
+	index     map[string]int
+	prefixes  *trie
+	separator string
+}
+
+// Official word lists
+var (
+	WordlistEnglish            = newWordlist(LanguageEnglish, EnglishWordList, " ")
+	WordlistChineseSimplified  = newWordlist(LanguageChineseSimplified, ChineseWordList, " ")
+	WordlistChineseTraditional = newWordlist(LanguageChineseTraditional, ChineseTraditionalWordList, " ")
+	WordlistJapanese           = newWordlist(LanguageJapanese, JapaneseWordList, "\u3000")
+	WordlistKorean             = newWordlist(LanguageKorean, KoreanWordList, " ")
+	WordlistSpanish            = newWordlist(LanguageSpanish, SpanishWordList, " ")
+	WordlistFrench             = newWordlist(LanguageFrench, FrenchWordList, " ")
+	WordlistItalian            = newWordlist(LanguageItalian, ItalianWordList, " ")
+	WordlistCzech              = newWordlist(LanguageCzech, CzechWordList, " ")
+)
+
+// wordlists in detection preference order
+var wordlists = []*Wordlist{
+	WordlistEnglish,
+	WordlistChineseSimplified,
+	WordlistChineseTraditional,
+	WordlistJapanese,
+	WordlistKorean,
+	WordlistSpanish,
+	WordlistFrench,
+	WordlistItalian,
+	WordlistCzech,
+}
+
+func newWordlist(language Language, words []string, separator string) *Wordlist {
+	index := make(map[string]int, len(words))
+
+	for i, word := range words {
+		index[normalize(word)] = i
+	}
+
+	return &Wordlist{
+		language:  language,
+		words:     words,
+		index:     index,
+		prefixes:  newTrie(words),
+		separator: separator,
+	}
+}
+
+// GetWordlist get official word list by language
+func GetWordlist(language Language) (*Wordlist, error) {
+	for _, wordlist := range wordlists {
+		if wordlist.language == language {
+			return wordlist, nil
+		}
+	}
+
+	return nil, fmt.Errorf("unsupported bip39 language :%s", language)
+}
+
+// Language word list language
+func (wordlist *Wordlist) Language() Language {
+	return wordlist.language
+}
+
+// Len word list size
+func (wordlist *Wordlist) Len() int {
+	return len(wordlist.words)
+}
+
+// Word get word by index
+func (wordlist *Wordlist) Word(index int) string {
+	return wordlist.words[index]
+}
+
+// Words get copy of all words
+func (wordlist *Wordlist) Words() []string {
+	return append([]string(nil), wordlist.words...)
+}
+
+// Index get word index, the word is NFKD normalized before lookup
+func (wordlist *Wordlist) Index(word string) (int, bool) {
+	index, ok := wordlist.index[normalize(word)]
+
+	return index, ok
+}
+
+// Contains check if word is in the word list
+func (wordlist *Wordlist) Contains(word string) bool {
+	_, ok := wordlist.Index(word)
+
+	return ok
+}
+
+// Separator word separator used when generating mnemonic
+func (wordlist *Wordlist) Separator() string {
+	return wordlist.separator
+}
+
+// DetectWordlist detect mnemonic language, when words belong to several lists
+// the one with valid checksum is preferred
+func DetectWordlist(mnemonic string) (*Wordlist, error) {
+	words := splitMnemonic(mnemonic)
+
+	if len(words) == 0 {
+		return nil, ErrUnknownLanguage
+	}
+
+	var candidates []*Wordlist
+
+	for _, wordlist := range wordlists {
+		matched := true
+
+		for _, word := range words {
+			if !wordlist.Contains(word) {
+				matched = false
+				break
+			}
+		}
+
+		if matched {
+			candidates = append(candidates, wordlist)
+		}
+	}
+
+	if len(candidates) == 0 {
+		return nil, ErrUnknownLanguage
+	}
+
+	for _, wordlist := range candidates {
+		if _, err := EntropyFromMnemonicWithWordlist(mnemonic, wordlist); err == nil {
+			return wordlist, nil
+		}
+	}
+
+	return candidates[0], nil
+}
+
+// NewMnemonicWithWordlist create mnemonic for the given entropy with special word list
+func NewMnemonicWithWordlist(entropy []byte, wordlist *Wordlist) (string, error) {
+	return newMnemonic(entropy, wordlist.words, wordlist.separator)
+}
+
+// EntropyFromMnemonicWithWordlist convert mnemonic back to the original entropy
+// using special word list, errors are the same as EntropyFromMnemonic
+func EntropyFromMnemonicWithWordlist(mnemonic string, wordlist *Wordlist) ([]byte, error) {
+	words := splitMnemonic(mnemonic)
+
+	indexes := make([]int, len(words))
+
+	for i, word := range words {
+		index, ok := wordlist.Index(word)
+
+		if !ok {
+			return nil, &WordError{Position: i, Word: word}
+		}
+
+		indexes[i] = index
+	}
+
+	return entropyFromIndexes(indexes)
+}
+
+// IsMnemonicValidWithWordlist check words count, word membership and checksum
+// with special word list
+func IsMnemonicValidWithWordlist(mnemonic string, wordlist *Wordlist) bool {
+	_, err := EntropyFromMnemonicWithWordlist(mnemonic, wordlist)
+
+	return err == nil
+}
+
+func splitMnemonic(mnemonic string) []string {
+	return strings.Fields(normalize(mnemonic))
+}
+
+// normalize apply unicode NFKD normalization required by bip39
+func normalize(s string) string {
+	return norm.NFKD.String(s)
+}
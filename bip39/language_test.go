@@ -0,0 +1,100 @@
+package bip39
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestWordlists(t *testing.T) {
+	for _, wordlist := range wordlists {
+		if wordlist.Len() != 2048 || len(wordlist.index) != 2048 {
+			t.Fatalf("%s word list expect 2048 unique words got %d", wordlist.Language(), len(wordlist.index))
+		}
+	}
+
+	if WordlistChineseSimplified.Word(0) != "的" {
+		t.Fatalf("unexpected simplified chinese word list")
+	}
+}
+
+func TestMnemonicWithWordlist(t *testing.T) {
+	for _, wordlist := range wordlists {
+		for _, bitSize := range []int{128, 160, 192, 224, 256} {
+			entropy, err := NewEntropy(bitSize)
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			mnemonic, err := NewMnemonicWithWordlist(entropy, wordlist)
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			decoded, err := EntropyFromMnemonicWithWordlist(mnemonic, wordlist)
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !bytes.Equal(decoded, entropy) {
+				t.Fatalf("%s expect %x got %x", wordlist.Language(), entropy, decoded)
+			}
+
+			detected, err := DetectWordlist(mnemonic)
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if _, err := EntropyFromMnemonicWithWordlist(mnemonic, detected); err != nil {
+				t.Fatalf("%s detected as %s: %s", wordlist.Language(), detected.Language(), err)
+			}
+		}
+	}
+}
+
+func TestDetectWordlist(t *testing.T) {
+	entropy := bytes.Repeat([]byte{0x7f}, 16)
+
+	mnemonic, err := NewMnemonicWithWordlist(entropy, WordlistCzech)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	wordlist, err := DetectWordlist(mnemonic)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if wordlist != WordlistCzech {
+		t.Fatalf("expect czech got %s", wordlist.Language())
+	}
+
+	if _, err := DetectWordlist("bitcoin ethereum"); err != ErrUnknownLanguage {
+		t.Fatalf("expect ErrUnknownLanguage got %v", err)
+	}
+}
+
+func TestNormalization(t *testing.T) {
+	// the same spanish word in composed and decomposed form
+	composed := "\u00e1baco"
+	decomposed := "a\u0301baco"
+
+	index, ok := WordlistSpanish.Index(composed)
+
+	if !ok || index != 0 {
+		t.Fatalf("expect index 0 got %d", index)
+	}
+
+	if index2, _ := WordlistSpanish.Index(decomposed); index2 != index {
+		t.Fatalf("expect %d got %d", index, index2)
+	}
+
+	if !bytes.Equal(NewSeed(composed, composed), NewSeed(decomposed, decomposed)) {
+		t.Fatal("seed must be computed from NFKD normalized input")
+	}
+}
@@ -0,0 +1,346 @@
+package bip39
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+)
+
+// MaxSuggestDistance max edit distance supported by mnemonic suggestion
+const MaxSuggestDistance = 2
+
+// maxRecoverCombinations limit of candidate mnemonics checked by SuggestMnemonics
+const maxRecoverCombinations = 1 << 20
+
+// ErrTooManyCombinations too many unknown words to search all candidates
+var ErrTooManyCombinations = errors.New("too many candidate mnemonics, fix some words first")
+
+// SuggestWords get words within maxDistance edits of word, ordered by distance.
+// Insert, delete, substitute and transpose of two adjacent letters count as one edit.
+// maxDistance is clamped to [0, MaxSuggestDistance]
+func SuggestWords(word string, wordlist *Wordlist, maxDistance int) []string {
+	if maxDistance > MaxSuggestDistance {
+		maxDistance = MaxSuggestDistance
+	}
+
+	if maxDistance < 0 {
+		maxDistance = 0
+	}
+
+	source := []rune(normalize(word))
+
+	buckets := make([][]string, maxDistance+1)
+
+	for _, candidate := range wordlist.words {
+		distance := editDistance(source, []rune(normalize(candidate)), maxDistance)
+
+		if distance <= maxDistance {
+			buckets[distance] = append(buckets[distance], candidate)
+		}
+	}
+
+	var words []string
+
+	for _, bucket := range buckets {
+		words = append(words, bucket...)
+	}
+
+	return words
+}
+
+// LastWordCandidates get all words which complete the given 11, 14, 17, 20 or 23
+// words into a checksum valid mnemonic
+func LastWordCandidates(mnemonic string, wordlist *Wordlist) ([]string, error) {
+	words := splitMnemonic(mnemonic)
+
+	if (len(words)+1)%3 != 0 || len(words) < 11 || len(words) > 23 {
+		return nil, fmt.Errorf("expect 11, 14, 17, 20 or 23 words got %d", len(words))
+	}
+
+	indexes, err := wordIndexes(words, wordlist)
+
+	if err != nil {
+		return nil, err
+	}
+
+	indexes = append(indexes, 0)
+
+	var candidates []string
+
+	for i := range wordlist.words {
+		indexes[len(indexes)-1] = i
+
+		if _, err := entropyFromIndexes(indexes); err == nil {
+			candidates = append(candidates, wordlist.words[i])
+		}
+	}
+
+	return candidates, nil
+}
+
+// SuggestMnemonics get checksum valid mnemonics close to a mistyped one.
+// Unknown words are replaced by words within maxDistance edits, when all words
+// are known but the checksum fails a single word replacement or a swap of two
+// adjacent words is tried. A valid mnemonic is returned as is
+func SuggestMnemonics(mnemonic string, wordlist *Wordlist, maxDistance int) ([]string, error) {
+	words := splitMnemonic(mnemonic)
+
+	if validateEntropyWithChecksumBitSize(len(words)*11) != nil {
+		return nil, ErrInvalidWordCount
+	}
+
+	if _, err := EntropyFromMnemonicWithWordlist(mnemonic, wordlist); err == nil {
+		return []string{joinWords(words, wordlist)}, nil
+	}
+
+	choices := make([][]string, len(words))
+	combinations := 1
+	unknown := false
+
+	for i, word := range words {
+		if wordlist.Contains(word) {
+			choices[i] = []string{word}
+			continue
+		}
+
+		unknown = true
+
+		choices[i] = SuggestWords(word, wordlist, maxDistance)
+
+		combinations *= len(choices[i])
+
+		if combinations == 0 {
+			return nil, nil
+		}
+
+		if combinations > maxRecoverCombinations {
+			return nil, ErrTooManyCombinations
+		}
+	}
+
+	if unknown {
+		return validCombinations(choices, wordlist), nil
+	}
+
+	var candidates []string
+
+	seen := make(map[string]bool)
+
+	add := func(candidate []string) {
+		if isChecksumValid(candidate, wordlist) {
+			sentence := joinWords(candidate, wordlist)
+
+			if !seen[sentence] {
+				seen[sentence] = true
+				candidates = append(candidates, sentence)
+			}
+		}
+	}
+
+	candidate := make([]string, len(words))
+
+	for i, word := range words {
+		copy(candidate, words)
+
+		for _, replacement := range SuggestWords(word, wordlist, maxDistance) {
+			if normalize(replacement) == word {
+				continue
+			}
+
+			candidate[i] = replacement
+
+			add(candidate)
+		}
+	}
+
+	for i := 0; i+1 < len(words); i++ {
+		if words[i] == words[i+1] {
+			continue
+		}
+
+		copy(candidate, words)
+
+		candidate[i], candidate[i+1] = candidate[i+1], candidate[i]
+
+		add(candidate)
+	}
+
+	return candidates, nil
+}
+
+// RankMnemonics sort candidates by the score reported by check in descending order,
+// e.g. the number of on chain transactions of the derived address.
+// Candidates with zero score are dropped
+func RankMnemonics(candidates []string, check func(mnemonic string) (int, error)) ([]string, error) {
+	type scored struct {
+		mnemonic string
+		score    int
+	}
+
+	var ranked []scored
+
+	for _, candidate := range candidates {
+		score, err := check(candidate)
+
+		if err != nil {
+			return nil, err
+		}
+
+		if score > 0 {
+			ranked = append(ranked, scored{candidate, score})
+		}
+	}
+
+	sort.SliceStable(ranked, func(i, j int) bool {
+		return ranked[i].score > ranked[j].score
+	})
+
+	result := make([]string, len(ranked))
+
+	for i, r := range ranked {
+		result[i] = r.mnemonic
+	}
+
+	return result, nil
+}
+
+func validCombinations(choices [][]string, wordlist *Wordlist) []string {
+	var candidates []string
+
+	current := make([]string, len(choices))
+
+	var walk func(position int)
+
+	walk = func(position int) {
+		if position == len(choices) {
+			if isChecksumValid(current, wordlist) {
+				candidates = append(candidates, joinWords(current, wordlist))
+			}
+
+			return
+		}
+
+		for _, word := range choices[position] {
+			current[position] = word
+			walk(position + 1)
+		}
+	}
+
+	walk(0)
+
+	return candidates
+}
+
+func isChecksumValid(words []string, wordlist *Wordlist) bool {
+	indexes, err := wordIndexes(words, wordlist)
+
+	if err != nil {
+		return false
+	}
+
+	_, err = entropyFromIndexes(indexes)
+
+	return err == nil
+}
+
+func wordIndexes(words []string, wordlist *Wordlist) ([]int, error) {
+	indexes := make([]int, len(words))
+
+	for i, word := range words {
+		index, ok := wordlist.Index(word)
+
+		if !ok {
+			return nil, &WordError{Position: i, Word: word}
+		}
+
+		indexes[i] = index
+	}
+
+	return indexes, nil
+}
+
+// joinWords join words with the word list separator using the word list spelling
+func joinWords(words []string, wordlist *Wordlist) string {
+	sentence := ""
+
+	for i, word := range words {
+		if i > 0 {
+			sentence += wordlist.separator
+		}
+
+		if index, ok := wordlist.Index(word); ok {
+			word = wordlist.words[index]
+		}
+
+		sentence += word
+	}
+
+	return sentence
+}
+
+// editDistance optimal string alignment distance, returns maxDistance+1 early
+// when the distance exceeds maxDistance
+func editDistance(a, b []rune, maxDistance int) int {
+	if abs(len(a)-len(b)) > maxDistance {
+		return maxDistance + 1
+	}
+
+	prev2 := make([]int, len(b)+1)
+	prev := make([]int, len(b)+1)
+	current := make([]int, len(b)+1)
+
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(a); i++ {
+		current[0] = i
+		rowMin := current[0]
+
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+
+			current[j] = min3(prev[j]+1, current[j-1]+1, prev[j-1]+cost)
+
+			if i > 1 && j > 1 && a[i-1] == b[j-2] && a[i-2] == b[j-1] && prev2[j-2]+1 < current[j] {
+				current[j] = prev2[j-2] + 1
+			}
+
+			if current[j] < rowMin {
+				rowMin = current[j]
+			}
+		}
+
+		if rowMin > maxDistance {
+			return maxDistance + 1
+		}
+
+		prev2, prev, current = prev, current, prev2
+	}
+
+	return prev[len(b)]
+}
+
+func min3(a, b, c int) int {
+	if b < a {
+		a = b
+	}
+
+	if c < a {
+		a = c
+	}
+
+	return a
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+
+	return n
+}
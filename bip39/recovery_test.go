@@ -0,0 +1,104 @@
+package bip39
+
+import (
+	"strings"
+	"testing"
+)
+
+const testMnemonic = "legal winner thank year wave sausage worth useful legal winner thank yellow"
+
+func TestSuggestWords(t *testing.T) {
+	words := SuggestWords("winner", WordlistEnglish, 1)
+
+	if len(words) == 0 || words[0] != "winner" {
+		t.Fatalf("expect exact match first got %v", words)
+	}
+
+	for typo, expect := range map[string]string{"wnner": "winner", "tahnk": "thank", "yelllow": "yellow"} {
+		if !containsWord(SuggestWords(typo, WordlistEnglish, 1), expect) {
+			t.Fatalf("expect %s in suggestions of %s", expect, typo)
+		}
+	}
+
+	for _, maxDistance := range []int{0, -1, -5} {
+		words := SuggestWords("winner", WordlistEnglish, maxDistance)
+
+		if len(words) != 1 || words[0] != "winner" {
+			t.Fatalf("distance %d: expect exact match only got %v", maxDistance, words)
+		}
+	}
+
+	mnemonics, err := SuggestMnemonics("legal wnner thank year wave sausage worth useful legal winner thank yellow", WordlistEnglish, -5)
+
+	if err != nil || len(mnemonics) != 0 {
+		t.Fatalf("expect no suggestion without edits got %v %v", mnemonics, err)
+	}
+}
+
+func containsWord(words []string, word string) bool {
+	for _, w := range words {
+		if w == word {
+			return true
+		}
+	}
+
+	return false
+}
+
+func TestSuggestMnemonics(t *testing.T) {
+	typos := []string{
+		strings.Replace(testMnemonic, "sausage", "sausag", 1),
+		strings.Replace(testMnemonic, "worth useful", "useful worth", 1),
+		strings.Replace(testMnemonic, "wave", "save", 1),
+	}
+
+	for _, typo := range typos {
+		candidates, err := SuggestMnemonics(typo, WordlistEnglish, 1)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		for _, candidate := range candidates {
+			if !IsMnemonicValidWithWordlist(candidate, WordlistEnglish) {
+				t.Fatalf("invalid candidate %s", candidate)
+			}
+		}
+
+		if !containsWord(candidates, testMnemonic) {
+			t.Fatalf("%s: expect %s in %v", typo, testMnemonic, candidates)
+		}
+	}
+}
+
+func TestLastWordCandidates(t *testing.T) {
+	words := strings.Fields(testMnemonic)
+
+	candidates, err := LastWordCandidates(strings.Join(words[:11], " "), WordlistEnglish)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(candidates) != 128 {
+		t.Fatalf("expect 128 candidates got %d", len(candidates))
+	}
+
+	if !containsWord(candidates, words[11]) {
+		t.Fatalf("expect %s in candidates", words[11])
+	}
+}
+
+func TestRankMnemonics(t *testing.T) {
+	ranked, err := RankMnemonics([]string{"a", "b", "c"}, func(mnemonic string) (int, error) {
+		return map[string]int{"b": 3, "c": 1}[mnemonic], nil
+	})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if strings.Join(ranked, ",") != "b,c" {
+		t.Fatalf("expect b,c got %v", ranked)
+	}
+}
@@ -0,0 +1,167 @@
+package bip39
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"strconv"
+
+	"github.com/goany/qrcode"
+)
+
+// ErrInvalidSeedQR payload is neither a SeedQR digit stream nor a CompactSeedQR
+var ErrInvalidSeedQR = errors.New("invalid SeedQR payload")
+
+// seedQRWords SeedQR only defines 12 and 24 words mnemonics
+func seedQRWords(words int) error {
+	if words != 12 && words != 24 {
+		return fmt.Errorf("SeedQR supports 12 or 24 words mnemonic, got %d", words)
+	}
+
+	return nil
+}
+
+// SeedQRDigits encode mnemonic as standard SeedQR digit stream, the zero
+// padded four digits word index of each word
+func SeedQRDigits(mnemonic string, wordlist *Wordlist) (string, error) {
+	words := splitMnemonic(mnemonic)
+
+	if err := seedQRWords(len(words)); err != nil {
+		return "", err
+	}
+
+	if _, err := EntropyFromMnemonicWithWordlist(mnemonic, wordlist); err != nil {
+		return "", err
+	}
+
+	digits := make([]byte, 0, len(words)*4)
+
+	for _, word := range words {
+		index, _ := wordlist.Index(word)
+
+		digits = append(digits, fmt.Sprintf("%04d", index)...)
+	}
+
+	return string(digits), nil
+}
+
+// MnemonicFromSeedQRDigits decode standard SeedQR digit stream, the checksum is verified
+func MnemonicFromSeedQRDigits(digits string, wordlist *Wordlist) (string, error) {
+	if len(digits)%4 != 0 || seedQRWords(len(digits)/4) != nil {
+		return "", ErrInvalidSeedQR
+	}
+
+	indexes := make([]int, len(digits)/4)
+
+	for i := range indexes {
+		index, err := strconv.Atoi(digits[i*4 : i*4+4])
+
+		if err != nil || index < 0 || index >= wordlist.Len() {
+			return "", ErrInvalidSeedQR
+		}
+
+		indexes[i] = index
+	}
+
+	entropy, err := entropyFromIndexes(indexes)
+
+	if err != nil {
+		return "", err
+	}
+
+	return NewMnemonicWithWordlist(entropy, wordlist)
+}
+
+// CompactSeedQR encode mnemonic as CompactSeedQR, the raw entropy without checksum
+func CompactSeedQR(mnemonic string, wordlist *Wordlist) ([]byte, error) {
+	if err := seedQRWords(len(splitMnemonic(mnemonic))); err != nil {
+		return nil, err
+	}
+
+	return EntropyFromMnemonicWithWordlist(mnemonic, wordlist)
+}
+
+// MnemonicFromCompactSeedQR decode CompactSeedQR of 16 or 32 bytes
+func MnemonicFromCompactSeedQR(data []byte, wordlist *Wordlist) (string, error) {
+	if len(data) != 16 && len(data) != 32 {
+		return "", ErrInvalidSeedQR
+	}
+
+	return NewMnemonicWithWordlist(data, wordlist)
+}
+
+// MnemonicFromSeedQR decode scanned payload of either SeedQR format, formats
+// are told apart by payload length
+func MnemonicFromSeedQR(payload []byte, wordlist *Wordlist) (string, error) {
+	switch len(payload) {
+	case 16, 32:
+		return MnemonicFromCompactSeedQR(payload, wordlist)
+	case 48, 96:
+		return MnemonicFromSeedQRDigits(string(payload), wordlist)
+	}
+
+	return "", ErrInvalidSeedQR
+}
+
+// SeedQRCode render mnemonic as SeedQR or CompactSeedQR code with error correction level L
+func SeedQRCode(mnemonic string, compact bool, wordlist *Wordlist) (*qrcode.Code, error) {
+	if compact {
+		data, err := CompactSeedQR(mnemonic, wordlist)
+
+		if err != nil {
+			return nil, err
+		}
+
+		return qrcode.Encode(qrcode.LevelL, qrcode.ByteSegment(data))
+	}
+
+	digits, err := SeedQRDigits(mnemonic, wordlist)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return qrcode.Encode(qrcode.LevelL, qrcode.NumericSegment(digits))
+}
+
+// MnemonicFromSeedQRMatrix decode SeedQR or CompactSeedQR module matrix
+func MnemonicFromSeedQRMatrix(matrix [][]bool, wordlist *Wordlist) (string, error) {
+	segments, err := qrcode.Decode(matrix)
+
+	if err != nil {
+		return "", err
+	}
+
+	return mnemonicFromSeedQRSegments(segments, wordlist)
+}
+
+// MnemonicFromSeedQRImage decode upright SeedQR or CompactSeedQR image
+func MnemonicFromSeedQRImage(img image.Image, wordlist *Wordlist) (string, error) {
+	segments, err := qrcode.DecodeImage(img)
+
+	if err != nil {
+		return "", err
+	}
+
+	return mnemonicFromSeedQRSegments(segments, wordlist)
+}
+
+func mnemonicFromSeedQRSegments(segments []qrcode.Segment, wordlist *Wordlist) (string, error) {
+	var payload []byte
+
+	compact := false
+
+	for _, segment := range segments {
+		payload = append(payload, segment.Data...)
+
+		if segment.Mode == qrcode.ModeByte {
+			compact = true
+		}
+	}
+
+	if compact {
+		return MnemonicFromCompactSeedQR(payload, wordlist)
+	}
+
+	return MnemonicFromSeedQRDigits(string(payload), wordlist)
+}
@@ -0,0 +1,127 @@
+package bip39
+
+import (
+	"bytes"
+	"image/png"
+	"testing"
+)
+
+var seedQRVectors = []struct {
+	mnemonic string
+	digits   string
+}{
+	{
+		"forum undo fragile fade shy sign arrest garment culture tube off merit",
+		"073318950739065415961602009907670428187212261116",
+	},
+	{
+		"attack pizza motion avocado network gather crop fresh patrol unusual wild holiday candy pony ranch winter theme error hybrid van cereal salon goddess expire",
+		"011513251154012711900771041507421289190620080870026613431420201617920614089619290300152408010643",
+	},
+}
+
+func TestSeedQRDigits(t *testing.T) {
+	for _, vector := range seedQRVectors {
+		digits, err := SeedQRDigits(vector.mnemonic, WordlistEnglish)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if digits != vector.digits {
+			t.Fatalf("expect %s got %s", vector.digits, digits)
+		}
+
+		mnemonic, err := MnemonicFromSeedQR([]byte(digits), WordlistEnglish)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if mnemonic != vector.mnemonic {
+			t.Fatalf("expect %s got %s", vector.mnemonic, mnemonic)
+		}
+	}
+
+	if _, err := MnemonicFromSeedQRDigits("073318950739065415961602009907670428187212261117", WordlistEnglish); err != ErrChecksumIncorrect {
+		t.Fatalf("expect checksum error got %v", err)
+	}
+
+	if _, err := MnemonicFromSeedQRDigits("07331895073906541596160200990767042818721226111x", WordlistEnglish); err != ErrInvalidSeedQR {
+		t.Fatalf("expect invalid payload got %v", err)
+	}
+}
+
+func TestCompactSeedQR(t *testing.T) {
+	for _, vector := range seedQRVectors {
+		data, err := CompactSeedQR(vector.mnemonic, WordlistEnglish)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		entropy, err := EntropyFromMnemonic(vector.mnemonic)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !bytes.Equal(data, entropy) {
+			t.Fatalf("expect %x got %x", entropy, data)
+		}
+
+		mnemonic, err := MnemonicFromSeedQR(data, WordlistEnglish)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if mnemonic != vector.mnemonic {
+			t.Fatalf("expect %s got %s", vector.mnemonic, mnemonic)
+		}
+	}
+}
+
+func TestSeedQRCode(t *testing.T) {
+	sizes := map[bool][]int{false: {25, 29}, true: {21, 25}}
+
+	for _, compact := range []bool{false, true} {
+		for i, vector := range seedQRVectors {
+			code, err := SeedQRCode(vector.mnemonic, compact, WordlistEnglish)
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if code.Size != sizes[compact][i] {
+				t.Fatalf("expect size %d got %d", sizes[compact][i], code.Size)
+			}
+
+			mnemonic, err := MnemonicFromSeedQRMatrix(code.Matrix(), WordlistEnglish)
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if mnemonic != vector.mnemonic {
+				t.Fatalf("expect %s got %s", vector.mnemonic, mnemonic)
+			}
+
+			data, err := code.PNG(4)
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			img, err := png.Decode(bytes.NewReader(data))
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if mnemonic, err = MnemonicFromSeedQRImage(img, WordlistEnglish); err != nil || mnemonic != vector.mnemonic {
+				t.Fatalf("expect %s got %s %v", vector.mnemonic, mnemonic, err)
+			}
+		}
+	}
+}
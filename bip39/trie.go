@@ -0,0 +1,85 @@
+package bip39
+
+import "sort"
+
+// trie prefix tree of word list, words are stored NFKD normalized
+type trie struct {
+	children map[rune]*trie
+	index    int // word index, -1 for inner node
+	count    int // number of words below this node
+}
+
+func newTrie(words []string) *trie {
+	root := &trie{index: -1}
+
+	for i, word := range words {
+		root.insert(normalize(word), i)
+	}
+
+	return root
+}
+
+func (node *trie) insert(word string, index int) {
+	node.count++
+
+	for _, r := range word {
+		if node.children == nil {
+			node.children = make(map[rune]*trie)
+		}
+
+		child, ok := node.children[r]
+
+		if !ok {
+			child = &trie{index: -1}
+			node.children[r] = child
+		}
+
+		child.count++
+
+		node = child
+	}
+
+	node.index = index
+}
+
+// find get node of prefix, nil if no word has this prefix
+func (node *trie) find(prefix string) *trie {
+	for _, r := range prefix {
+		child, ok := node.children[r]
+
+		if !ok {
+			return nil
+		}
+
+		node = child
+	}
+
+	return node
+}
+
+// indexes word indexes below node in word list order, at most limit if limit > 0
+func (node *trie) indexes(limit int) []int {
+	result := make([]int, 0, node.count)
+
+	var walk func(n *trie)
+
+	walk = func(n *trie) {
+		if n.index >= 0 {
+			result = append(result, n.index)
+		}
+
+		for _, child := range n.children {
+			walk(child)
+		}
+	}
+
+	walk(node)
+
+	sort.Ints(result)
+
+	if limit > 0 && len(result) > limit {
+		result = result[:limit]
+	}
+
+	return result
+}
@@ -18,14 +18,16 @@ func init() {
 
 // SetWordList .
 func SetWordList(wordlist []string) {
-	WordList = wordlist
-	for i, v := range WordList {
-		ReverseWordMap[v] = i
+	reverse := make(map[string]int, len(wordlist))
+	for i, v := range wordlist {
+		reverse[v] = i
 	}
+	WordList = wordlist
+	ReverseWordMap = reverse
 }
 
-// ChineseWordList .
-var ChineseWordList = strings.Split(englishWordList, "\n")
+// ChineseWordList official bip39 simplified chinese word list
+var ChineseWordList = strings.Split(strings.TrimSpace(chineseWordList), "\n")
 var chineseWordList = `的
 一
 是
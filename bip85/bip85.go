@@ -0,0 +1,179 @@
+package bip85
+
+import (
+	"crypto/hmac"
+	"crypto/sha512"
+	"encoding/hex"
+	"errors"
+	"fmt"
+
+	"github.com/btcsuite/btcd/btcec"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcutil"
+	"github.com/goany/bip39"
+	"github.com/goany/hdkey"
+	"github.com/goany/secure"
+)
+
+// Purpose bip85 derivation purpose, every path starts with m/83696968'
+const Purpose uint32 = 83696968
+
+// bip85 application numbers
+const (
+	AppBIP39 uint32 = 39
+	AppWIF   uint32 = 2
+	AppXPRV  uint32 = 32
+	AppHex   uint32 = 128169
+)
+
+var entropyKey = []byte("bip-entropy-from-k")
+
+// bip85 errors
+var (
+	ErrInvalidWordCount = errors.New("bip85 mnemonic must have 12, 18 or 24 words")
+	ErrInvalidHexLen    = errors.New("bip85 hex length must be between 16 and 64 bytes")
+)
+
+// languageCodes bip85 language code of each bip39 word list
+var languageCodes = map[bip39.Language]uint32{
+	bip39.LanguageEnglish:            0,
+	bip39.LanguageJapanese:           1,
+	bip39.LanguageKorean:             2,
+	bip39.LanguageSpanish:            3,
+	bip39.LanguageChineseSimplified:  4,
+	bip39.LanguageChineseTraditional: 5,
+	bip39.LanguageFrench:             6,
+	bip39.LanguageItalian:            7,
+	bip39.LanguageCzech:              8,
+}
+
+// RootFromMnemonic create bip85 root key from bip39 mnemonic and passphrase
+func RootFromMnemonic(mnemonic string, passphrase string) (*hdkey.Key, error) {
+	seed, err := bip39.NewSeedWithErrorChecking(mnemonic, passphrase)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer secure.Wipe(seed)
+
+	return hdkey.NewMasterKey(seed)
+}
+
+// DeriveEntropy derive 64 bytes entropy from root key with hardened path
+// m/83696968'/{path...}, all path indexes are hardened. The derived child key
+// is wiped, callers wipe the returned entropy
+func DeriveEntropy(root *hdkey.Key, path ...uint32) ([]byte, error) {
+	if !root.IsPrivate() {
+		return nil, hdkey.ErrNotPrivate
+	}
+
+	indexes := make([]uint32, 0, len(path)+1)
+
+	for _, index := range append([]uint32{Purpose}, path...) {
+		if index >= hdkey.HardenedKeyStart {
+			return nil, fmt.Errorf("bip85 path index %d out of range", index)
+		}
+
+		indexes = append(indexes, index+hdkey.HardenedKeyStart)
+	}
+
+	key, err := root.DerivePath(indexes)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer key.Wipe()
+
+	k, err := key.PrivateKeyBytes()
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer secure.Wipe(k)
+
+	mac := hmac.New(sha512.New, entropyKey)
+	mac.Write(k)
+
+	return mac.Sum(nil), nil
+}
+
+// DeriveMnemonic derive child bip39 mnemonic of 12, 18 or 24 words with
+// special word list at m/83696968'/39'/{language}'/{words}'/{index}'
+func DeriveMnemonic(root *hdkey.Key, wordlist *bip39.Wordlist, words int, index uint32) (string, error) {
+	if words != 12 && words != 18 && words != 24 {
+		return "", ErrInvalidWordCount
+	}
+
+	language, ok := languageCodes[wordlist.Language()]
+
+	if !ok {
+		return "", fmt.Errorf("bip85 does not support language :%s", wordlist.Language())
+	}
+
+	entropy, err := DeriveEntropy(root, AppBIP39, language, uint32(words), index)
+
+	if err != nil {
+		return "", err
+	}
+
+	defer secure.Wipe(entropy)
+
+	return bip39.NewMnemonicWithWordlist(entropy[:words*4/3], wordlist)
+}
+
+// DeriveWIF derive compressed mainnet wif private key at m/83696968'/2'/{index}'
+func DeriveWIF(root *hdkey.Key, index uint32) (string, error) {
+	entropy, err := DeriveEntropy(root, AppWIF, index)
+
+	if err != nil {
+		return "", err
+	}
+
+	defer secure.Wipe(entropy)
+
+	priv, _ := btcec.PrivKeyFromBytes(btcec.S256(), entropy[:32])
+
+	defer secure.WipePrivateKey(priv.ToECDSA())
+
+	wif, err := btcutil.NewWIF(priv, &chaincfg.MainNetParams, true)
+
+	if err != nil {
+		return "", err
+	}
+
+	return wif.String(), nil
+}
+
+// DeriveXPRV derive master extended private key at m/83696968'/32'/{index}',
+// the first 32 bytes of entropy are the chain code and the last 32 the key
+func DeriveXPRV(root *hdkey.Key, index uint32) (*hdkey.Key, error) {
+	entropy, err := DeriveEntropy(root, AppXPRV, index)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer secure.Wipe(entropy)
+
+	return hdkey.NewPrivateMasterKey(entropy[:32], entropy[32:], hdkey.MainNetPrivate)
+}
+
+// DeriveHex derive numBytes of hex encoded entropy at m/83696968'/128169'/{numBytes}'/{index}'
+func DeriveHex(root *hdkey.Key, numBytes int, index uint32) (string, error) {
+	if numBytes < 16 || numBytes > 64 {
+		return "", ErrInvalidHexLen
+	}
+
+	entropy, err := DeriveEntropy(root, AppHex, uint32(numBytes), index)
+
+	if err != nil {
+		return "", err
+	}
+
+	defer secure.Wipe(entropy)
+
+	return hex.EncodeToString(entropy[:numBytes]), nil
+}
@@ -0,0 +1,118 @@
+package bip85
+
+import (
+	"encoding/hex"
+	"testing"
+
+	"github.com/goany/bip39"
+	"github.com/goany/hdkey"
+)
+
+const testRoot = "xprv9s21ZrQH143K2LBWUUQRFXhucrQqBpKdRRxNVq2zBqsx8HVqFk2uYo8kmbaLLHRdqtQpUm98uKfu3vca1LqdGhUtyoFnCNkfmXRyPXLjbKb"
+
+func testRootKey(t *testing.T) *hdkey.Key {
+	root, err := hdkey.ParseKey(testRoot)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return root
+}
+
+func TestDeriveEntropy(t *testing.T) {
+	root := testRootKey(t)
+
+	tests := []struct {
+		index   uint32
+		entropy string
+	}{
+		{0, "efecfbccffea313214232d29e71563d941229afb4338c21f9517c41aaa0d16f00b83d2a09ef747e7a64e8e2bd5a14869e693da66ce94ac2da570ab7ee48618f7"},
+		{1, "70c6e3e8ebee8dc4c0dbba66076819bb8c09672527c4277ca8729532ad711872218f826919f6b67218adde99018a6df9095ab2b58d803b5b93ec9802085a690e"},
+	}
+
+	for _, test := range tests {
+		entropy, err := DeriveEntropy(root, 0, test.index)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if hex.EncodeToString(entropy) != test.entropy {
+			t.Fatalf("expect %s got %x", test.entropy, entropy)
+		}
+	}
+}
+
+func TestDeriveMnemonic(t *testing.T) {
+	root := testRootKey(t)
+
+	tests := []struct {
+		words    int
+		mnemonic string
+	}{
+		{12, "girl mad pet galaxy egg matter matrix prison refuse sense ordinary nose"},
+		{18, "near account window bike charge season chef number sketch tomorrow excuse sniff circle vital hockey outdoor supply token"},
+		{24, "puppy ocean match cereal symbol another shed magic wrap hammer bulb intact gadget divorce twin tonight reason outdoor destroy simple truth cigar social volcano"},
+	}
+
+	for _, test := range tests {
+		mnemonic, err := DeriveMnemonic(root, bip39.WordlistEnglish, test.words, 0)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if mnemonic != test.mnemonic {
+			t.Fatalf("expect %s got %s", test.mnemonic, mnemonic)
+		}
+	}
+
+	if _, err := DeriveMnemonic(root, bip39.WordlistEnglish, 15, 0); err != ErrInvalidWordCount {
+		t.Fatalf("expect word count error got %v", err)
+	}
+}
+
+func TestDeriveWIF(t *testing.T) {
+	wif, err := DeriveWIF(testRootKey(t), 0)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if wif != "Kzyv4uF39d4Jrw2W7UryTHwZr1zQVNk4dAFyqE6BuMrMh1Za7uhp" {
+		t.Fatalf("unexpected wif %s", wif)
+	}
+}
+
+func TestDeriveXPRV(t *testing.T) {
+	key, err := DeriveXPRV(testRootKey(t), 0)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expect := "xprv9s21ZrQH143K2srSbCSg4m4kLvPMzcWydgmKEnMmoZUurYuBuYG46c6P71UGXMzmriLzCCBvKQWBUv3vPB3m1SATMhp3uEjXHJ42jFg7myX"
+
+	if key.String() != expect {
+		t.Fatalf("expect %s got %s", expect, key.String())
+	}
+}
+
+func TestDeriveHex(t *testing.T) {
+	entropy, err := DeriveHex(testRootKey(t), 64, 0)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expect := "492db4698cf3b73a5a24998aa3e9d7fa96275d85724a91e71aa2d645442f878555d078fd1f1f67e368976f04137b1f7a0d19232136ca50c44614af72b5582a5c"
+
+	if entropy != expect {
+		t.Fatalf("expect %s got %s", expect, entropy)
+	}
+
+	if _, err := DeriveHex(testRootKey(t), 8, 0); err != ErrInvalidHexLen {
+		t.Fatalf("expect hex length error got %v", err)
+	}
+}
@@ -0,0 +1,513 @@
+package btc
+
+import (
+	crand "crypto/rand"
+	"encoding/binary"
+	"errors"
+	"math/rand"
+	"sort"
+
+	"github.com/btcsuite/btcutil"
+)
+
+// DefaultLongTermFeeRate fee rate in satoshis per vbyte expected when the
+// change output is spent later, used by the waste metric
+const DefaultLongTermFeeRate btcutil.Amount = 10
+
+// bnbMaxTries search steps of branch and bound before it gives up
+const bnbMaxTries = 100000
+
+// Coin selection errors
+var (
+	ErrInsufficientFunds = errors.New("not enough funds for coin selection")
+	ErrNoChangeless      = errors.New("no changeless coin selection found")
+)
+
+// CoinSelectionParams payment to fund, weights are in weight units and fee
+// rates in satoshis per vbyte
+type CoinSelectionParams struct {
+	// Amount sum of the payment outputs
+	Amount          btcutil.Amount
+	FeeRate         btcutil.Amount
+	LongTermFeeRate btcutil.Amount
+	// BaseWeight transaction weight without inputs and change output
+	BaseWeight int
+	// ChangeWeight weight of the change output
+	ChangeWeight int
+	// ChangeSpendWeight weight of the input spending the change output later
+	ChangeSpendWeight int
+	// DustLimit smallest change output worth creating
+	DustLimit btcutil.Amount
+	// InputWeight weight of the signed input spending utxo
+	InputWeight func(utxo UTXO) (int, error)
+	// SubtractFee the payment outputs pay the fee instead of the inputs,
+	// the excess of changeless selections is added to them
+	SubtractFee bool
+}
+
+// CoinSelection selected inputs with the resulting fee and change, Waste is
+// the fee paid now above the long term fee rate plus the cost of change, or
+// the excess dropped to fees of changeless selections
+type CoinSelection struct {
+	Inputs []UTXO
+	Fee    btcutil.Amount
+	Change btcutil.Amount
+	Waste  btcutil.Amount
+}
+
+// CoinSelector utxo selection strategy
+type CoinSelector interface {
+	Select(utxos []UTXO, params *CoinSelectionParams) (*CoinSelection, error)
+}
+
+// fee fee of weight at rate, rounded up to whole vbytes
+func fee(weight int, rate btcutil.Amount) btcutil.Amount {
+	return btcutil.Amount((weight+3)/4) * rate
+}
+
+// costOfChange fee of creating and later spending the change output
+func (params *CoinSelectionParams) costOfChange() btcutil.Amount {
+	return fee(params.ChangeWeight, params.FeeRate) + fee(params.ChangeSpendWeight, params.LongTermFeeRate)
+}
+
+// target amount the effective values of the inputs must cover without change
+func (params *CoinSelectionParams) target() btcutil.Amount {
+	return params.Amount + params.inputsFee(params.BaseWeight)
+}
+
+// inputsFee fee of weight paid by the inputs
+func (params *CoinSelectionParams) inputsFee(weight int) btcutil.Amount {
+	if params.SubtractFee {
+		return 0
+	}
+
+	return fee(weight, params.FeeRate)
+}
+
+// effectiveValue utxo value minus the fee of spending it
+func (params *CoinSelectionParams) effectiveValue(utxo UTXO) (btcutil.Amount, error) {
+	weight, err := params.InputWeight(utxo)
+
+	if err != nil {
+		return 0, err
+	}
+
+	return btcutil.Amount(utxo.Satoshis) - params.inputsFee(weight), nil
+}
+
+// spendable utxos with positive effective value
+func (params *CoinSelectionParams) spendable(utxos []UTXO) ([]UTXO, error) {
+	result := make([]UTXO, 0, len(utxos))
+
+	for _, utxo := range utxos {
+		value, err := params.effectiveValue(utxo)
+
+		if err != nil {
+			return nil, err
+		}
+
+		if value > 0 {
+			result = append(result, utxo)
+		}
+	}
+
+	return result, nil
+}
+
+// Selection fee, change and waste of spending inputs, ErrInsufficientFunds
+// is returned if inputs do not cover the amount and fee
+func (params *CoinSelectionParams) Selection(inputs []UTXO) (*CoinSelection, error) {
+	return params.selection(inputs, false)
+}
+
+// selection of inputs, the excess of changeless selections goes to fees
+func (params *CoinSelectionParams) selection(inputs []UTXO, changeless bool) (*CoinSelection, error) {
+	var (
+		total       btcutil.Amount
+		inputWeight int
+	)
+
+	for _, utxo := range inputs {
+		weight, err := params.InputWeight(utxo)
+
+		if err != nil {
+			return nil, err
+		}
+
+		total += btcutil.Amount(utxo.Satoshis)
+		inputWeight += weight
+	}
+
+	weight := params.BaseWeight + inputWeight
+
+	if len(inputs) == 0 || total < params.Amount+params.inputsFee(weight) {
+		return nil, ErrInsufficientFunds
+	}
+
+	selection := &CoinSelection{
+		Inputs: inputs,
+		Waste:  fee(inputWeight, params.FeeRate) - fee(inputWeight, params.LongTermFeeRate),
+	}
+
+	change := total - params.Amount - params.inputsFee(weight+params.ChangeWeight)
+
+	switch {
+	case !changeless && change >= params.DustLimit:
+		selection.Change = change
+		selection.Fee = fee(weight+params.ChangeWeight, params.FeeRate)
+		selection.Waste += params.costOfChange()
+	case params.SubtractFee:
+		selection.Fee = fee(weight, params.FeeRate)
+	default:
+		selection.Fee = total - params.Amount
+		selection.Waste += selection.Fee - fee(weight, params.FeeRate)
+	}
+
+	return selection, nil
+}
+
+// accumulate select utxos in order until the payment is funded
+func accumulate(utxos []UTXO, params *CoinSelectionParams) (*CoinSelection, error) {
+	for i := range utxos {
+		selection, err := params.Selection(utxos[:i+1])
+
+		if err == ErrInsufficientFunds {
+			continue
+		}
+
+		return selection, err
+	}
+
+	return nil, ErrInsufficientFunds
+}
+
+// InOrderSelector select utxos in the given order until the payment is funded
+type InOrderSelector struct{}
+
+// Select implement CoinSelector
+func (InOrderSelector) Select(utxos []UTXO, params *CoinSelectionParams) (*CoinSelection, error) {
+	return accumulate(utxos, params)
+}
+
+// LargestFirstSelector select the largest utxos first, giving few inputs
+type LargestFirstSelector struct{}
+
+// Select implement CoinSelector
+func (LargestFirstSelector) Select(utxos []UTXO, params *CoinSelectionParams) (*CoinSelection, error) {
+	spendable, err := params.spendable(utxos)
+
+	if err != nil {
+		return nil, err
+	}
+
+	sort.SliceStable(spendable, func(i, j int) bool {
+		return spendable[i].Satoshis > spendable[j].Satoshis
+	})
+
+	return accumulate(spendable, params)
+}
+
+// SmallestFirstSelector select the smallest utxos first, consolidating dust
+// at low fee rates
+type SmallestFirstSelector struct{}
+
+// Select implement CoinSelector
+func (SmallestFirstSelector) Select(utxos []UTXO, params *CoinSelectionParams) (*CoinSelection, error) {
+	spendable, err := params.spendable(utxos)
+
+	if err != nil {
+		return nil, err
+	}
+
+	sort.SliceStable(spendable, func(i, j int) bool {
+		return spendable[i].Satoshis < spendable[j].Satoshis
+	})
+
+	return accumulate(spendable, params)
+}
+
+// BranchAndBoundSelector search the changeless selection with the least
+// waste whose effective value falls between the target and the target plus
+// the cost of change. Fallback is used if no such selection exists
+type BranchAndBoundSelector struct {
+	Fallback CoinSelector
+}
+
+// Select implement CoinSelector
+func (selector BranchAndBoundSelector) Select(utxos []UTXO, params *CoinSelectionParams) (*CoinSelection, error) {
+	selection, err := branchAndBound(utxos, params)
+
+	if err == ErrNoChangeless && selector.Fallback != nil {
+		return selector.Fallback.Select(utxos, params)
+	}
+
+	return selection, err
+}
+
+func branchAndBound(utxos []UTXO, params *CoinSelectionParams) (*CoinSelection, error) {
+	spendable, err := params.spendable(utxos)
+
+	if err != nil {
+		return nil, err
+	}
+
+	values := make([]btcutil.Amount, len(spendable))
+	waste := make([]btcutil.Amount, len(spendable))
+
+	for i, utxo := range spendable {
+		weight, err := params.InputWeight(utxo)
+
+		if err != nil {
+			return nil, err
+		}
+
+		values[i] = btcutil.Amount(utxo.Satoshis) - params.inputsFee(weight)
+		waste[i] = fee(weight, params.FeeRate) - fee(weight, params.LongTermFeeRate)
+	}
+
+	order := make([]int, len(spendable))
+
+	for i := range order {
+		order[i] = i
+	}
+
+	sort.SliceStable(order, func(i, j int) bool {
+		return values[order[i]] > values[order[j]]
+	})
+
+	target := params.target()
+	upper := target + params.costOfChange()
+
+	var (
+		available btcutil.Amount
+		best      []int
+		bestWaste btcutil.Amount
+		current   []int
+		tries     int
+	)
+
+	for _, value := range values {
+		available += value
+	}
+
+	if available < target {
+		return nil, ErrInsufficientFunds
+	}
+
+	var search func(depth int, value btcutil.Amount, currentWaste btcutil.Amount, remaining btcutil.Amount)
+
+	search = func(depth int, value btcutil.Amount, currentWaste btcutil.Amount, remaining btcutil.Amount) {
+		if tries++; tries > bnbMaxTries {
+			return
+		}
+
+		// out of range, or more waste than the best selection when the fee
+		// rate is above the long term rate so further inputs only add waste
+		if value > upper || value+remaining < target ||
+			(best != nil && params.FeeRate > params.LongTermFeeRate && currentWaste > bestWaste) {
+			return
+		}
+
+		if value >= target {
+			total := currentWaste + value - target
+
+			if best == nil || total < bestWaste {
+				best = append([]int{}, current...)
+				bestWaste = total
+			}
+
+			return
+		}
+
+		if depth == len(order) {
+			return
+		}
+
+		i := order[depth]
+
+		// including i after excluding a previous utxo of the same value
+		// repeats an explored selection
+		previousExcluded := depth > 0 && (len(current) == 0 || current[len(current)-1] != order[depth-1])
+
+		if !previousExcluded || values[order[depth-1]] != values[i] {
+			current = append(current, i)
+			search(depth+1, value+values[i], currentWaste+waste[i], remaining-values[i])
+			current = current[:len(current)-1]
+		}
+
+		search(depth+1, value, currentWaste, remaining-values[i])
+	}
+
+	search(0, 0, 0, available)
+
+	if best == nil {
+		return nil, ErrNoChangeless
+	}
+
+	inputs := make([]UTXO, 0, len(best))
+
+	for _, i := range best {
+		inputs = append(inputs, spendable[i])
+	}
+
+	return params.selection(inputs, true)
+}
+
+// RandomImproveSelector CIP-2 random improve, utxos are picked at random
+// until the target is met, then more random utxos are added while they move
+// the selection towards twice the target, leaving change of similar size
+// to the payment. Rand nil uses a cryptographically seeded source
+type RandomImproveSelector struct {
+	Rand *rand.Rand
+}
+
+// Select implement CoinSelector
+func (selector RandomImproveSelector) Select(utxos []UTXO, params *CoinSelectionParams) (*CoinSelection, error) {
+	spendable, err := params.spendable(utxos)
+
+	if err != nil {
+		return nil, err
+	}
+
+	random := selector.Rand
+
+	if random == nil {
+		var seed [8]byte
+
+		if _, err := crand.Read(seed[:]); err != nil {
+			return nil, err
+		}
+
+		random = rand.New(rand.NewSource(int64(binary.LittleEndian.Uint64(seed[:]))))
+	}
+
+	random.Shuffle(len(spendable), func(i, j int) {
+		spendable[i], spendable[j] = spendable[j], spendable[i]
+	})
+
+	var (
+		selected []UTXO
+		value    btcutil.Amount
+	)
+
+	target := params.target()
+
+	for len(spendable) > 0 && value < target {
+		effective, err := params.effectiveValue(spendable[0])
+
+		if err != nil {
+			return nil, err
+		}
+
+		selected = append(selected, spendable[0])
+		spendable = spendable[1:]
+		value += effective
+	}
+
+	if value < target {
+		return nil, ErrInsufficientFunds
+	}
+
+	// improve towards twice the target without passing three times
+	ideal, limit := 2*target, 3*target
+
+	for _, utxo := range spendable {
+		effective, err := params.effectiveValue(utxo)
+
+		if err != nil {
+			return nil, err
+		}
+
+		if abs64(ideal-value-effective) < abs64(ideal-value) && value+effective <= limit {
+			selected = append(selected, utxo)
+			value += effective
+		}
+	}
+
+	selection, err := params.Selection(selected)
+
+	if err != ErrInsufficientFunds {
+		return selection, err
+	}
+
+	// rounding of the summed input fees can fall short of the total fee
+	return LargestFirstSelector{}.Select(utxos, params)
+}
+
+func abs64(n btcutil.Amount) btcutil.Amount {
+	if n < 0 {
+		return -n
+	}
+
+	return n
+}
+
+// PrivacySelector avoid linking addresses in one transaction. The utxos of
+// one address are always spent together, and the single address covering
+// the payment with the least waste is preferred. If no single address is
+// enough, whole addresses are added largest first
+type PrivacySelector struct{}
+
+// Select implement CoinSelector
+func (PrivacySelector) Select(utxos []UTXO, params *CoinSelectionParams) (*CoinSelection, error) {
+	var (
+		keys   []string
+		groups = make(map[string][]UTXO)
+		totals = make(map[string]btcutil.Amount)
+	)
+
+	for _, utxo := range utxos {
+		key := utxo.Address
+
+		if key == "" {
+			key = utxo.ScriptPubKey
+		}
+
+		if _, ok := groups[key]; !ok {
+			keys = append(keys, key)
+		}
+
+		groups[key] = append(groups[key], utxo)
+		totals[key] += btcutil.Amount(utxo.Satoshis)
+	}
+
+	var best *CoinSelection
+
+	for _, key := range keys {
+		selection, err := params.Selection(groups[key])
+
+		if err == ErrInsufficientFunds {
+			continue
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		if best == nil || selection.Waste < best.Waste {
+			best = selection
+		}
+	}
+
+	if best != nil {
+		return best, nil
+	}
+
+	sort.SliceStable(keys, func(i, j int) bool {
+		return totals[keys[i]] > totals[keys[j]]
+	})
+
+	var selected []UTXO
+
+	for _, key := range keys {
+		selected = append(selected, groups[key]...)
+
+		selection, err := params.Selection(selected)
+
+		if err != ErrInsufficientFunds {
+			return selection, err
+		}
+	}
+
+	return nil, ErrInsufficientFunds
+}
@@ -0,0 +1,197 @@
+package btc
+
+import (
+	"bytes"
+	"math/rand"
+	"testing"
+
+	"github.com/btcsuite/btcd/wire"
+	"github.com/btcsuite/btcutil"
+)
+
+// testSelectionParams 10000 satoshis at 1 sat/vbyte, every input is 68 vbytes
+func testSelectionParams() *CoinSelectionParams {
+	return &CoinSelectionParams{
+		Amount:            10000,
+		FeeRate:           1,
+		LongTermFeeRate:   DefaultLongTermFeeRate,
+		BaseWeight:        40 * 4,
+		ChangeWeight:      31 * 4,
+		ChangeSpendWeight: 68 * 4,
+		DustLimit:         294,
+		InputWeight: func(utxo UTXO) (int, error) {
+			return 68 * 4, nil
+		},
+	}
+}
+
+func testSelectionUTXOs() []UTXO {
+	return []UTXO{
+		{Address: "a", VOut: 0, Satoshis: 5000},
+		{Address: "b", VOut: 1, Satoshis: 20000},
+		{Address: "c", VOut: 2, Satoshis: 10108},
+		{Address: "a", VOut: 3, Satoshis: 3000},
+	}
+}
+
+func TestCoinSelectors(t *testing.T) {
+	for name, test := range map[string]struct {
+		selector CoinSelector
+		vouts    []uint32
+		change   btcutil.Amount
+	}{
+		"in order":      {InOrderSelector{}, []uint32{0, 1}, 14793},
+		"largest first": {LargestFirstSelector{}, []uint32{1}, 9861},
+		"smallest":      {SmallestFirstSelector{}, []uint32{3, 0, 2}, 7833},
+		"bnb":           {BranchAndBoundSelector{}, []uint32{2}, 0},
+		"privacy":       {PrivacySelector{}, []uint32{2}, 0},
+	} {
+		params := testSelectionParams()
+
+		selection, err := test.selector.Select(testSelectionUTXOs(), params)
+
+		if err != nil {
+			t.Fatalf("%s: %s", name, err)
+		}
+
+		if len(selection.Inputs) != len(test.vouts) {
+			t.Fatalf("%s: expect %d inputs got %d", name, len(test.vouts), len(selection.Inputs))
+		}
+
+		var total btcutil.Amount
+
+		for i, utxo := range selection.Inputs {
+			if utxo.VOut != test.vouts[i] {
+				t.Fatalf("%s: expect input %d got %d", name, test.vouts[i], utxo.VOut)
+			}
+
+			total += btcutil.Amount(utxo.Satoshis)
+		}
+
+		if selection.Change != test.change {
+			t.Fatalf("%s: expect change %d got %d", name, test.change, selection.Change)
+		}
+
+		if total != params.Amount+selection.Fee+selection.Change {
+			t.Fatalf("%s: fee %d and change %d do not balance", name, selection.Fee, selection.Change)
+		}
+
+		expected, err := params.Selection(selection.Inputs)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if test.change == 0 && expected.Change == 0 && expected.Waste != selection.Waste {
+			t.Fatalf("%s: expect waste %d got %d", name, expected.Waste, selection.Waste)
+		}
+	}
+}
+
+func TestBranchAndBound(t *testing.T) {
+	params := testSelectionParams()
+
+	selection, err := BranchAndBoundSelector{}.Select(testSelectionUTXOs(), params)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// exact match pays 68 vbytes input fee at 1 sat/vbyte instead of 10
+	if selection.Fee != 108 || selection.Waste != 68-680 {
+		t.Fatalf("unexpected fee %d waste %d", selection.Fee, selection.Waste)
+	}
+
+	utxos := []UTXO{{VOut: 0, Satoshis: 20000}, {VOut: 1, Satoshis: 30000}}
+
+	if _, err := (BranchAndBoundSelector{}).Select(utxos, params); err != ErrNoChangeless {
+		t.Fatalf("expect no changeless error got %v", err)
+	}
+
+	selection, err = BranchAndBoundSelector{Fallback: LargestFirstSelector{}}.Select(utxos, params)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(selection.Inputs) != 1 || selection.Inputs[0].VOut != 1 {
+		t.Fatalf("expect fallback largest input got %v", selection.Inputs)
+	}
+}
+
+func TestRandomImprove(t *testing.T) {
+	var utxos []UTXO
+
+	for i := 0; i < 20; i++ {
+		utxos = append(utxos, UTXO{VOut: uint32(i), Satoshis: float64(1000 * (i + 1))})
+	}
+
+	params := testSelectionParams()
+
+	for seed := int64(0); seed < 10; seed++ {
+		selection, err := RandomImproveSelector{Rand: rand.New(rand.NewSource(seed))}.Select(utxos, params)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var total btcutil.Amount
+
+		for _, utxo := range selection.Inputs {
+			total += btcutil.Amount(utxo.Satoshis)
+		}
+
+		if total != params.Amount+selection.Fee+selection.Change {
+			t.Fatalf("seed %d: fee %d and change %d do not balance", seed, selection.Fee, selection.Change)
+		}
+
+		if total > 3*params.target()+btcutil.Amount(len(selection.Inputs))*68 {
+			t.Fatalf("seed %d: selected %d above three times the target", seed, total)
+		}
+	}
+}
+
+func TestInsufficientFunds(t *testing.T) {
+	params := testSelectionParams()
+	params.Amount = 1000000
+
+	for _, selector := range []CoinSelector{
+		InOrderSelector{},
+		LargestFirstSelector{},
+		SmallestFirstSelector{},
+		BranchAndBoundSelector{},
+		RandomImproveSelector{},
+		PrivacySelector{},
+	} {
+		if _, err := selector.Select(testSelectionUTXOs(), params); err != ErrInsufficientFunds {
+			t.Fatalf("%T: expect insufficient funds error got %v", selector, err)
+		}
+	}
+}
+
+func TestPayCoinSelector(t *testing.T) {
+	wallet := testWallet(t, WithAddressType(AddressTypeP2WPKH))
+
+	utxos := []UTXO{
+		testUTXO(t, wallet, 30000, 0),
+		testUTXO(t, wallet, 60000, 1),
+	}
+
+	var buff bytes.Buffer
+
+	if err := wallet.Pay(utxos, testPayTo, 50000, 2, &buff, WithCoinSelector(LargestFirstSelector{})); err != nil {
+		t.Fatal(err)
+	}
+
+	var tx wire.MsgTx
+
+	if err := tx.Deserialize(bytes.NewReader(buff.Bytes())); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(tx.TxIn) != 1 || tx.TxIn[0].PreviousOutPoint.Index != 1 {
+		t.Fatalf("expect the largest utxo spent alone got %d inputs", len(tx.TxIn))
+	}
+
+	verifyTx(t, &tx, utxos)
+}
@@ -0,0 +1,122 @@
+package btc
+
+import (
+	"errors"
+	"io"
+
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/btcsuite/btcutil"
+)
+
+// Child pays for parent errors
+var (
+	ErrCPFPParent = errors.New("invalid cpfp parent size or fee")
+	ErrCPFPFunds  = errors.New("utxo can not pay the cpfp child fee")
+)
+
+// CPFP sign child spending utxo of an unconfirmed parent transaction back to
+// the wallet address, the child fee brings the parent and child package to
+// feeRate. The child pays at least feeRate for its own size if the parent
+// fee already covers the package
+func (wallet *Wallet) CPFP(
+	parentVSize int,
+	parentFee btcutil.Amount,
+	utxo UTXO,
+	feeRate btcutil.Amount,
+	writer io.Writer,
+	options ...PayOption) error {
+
+	if wallet.privateKey == nil {
+		return ErrWalletClosed
+	}
+
+	tx, err := txFrom([]UTXO{utxo}).
+		change(wallet.Address).
+		feeRate(feeRate).
+		options(options).
+		sign(wallet.privateKey, wallet.compressed).
+		child(parentVSize, parentFee)
+
+	if err != nil {
+		return err
+	}
+
+	return tx.Serialize(writer)
+}
+
+// child build cpfp child sweeping the inputs to the change address, inputs
+// are signed if a private key is set
+func (trans *transaction) child(parentVSize int, parentFee btcutil.Amount) (*wire.MsgTx, error) {
+	if parentVSize <= 0 || parentFee < 0 || len(trans.inputs) == 0 {
+		return nil, ErrCPFPParent
+	}
+
+	tx := wire.NewMsgTx(wire.TxVersion)
+
+	var total btcutil.Amount
+
+	for _, utxo := range trans.inputs {
+		hash, err := chainhash.NewHashFromStr(utxo.TxID)
+
+		if err != nil {
+			return nil, err
+		}
+
+		txin := wire.NewTxIn(wire.NewOutPoint(hash, utxo.VOut), nil, nil)
+
+		txin.Sequence = trans.sequence
+
+		tx.AddTxIn(txin)
+
+		trans.txIn[txin] = utxo
+
+		total += btcutil.Amount(utxo.Satoshis)
+	}
+
+	pkScript, err := payToAddrScript(trans.paychange)
+
+	if err != nil {
+		return nil, err
+	}
+
+	tx.AddTxOut(wire.NewTxOut(0, pkScript))
+
+	weight, err := trans.estimateWeight(tx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	childFee := packageFee(parentVSize, parentFee, weight, trans.payFeeRate)
+
+	if total-childFee < dustLimit(pkScript) {
+		return nil, ErrCPFPFunds
+	}
+
+	tx.TxOut[0].Value = int64(total - childFee)
+
+	trans.Debug("parent fee: ", parentFee, " child fee: ", childFee)
+
+	if trans.privatekey == nil {
+		return tx, nil
+	}
+
+	if err := trans.signInputs(tx); err != nil {
+		return nil, err
+	}
+
+	return tx, nil
+}
+
+// packageFee child fee bringing the package of parent and child with weight
+// to feeRate, at least feeRate for the child alone
+func packageFee(parentVSize int, parentFee btcutil.Amount, weight int, feeRate btcutil.Amount) btcutil.Amount {
+	required := fee(parentVSize*4+weight, feeRate) - parentFee
+
+	if own := fee(weight, feeRate); own > required {
+		return own
+	}
+
+	return required
+}
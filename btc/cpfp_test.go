@@ -0,0 +1,70 @@
+package btc
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/btcsuite/btcd/wire"
+	"github.com/btcsuite/btcutil"
+)
+
+func TestCPFP(t *testing.T) {
+	for _, addressType := range []AddressType{AddressTypeP2PKH, AddressTypeP2SHP2WPKH, AddressTypeP2TR} {
+		wallet := testWallet(t, WithAddressType(addressType))
+
+		utxo := testUTXO(t, wallet, 30000, 0)
+
+		for _, parentFee := range []int64{200, 50000} {
+			var buff bytes.Buffer
+
+			if err := wallet.CPFP(200, btcutil.Amount(parentFee), utxo, 10, &buff); err != nil {
+				t.Fatal(err)
+			}
+
+			var tx wire.MsgTx
+
+			if err := tx.Deserialize(bytes.NewReader(buff.Bytes())); err != nil {
+				t.Fatal(err)
+			}
+
+			if len(tx.TxIn) != 1 || len(tx.TxOut) != 1 {
+				t.Fatalf("expect sweep child got %d inputs %d outputs", len(tx.TxIn), len(tx.TxOut))
+			}
+
+			prevOut, _ := utxo.WitnessUtxo()
+
+			if err := verifySignatures(&tx, []*wire.TxOut{prevOut}); err != nil {
+				t.Fatal(err)
+			}
+
+			vsize := int64((tx.SerializeSizeStripped()*3 + tx.SerializeSize() + 3) / 4)
+
+			childFee := 30000 - tx.TxOut[0].Value
+
+			// the child pays its own size if the parent covers the package
+			expect := (200+vsize)*10 - parentFee
+
+			if expect < vsize*10 {
+				expect = vsize * 10
+			}
+
+			if childFee < expect || childFee > expect+20 {
+				t.Fatalf("%s: child fee %d expect %d", addressType, childFee, expect)
+			}
+		}
+	}
+}
+
+func TestCPFPInvalid(t *testing.T) {
+	wallet := testWallet(t, WithAddressType(AddressTypeP2WPKH))
+
+	var buff bytes.Buffer
+
+	if err := wallet.CPFP(0, 200, testUTXO(t, wallet, 30000, 0), 10, &buff); err != ErrCPFPParent {
+		t.Fatalf("expect parent error got %v", err)
+	}
+
+	if err := wallet.CPFP(200, 200, testUTXO(t, wallet, 2500, 0), 10, &buff); err != ErrCPFPFunds {
+		t.Fatalf("expect funds error got %v", err)
+	}
+}
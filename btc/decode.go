@@ -0,0 +1,271 @@
+package btc
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/btcsuite/btcutil"
+)
+
+// Script types of decoded inputs and outputs, named as by bitcoind
+const (
+	ScriptTypeNonStandard  = "nonstandard"
+	ScriptTypeCoinbase     = "coinbase"
+	ScriptTypeTaproot      = "witness_v1_taproot"
+	ScriptTypeWitnessV0PKH = "witness_v0_keyhash"
+	ScriptTypeWitnessV0SH  = "witness_v0_scripthash"
+	ScriptTypePubKeyHash   = "pubkeyhash"
+	ScriptTypeScriptHash   = "scripthash"
+)
+
+// ErrTrailingData raw transaction followed by extra bytes
+var ErrTrailingData = errors.New("trailing data after raw transaction")
+
+// DecodedInput transaction input, the spent utxo fields are set if the utxo
+// is known. ScriptType is the spent output type, inferred from the unlocking
+// data if the utxo is unknown
+type DecodedInput struct {
+	TxID       string         `json:"txid"`
+	VOut       uint32         `json:"vout"`
+	Sequence   uint32         `json:"sequence"`
+	ScriptSig  string         `json:"scriptSig"`
+	Witness    []string       `json:"witness,omitempty"`
+	ScriptType string         `json:"type"`
+	Address    string         `json:"address,omitempty"`
+	Value      btcutil.Amount `json:"value,omitempty"`
+}
+
+// DecodedOutput transaction output, OpReturn is the hex encoded data pushed
+// by OP_RETURN outputs
+type DecodedOutput struct {
+	Value        btcutil.Amount `json:"value"`
+	ScriptPubKey string         `json:"scriptPubKey"`
+	ScriptType   string         `json:"type"`
+	Address      string         `json:"address,omitempty"`
+	OpReturn     string         `json:"opReturn,omitempty"`
+}
+
+// DecodedTx structured view of a raw transaction, LockTimeEnabled is set if
+// a nonzero lock time is enforced by a non final input. Fee and FeeRate in
+// satoshis per vbyte are set if the utxos of every input are known
+type DecodedTx struct {
+	TxID            string          `json:"txid"`
+	WTxID           string          `json:"wtxid"`
+	Version         int32           `json:"version"`
+	Size            int             `json:"size"`
+	VSize           int             `json:"vsize"`
+	Weight          int             `json:"weight"`
+	LockTime        uint32          `json:"locktime"`
+	LockTimeEnabled bool            `json:"locktimeEnabled"`
+	RBF             bool            `json:"rbf"`
+	Inputs          []DecodedInput  `json:"inputs"`
+	Outputs         []DecodedOutput `json:"outputs"`
+	Fee             btcutil.Amount  `json:"fee,omitempty"`
+	FeeRate         float64         `json:"feeRate,omitempty"`
+}
+
+// DecodeRawTx decode hex encoded transaction, addresses are encoded for
+// chainname and utxos are the optional spent outputs
+func DecodeRawTx(rawTx string, chainname NetType, utxos []UTXO) (*DecodedTx, error) {
+	net, err := netParams(chainname)
+
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := hex.DecodeString(rawTx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	reader := bytes.NewReader(data)
+
+	var tx wire.MsgTx
+
+	if err := tx.Deserialize(reader); err != nil {
+		return nil, err
+	}
+
+	if reader.Len() != 0 {
+		return nil, ErrTrailingData
+	}
+
+	return decodeMsgTx(&tx, net, utxos)
+}
+
+func decodeMsgTx(tx *wire.MsgTx, net *chaincfg.Params, utxos []UTXO) (*DecodedTx, error) {
+	weight := tx.SerializeSizeStripped()*3 + tx.SerializeSize()
+
+	decoded := &DecodedTx{
+		TxID:     tx.TxHash().String(),
+		WTxID:    tx.WitnessHash().String(),
+		Version:  tx.Version,
+		Size:     tx.SerializeSize(),
+		VSize:    (weight + 3) / 4,
+		Weight:   weight,
+		LockTime: tx.LockTime,
+		RBF:      SignalsRBF(tx),
+	}
+
+	var (
+		in    btcutil.Amount
+		known = true
+	)
+
+	for _, txin := range tx.TxIn {
+		input := DecodedInput{
+			TxID:      txin.PreviousOutPoint.Hash.String(),
+			VOut:      txin.PreviousOutPoint.Index,
+			Sequence:  txin.Sequence,
+			ScriptSig: hex.EncodeToString(txin.SignatureScript),
+		}
+
+		for _, item := range txin.Witness {
+			input.Witness = append(input.Witness, hex.EncodeToString(item))
+		}
+
+		if txin.Sequence != wire.MaxTxInSequenceNum && tx.LockTime != 0 {
+			decoded.LockTimeEnabled = true
+		}
+
+		utxo, ok := findUTXO(utxos, txin.PreviousOutPoint)
+
+		if ok {
+			pkScript, err := hex.DecodeString(utxo.ScriptPubKey)
+
+			if err != nil {
+				return nil, err
+			}
+
+			input.ScriptType = scriptType(pkScript)
+			input.Address = scriptAddress(pkScript, net)
+			input.Value = btcutil.Amount(utxo.Satoshis)
+
+			in += input.Value
+		} else {
+			input.ScriptType = inputScriptType(txin)
+			known = false
+		}
+
+		decoded.Inputs = append(decoded.Inputs, input)
+	}
+
+	var out btcutil.Amount
+
+	for _, txout := range tx.TxOut {
+		output := DecodedOutput{
+			Value:        btcutil.Amount(txout.Value),
+			ScriptPubKey: hex.EncodeToString(txout.PkScript),
+			ScriptType:   scriptType(txout.PkScript),
+			Address:      scriptAddress(txout.PkScript, net),
+		}
+
+		if txscript.GetScriptClass(txout.PkScript) == txscript.NullDataTy {
+			pushes, err := txscript.PushedData(txout.PkScript)
+
+			if err != nil {
+				return nil, err
+			}
+
+			output.OpReturn = hex.EncodeToString(bytes.Join(pushes, nil))
+		}
+
+		decoded.Outputs = append(decoded.Outputs, output)
+
+		out += output.Value
+	}
+
+	if known && len(tx.TxIn) > 0 {
+		if in < out {
+			return nil, fmt.Errorf("spent utxos %d less than outputs %d", in, out)
+		}
+
+		decoded.Fee = in - out
+		decoded.FeeRate = math.Round(float64(decoded.Fee)/float64(decoded.VSize)*1000) / 1000
+	}
+
+	return decoded, nil
+}
+
+// findUTXO find utxo spent by outPoint
+func findUTXO(utxos []UTXO, outPoint wire.OutPoint) (UTXO, bool) {
+	for _, utxo := range utxos {
+		if utxo.TxID == outPoint.Hash.String() && utxo.VOut == outPoint.Index {
+			return utxo, true
+		}
+	}
+
+	return UTXO{}, false
+}
+
+// scriptType type name of output script
+func scriptType(pkScript []byte) string {
+	if isTaprootScript(pkScript) {
+		return ScriptTypeTaproot
+	}
+
+	return txscript.GetScriptClass(pkScript).String()
+}
+
+// scriptAddress address of output script on net, empty if the script has
+// no single address
+func scriptAddress(pkScript []byte, net *chaincfg.Params) string {
+	if isTaprootScript(pkScript) {
+		addr, err := NewAddressTaproot(pkScript[2:], net)
+
+		if err != nil {
+			return ""
+		}
+
+		return addr.EncodeAddress()
+	}
+
+	_, addrs, _, err := txscript.ExtractPkScriptAddrs(pkScript, net)
+
+	if err != nil || len(addrs) != 1 {
+		return ""
+	}
+
+	return addrs[0].EncodeAddress()
+}
+
+// inputScriptType infer spent output type from the unlocking data of txin
+func inputScriptType(txin *wire.TxIn) string {
+	outPoint := txin.PreviousOutPoint
+
+	if outPoint.Hash == (chainhash.Hash{}) && outPoint.Index == wire.MaxPrevOutIndex {
+		return ScriptTypeCoinbase
+	}
+
+	pushes, err := txscript.PushedData(txin.SignatureScript)
+
+	if err != nil {
+		return ScriptTypeNonStandard
+	}
+
+	witness := txin.Witness
+
+	switch {
+	case len(pushes) == 0 && len(witness) == 1 && (len(witness[0]) == 64 || len(witness[0]) == 65):
+		return ScriptTypeTaproot
+	case len(pushes) == 0 && len(witness) == 2 && len(witness[1]) == 33:
+		return ScriptTypeWitnessV0PKH
+	case len(pushes) == 0 && len(witness) > 0:
+		return ScriptTypeWitnessV0SH
+	case len(pushes) == 2 && len(witness) == 0 && (len(pushes[1]) == 33 || len(pushes[1]) == 65):
+		return ScriptTypePubKeyHash
+	case len(pushes) > 0:
+		// redeem script of p2sh, a witness program if nested segwit
+		return ScriptTypeScriptHash
+	}
+
+	return ScriptTypeNonStandard
+}
@@ -0,0 +1,175 @@
+package btc
+
+import (
+	"bytes"
+	"encoding/hex"
+	"testing"
+
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+)
+
+func TestDecodeRawTxGenesis(t *testing.T) {
+	var buff bytes.Buffer
+
+	if err := chaincfg.MainNetParams.GenesisBlock.Transactions[0].Serialize(&buff); err != nil {
+		t.Fatal(err)
+	}
+
+	decoded, err := DecodeRawTx(hex.EncodeToString(buff.Bytes()), NetTypeMainNet, nil)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if decoded.TxID != "4a5e1e4baab89f3a32518a88c31bc87f618f76673e2cc77ab2127b7afdeda33b" || decoded.WTxID != decoded.TxID {
+		t.Fatalf("unexpected txid %s wtxid %s", decoded.TxID, decoded.WTxID)
+	}
+
+	if decoded.Size != 204 || decoded.VSize != 204 || decoded.Weight != 816 {
+		t.Fatalf("unexpected size %d vsize %d weight %d", decoded.Size, decoded.VSize, decoded.Weight)
+	}
+
+	if decoded.Inputs[0].ScriptType != ScriptTypeCoinbase || decoded.RBF || decoded.LockTimeEnabled {
+		t.Fatalf("unexpected coinbase input %v", decoded.Inputs[0])
+	}
+
+	output := decoded.Outputs[0]
+
+	if output.Value != 5000000000 || output.ScriptType != "pubkey" || output.Address != "1A1zP1eP5QGefi2DMPTfTL5SLmv7DivfNa" {
+		t.Fatalf("unexpected genesis output %v", output)
+	}
+
+	if decoded.Fee != 0 || decoded.FeeRate != 0 {
+		t.Fatal("expect no fee without utxos")
+	}
+}
+
+func TestDecodeRawTx(t *testing.T) {
+	wallet := testWallet(t, WithAddressType(AddressTypeP2WPKH))
+
+	var utxos []UTXO
+
+	for i, addressType := range []AddressType{AddressTypeP2PKH, AddressTypeP2WPKH, AddressTypeP2SHP2WPKH, AddressTypeP2TR} {
+		utxos = append(utxos, testUTXO(t, testWallet(t, WithAddressType(addressType)), 20000, uint32(i)))
+	}
+
+	var buff bytes.Buffer
+
+	if err := wallet.Pay(utxos, testPayTo, 70000, 2, &buff, WithRBF()); err != nil {
+		t.Fatal(err)
+	}
+
+	rawTx := hex.EncodeToString(buff.Bytes())
+
+	var tx wire.MsgTx
+
+	if err := tx.Deserialize(bytes.NewReader(buff.Bytes())); err != nil {
+		t.Fatal(err)
+	}
+
+	inferred, err := DecodeRawTx(rawTx, NetTypeMainNet, nil)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	decoded, err := DecodeRawTx(rawTx, NetTypeMainNet, utxos)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if decoded.TxID != tx.TxHash().String() || decoded.WTxID == decoded.TxID || !decoded.RBF {
+		t.Fatalf("unexpected txid %s wtxid %s rbf %v", decoded.TxID, decoded.WTxID, decoded.RBF)
+	}
+
+	expect := map[uint32]string{
+		0: ScriptTypePubKeyHash,
+		1: ScriptTypeWitnessV0PKH,
+		2: ScriptTypeScriptHash,
+		3: ScriptTypeTaproot,
+	}
+
+	for i, input := range decoded.Inputs {
+		if input.ScriptType != expect[input.VOut] || inferred.Inputs[i].ScriptType != expect[input.VOut] {
+			t.Fatalf("input %d: expect %s got %s inferred %s", input.VOut, expect[input.VOut], input.ScriptType, inferred.Inputs[i].ScriptType)
+		}
+
+		if input.Address != utxos[input.VOut].Address || input.Value != 20000 {
+			t.Fatalf("input %d: unexpected address %s value %d", input.VOut, input.Address, input.Value)
+		}
+	}
+
+	if decoded.Outputs[0].Address != testPayTo || decoded.Outputs[1].Address != wallet.Address.EncodeAddress() {
+		t.Fatalf("unexpected outputs %v", decoded.Outputs)
+	}
+
+	fee := 80000 - decoded.Outputs[0].Value - decoded.Outputs[1].Value
+
+	if decoded.Fee != fee || decoded.FeeRate < 2 || decoded.FeeRate > 2.1 {
+		t.Fatalf("unexpected fee %d rate %f", decoded.Fee, decoded.FeeRate)
+	}
+
+	if inferred.Fee != 0 {
+		t.Fatal("expect no fee without utxos")
+	}
+}
+
+func TestDecodeRawTxOpReturn(t *testing.T) {
+	wallet := testWallet(t, WithAddressType(AddressTypeP2TR))
+
+	utxo := testUTXO(t, wallet, 20000, 0)
+
+	tx, err := txFrom([]UTXO{utxo}).change(wallet.Address).feeRate(2).to(wallet.Address, 10000).done()
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tx.LockTime = 800000
+	tx.TxIn[0].Sequence = wire.MaxTxInSequenceNum - 1
+
+	nullData, err := txscript.NullDataScript([]byte("hello"))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tx.AddTxOut(wire.NewTxOut(0, nullData))
+
+	var buff bytes.Buffer
+
+	if err := tx.Serialize(&buff); err != nil {
+		t.Fatal(err)
+	}
+
+	decoded, err := DecodeRawTx(hex.EncodeToString(buff.Bytes()), NetTypeMainNet, []UTXO{utxo})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !decoded.LockTimeEnabled || decoded.RBF || decoded.LockTime != 800000 {
+		t.Fatalf("unexpected lock time %d enabled %v rbf %v", decoded.LockTime, decoded.LockTimeEnabled, decoded.RBF)
+	}
+
+	if decoded.Outputs[0].ScriptType != ScriptTypeTaproot || decoded.Outputs[0].Address != wallet.Address.EncodeAddress() {
+		t.Fatalf("unexpected taproot output %v", decoded.Outputs[0])
+	}
+
+	output := decoded.Outputs[len(decoded.Outputs)-1]
+
+	if output.ScriptType != "nulldata" || output.OpReturn != hex.EncodeToString([]byte("hello")) || output.Address != "" {
+		t.Fatalf("unexpected op return output %v", output)
+	}
+
+	if _, err := DecodeRawTx(hex.EncodeToString(buff.Bytes())+"00", NetTypeMainNet, nil); err != ErrTrailingData {
+		t.Fatalf("expect trailing data error got %v", err)
+	}
+
+	if _, err := DecodeRawTx("zz", NetTypeMainNet, nil); err == nil {
+		t.Fatal("expect invalid hex error")
+	}
+}
@@ -0,0 +1,145 @@
+package btc
+
+import (
+	"crypto/hmac"
+	"crypto/sha512"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/goany/secure"
+	"golang.org/x/crypto/pbkdf2"
+	"golang.org/x/text/unicode/norm"
+)
+
+// ElectrumSeedType electrum v2 seed version
+type ElectrumSeedType string
+
+// electrum v2 seed versions
+const (
+	ElectrumSeedStandard  ElectrumSeedType = "standard"
+	ElectrumSeedSegwit    ElectrumSeedType = "segwit"
+	ElectrumSeed2FA       ElectrumSeedType = "2fa"
+	ElectrumSeed2FASegwit ElectrumSeedType = "2fa_segwit"
+)
+
+const (
+	electrumSeedVersionKey = "Seed version"
+	electrumSaltPrefix     = "electrum"
+)
+
+// electrumSeedPrefixes hex prefix of hmac-sha512("Seed version", seed) for each seed type
+var electrumSeedPrefixes = []struct {
+	prefix   string
+	seedType ElectrumSeedType
+}{
+	{"01", ElectrumSeedStandard},
+	{"100", ElectrumSeedSegwit},
+	{"101", ElectrumSeed2FA},
+	{"102", ElectrumSeed2FASegwit},
+}
+
+// DetectElectrumSeed get the electrum v2 seed type of mnemonic
+func DetectElectrumSeed(mnemonic string) (ElectrumSeedType, error) {
+	mac := hmac.New(sha512.New, []byte(electrumSeedVersionKey))
+	mac.Write([]byte(normalizeElectrumText(mnemonic)))
+
+	version := hex.EncodeToString(mac.Sum(nil))
+
+	for _, p := range electrumSeedPrefixes {
+		if strings.HasPrefix(version, p.prefix) {
+			return p.seedType, nil
+		}
+	}
+
+	return "", fmt.Errorf("not an electrum v2 seed")
+}
+
+// ElectrumSeed convert electrum v2 mnemonic and passphrase into bip32 seed,
+// an error is returned if mnemonic is not an electrum v2 seed
+func ElectrumSeed(mnemonic string, passphrase string) ([]byte, error) {
+	if _, err := DetectElectrumSeed(mnemonic); err != nil {
+		return nil, err
+	}
+
+	return pbkdf2.Key(
+		[]byte(normalizeElectrumText(mnemonic)),
+		[]byte(electrumSaltPrefix+normalizeElectrumText(passphrase)),
+		2048, 64, sha512.New), nil
+}
+
+// WalletFromElectrumSeed create wallet from electrum standard or segwit seed,
+// standard seeds derive p2pkh address m/0/{index}, segwit seeds derive
+// p2wpkh address m/0'/0/{index}
+func WalletFromElectrumSeed(mnemonic string, passphrase string, index uint32, chainname NetType) (*Wallet, error) {
+	seedType, err := DetectElectrumSeed(mnemonic)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		path        string
+		addressType AddressType
+	)
+
+	switch seedType {
+	case ElectrumSeedStandard:
+		path = fmt.Sprintf("m/0/%d", index)
+		addressType = AddressTypeP2PKH
+	case ElectrumSeedSegwit:
+		path = fmt.Sprintf("m/0'/0/%d", index)
+		addressType = AddressTypeP2WPKH
+	default:
+		return nil, fmt.Errorf("electrum %s seed is not supported", seedType)
+	}
+
+	seed, err := ElectrumSeed(mnemonic, passphrase)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer secure.Wipe(seed)
+
+	priv, origin, err := derivePrivateKey(seed, path)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return newWallet(priv, priv.PubKey(), true, addressType, chainname, withKeyOrigin(origin))
+}
+
+// normalizeElectrumText electrum seed normalization: NFKD, lower case,
+// accents removed, whitespace collapsed and removed between CJK characters
+func normalizeElectrumText(text string) string {
+	text = strings.ToLower(norm.NFKD.String(text))
+
+	text = strings.Map(func(r rune) rune {
+		if unicode.Is(unicode.Mn, r) {
+			return -1
+		}
+
+		return r
+	}, text)
+
+	runes := []rune(strings.Join(strings.Fields(text), " "))
+
+	result := make([]rune, 0, len(runes))
+
+	for i, r := range runes {
+		if r == ' ' && i > 0 && i+1 < len(runes) && isCJK(runes[i-1]) && isCJK(runes[i+1]) {
+			continue
+		}
+
+		result = append(result, r)
+	}
+
+	return string(result)
+}
+
+func isCJK(r rune) bool {
+	return unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana, unicode.Hangul)
+}
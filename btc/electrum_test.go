@@ -0,0 +1,50 @@
+package btc
+
+import (
+	"testing"
+)
+
+func TestWalletFromElectrumSeed(t *testing.T) {
+	vectors := []struct {
+		mnemonic string
+		seedType ElectrumSeedType
+		address  string
+	}{
+		{
+			"cycle rocket west magnet parrot shuffle foot correct salt library feed song",
+			ElectrumSeedStandard,
+			"1NNkttn1YvVGdqBW4PR6zvc3Zx3H5owKRf",
+		},
+		{
+			"bitter grass shiver impose acquire brush forget axis eager alone wine silver",
+			ElectrumSeedSegwit,
+			"bc1q3g5tmkmlvxryhh843v4dz026avatc0zzr6h3af",
+		},
+	}
+
+	for _, vector := range vectors {
+		seedType, err := DetectElectrumSeed(vector.mnemonic)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if seedType != vector.seedType {
+			t.Fatalf("expect %s got %s", vector.seedType, seedType)
+		}
+
+		wallet, err := WalletFromElectrumSeed(vector.mnemonic, "", 0, NetTypeMainNet)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if wallet.Address.EncodeAddress() != vector.address {
+			t.Fatalf("expect %s got %s", vector.address, wallet.Address.EncodeAddress())
+		}
+	}
+
+	if _, err := DetectElectrumSeed("abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about"); err == nil {
+		t.Fatal("expect bip39 mnemonic not to be an electrum seed")
+	}
+}
@@ -0,0 +1,477 @@
+package btc
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/btcsuite/btcd/btcec"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/btcsuite/btcutil"
+	"github.com/goany/slf4go"
+)
+
+// MaxMultisigKeys largest number of cosigners, keeps p2sh redeem scripts
+// within the 520 bytes push limit
+const MaxMultisigKeys = 15
+
+// Multisig errors
+var (
+	ErrNotCosigner          = errors.New("wallet key is not a cosigner of the multisig wallet")
+	ErrMultisigIncomplete   = errors.New("multisig transaction has not enough signatures")
+	ErrMultisigInvalidInput = errors.New("utxo is not an output of the multisig wallet")
+)
+
+// MultisigWallet m-of-n multisig wallet, the cosigner public keys are sorted
+// as BIP67 requires
+type MultisigWallet struct {
+	slf4go.Logger
+	Address     btcutil.Address
+	publicKeys  []*btcec.PublicKey
+	threshold   int
+	script      []byte
+	net         *chaincfg.Params
+	addressType AddressType
+}
+
+// NewMultisigWallet create threshold of len(publicKeys) multisig wallet from
+// hex encoded compressed public keys, addressType is AddressTypeP2SH or AddressTypeP2WSH
+func NewMultisigWallet(publicKeys []string, threshold int, chainname NetType, addressType AddressType) (*MultisigWallet, error) {
+
+	if len(publicKeys) == 0 || len(publicKeys) > MaxMultisigKeys {
+		return nil, fmt.Errorf("multisig wallet requires 1 to %d public keys", MaxMultisigKeys)
+	}
+
+	if threshold < 1 || threshold > len(publicKeys) {
+		return nil, fmt.Errorf("invalid multisig threshold %d of %d", threshold, len(publicKeys))
+	}
+
+	keys := make([][]byte, 0, len(publicKeys))
+
+	for _, publicKey := range publicKeys {
+		key, err := hex.DecodeString(publicKey)
+
+		if err != nil {
+			return nil, err
+		}
+
+		if len(key) != btcec.PubKeyBytesLenCompressed {
+			return nil, fmt.Errorf("multisig public key must be compressed :%s", publicKey)
+		}
+
+		keys = append(keys, key)
+	}
+
+	// BIP67 lexicographical order of the serialized keys
+	sort.Slice(keys, func(i, j int) bool {
+		return bytes.Compare(keys[i], keys[j]) < 0
+	})
+
+	net, err := netParams(chainname)
+
+	if err != nil {
+		return nil, err
+	}
+
+	wallet := &MultisigWallet{
+		Logger:      slf4go.Get("BTCMultisigWallet"),
+		threshold:   threshold,
+		net:         net,
+		addressType: addressType,
+	}
+
+	addresses := make([]*btcutil.AddressPubKey, 0, len(keys))
+
+	for i, key := range keys {
+		if i > 0 && bytes.Equal(key, keys[i-1]) {
+			return nil, fmt.Errorf("duplicate multisig public key :%x", key)
+		}
+
+		address, err := btcutil.NewAddressPubKey(key, net)
+
+		if err != nil {
+			return nil, err
+		}
+
+		addresses = append(addresses, address)
+		wallet.publicKeys = append(wallet.publicKeys, address.PubKey())
+	}
+
+	wallet.script, err = txscript.MultiSigScript(addresses, threshold)
+
+	if err != nil {
+		return nil, err
+	}
+
+	switch addressType {
+	case AddressTypeP2SH:
+		wallet.Address, err = btcutil.NewAddressScriptHash(wallet.script, net)
+	case AddressTypeP2WSH:
+		scriptHash := sha256.Sum256(wallet.script)
+
+		wallet.Address, err = btcutil.NewAddressWitnessScriptHash(scriptHash[:], net)
+	default:
+		return nil, fmt.Errorf("unknown btc multisig address type :%s", addressType)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return wallet, nil
+}
+
+// Script multisig redeem script of p2sh or witness script of p2wsh wallets
+func (wallet *MultisigWallet) Script() []byte {
+	return wallet.script
+}
+
+// Threshold number of signatures required to spend
+func (wallet *MultisigWallet) Threshold() int {
+	return wallet.threshold
+}
+
+// AddressType get wallet address type
+func (wallet *MultisigWallet) AddressType() AddressType {
+	return wallet.addressType
+}
+
+// MultisigTx unsigned multisig wallet transaction collecting cosigner
+// signatures, Bytes and ParseMultisigTx pass it between cosigners as a psbt
+type MultisigTx struct {
+	wallet *MultisigWallet
+	tx     *wire.MsgTx
+	inputs []UTXO
+	// signatures of every input by cosigner public key index
+	signatures [][][]byte
+	hashType   txscript.SigHashType
+}
+
+// CreateTx create unsigned transaction paying amount to address, the
+// change is returned to the multisig address
+func (wallet *MultisigWallet) CreateTx(
+	inputs []UTXO,
+	to string,
+	amount btcutil.Amount,
+	feeRate btcutil.Amount,
+	options ...PayOption) (*MultisigTx, error) {
+
+	pkScript, err := txscript.PayToAddrScript(wallet.Address)
+
+	if err != nil {
+		return nil, err
+	}
+
+	for _, utxo := range inputs {
+		if utxo.ScriptPubKey != hex.EncodeToString(pkScript) {
+			return nil, ErrMultisigInvalidInput
+		}
+	}
+
+	addr, err := decodeAddress(to, wallet.net)
+
+	if err != nil {
+		return nil, err
+	}
+
+	trans := txFrom(inputs).
+		to(addr, amount).
+		change(wallet.Address).
+		feeRate(feeRate).
+		options(options).
+		multisig(wallet.script, wallet.threshold)
+
+	tx, err := trans.done()
+
+	if err != nil {
+		return nil, err
+	}
+
+	mtx := &MultisigTx{
+		wallet:     wallet,
+		tx:         tx,
+		signatures: make([][][]byte, len(tx.TxIn)),
+		hashType:   trans.sigHashType,
+	}
+
+	for i, txin := range tx.TxIn {
+		mtx.inputs = append(mtx.inputs, trans.txIn[txin])
+		mtx.signatures[i] = make([][]byte, len(wallet.publicKeys))
+	}
+
+	return mtx, nil
+}
+
+// Tx unsigned transaction
+func (mtx *MultisigTx) Tx() *wire.MsgTx {
+	return mtx.tx
+}
+
+// Bytes serialize transaction and collected signatures as version 0 psbt
+func (mtx *MultisigTx) Bytes() ([]byte, error) {
+	p, err := newPsbtFromTx(mtx.tx, mtx.inputs, PsbtVersion0)
+
+	if err != nil {
+		return nil, err
+	}
+
+	for i, input := range p.Inputs {
+		if mtx.wallet.addressType == AddressTypeP2WSH {
+			input.WitnessScript = mtx.wallet.script
+		} else {
+			input.RedeemScript = mtx.wallet.script
+		}
+
+		input.SighashType = mtx.hashType
+		input.HasSighashType = true
+
+		for j, sig := range mtx.signatures[i] {
+			if sig != nil {
+				input.addPartialSig(mtx.wallet.publicKeys[j].SerializeCompressed(), sig)
+			}
+		}
+	}
+
+	return p.Bytes()
+}
+
+// ParseMultisigTx parse multisig transaction of wallet serialized by Bytes,
+// every input must spend an output of wallet, partial signatures which do
+// not verify are dropped
+func ParseMultisigTx(data []byte, wallet *MultisigWallet) (*MultisigTx, error) {
+	p, err := ParsePsbt(data)
+
+	if err != nil {
+		return nil, err
+	}
+
+	pkScript, err := txscript.PayToAddrScript(wallet.Address)
+
+	if err != nil {
+		return nil, err
+	}
+
+	mtx := &MultisigTx{
+		wallet:     wallet,
+		tx:         p.UnsignedTx(),
+		signatures: make([][][]byte, len(p.Inputs)),
+		hashType:   txscript.SigHashAll,
+	}
+
+	for i, input := range p.Inputs {
+		prevOut := p.prevOut(i)
+
+		if prevOut == nil || !bytes.Equal(prevOut.PkScript, pkScript) {
+			return nil, ErrMultisigInvalidInput
+		}
+
+		if input.HasSighashType {
+			if i > 0 && input.SighashType != mtx.hashType {
+				return nil, fmt.Errorf("%w: inputs use different sighash types", ErrPsbtMalformed)
+			}
+
+			mtx.hashType = input.SighashType
+		}
+
+		mtx.inputs = append(mtx.inputs, UTXO{
+			Address:      wallet.Address.EncodeAddress(),
+			TxID:         input.PreviousOutPoint.Hash.String(),
+			VOut:         input.PreviousOutPoint.Index,
+			ScriptPubKey: hex.EncodeToString(prevOut.PkScript),
+			Satoshis:     float64(prevOut.Value),
+		})
+
+	}
+
+	sigHashes := txscript.NewTxSigHashes(mtx.tx)
+
+	for i, input := range p.Inputs {
+		mtx.signatures[i] = make([][]byte, len(wallet.publicKeys))
+
+		for j, key := range wallet.publicKeys {
+			sig := input.partialSig(key.SerializeCompressed())
+
+			if sig != nil && mtx.verifySignature(sigHashes, i, key, sig) {
+				mtx.signatures[i][j] = sig
+			}
+		}
+	}
+
+	return mtx, nil
+}
+
+// verifySignature check partial signature of cosigner key over input idx,
+// the signature must commit to the sighash type of the transaction
+func (mtx *MultisigTx) verifySignature(sigHashes *txscript.TxSigHashes, idx int, key *btcec.PublicKey, sig []byte) bool {
+	if len(sig) < 2 || txscript.SigHashType(sig[len(sig)-1]) != mtx.hashType {
+		return false
+	}
+
+	var (
+		hash []byte
+		err  error
+	)
+
+	if mtx.wallet.addressType == AddressTypeP2WSH {
+		hash, err = txscript.CalcWitnessSigHash(mtx.wallet.script, sigHashes, mtx.hashType, mtx.tx, idx, int64(mtx.inputs[idx].Satoshis))
+	} else {
+		hash, err = txscript.CalcSignatureHash(mtx.wallet.script, mtx.hashType, mtx.tx, idx)
+	}
+
+	if err != nil {
+		return false
+	}
+
+	signature, err := btcec.ParseDERSignature(sig[:len(sig)-1], btcec.S256())
+
+	if err != nil {
+		return false
+	}
+
+	return signature.Verify(hash, key)
+}
+
+// Signatures number of cosigners who signed every input
+func (mtx *MultisigTx) Signatures() int {
+	count := len(mtx.wallet.publicKeys)
+
+	for _, sigs := range mtx.signatures {
+		signed := 0
+
+		for _, sig := range sigs {
+			if sig != nil {
+				signed++
+			}
+		}
+
+		if signed < count {
+			count = signed
+		}
+	}
+
+	return count
+}
+
+// Complete check the threshold of signatures is met for every input
+func (mtx *MultisigTx) Complete() bool {
+	return mtx.Signatures() >= mtx.wallet.threshold
+}
+
+// sign add signatures of cosigner key to every input
+func (mtx *MultisigTx) sign(priv *btcec.PrivateKey) error {
+	pubKey := priv.PubKey().SerializeCompressed()
+
+	cosigner := -1
+
+	for i, key := range mtx.wallet.publicKeys {
+		if bytes.Equal(key.SerializeCompressed(), pubKey) {
+			cosigner = i
+		}
+	}
+
+	if cosigner < 0 {
+		return ErrNotCosigner
+	}
+
+	sigHashes := txscript.NewTxSigHashes(mtx.tx)
+
+	for i, utxo := range mtx.inputs {
+		if err := checkSigHashType(mtx.tx, i, mtx.hashType); err != nil {
+			return err
+		}
+
+		var (
+			sig []byte
+			err error
+		)
+
+		if mtx.wallet.addressType == AddressTypeP2WSH {
+			sig, err = txscript.RawTxInWitnessSignature(mtx.tx, sigHashes, i, int64(utxo.Satoshis), mtx.wallet.script, mtx.hashType, priv)
+		} else {
+			sig, err = txscript.RawTxInSignature(mtx.tx, i, mtx.wallet.script, mtx.hashType, priv)
+		}
+
+		if err != nil {
+			return err
+		}
+
+		mtx.signatures[i][cosigner] = sig
+	}
+
+	return nil
+}
+
+// SignMultisig add wallet signatures to multisig transaction, the wallet
+// key must be one of the cosigner keys
+func (wallet *Wallet) SignMultisig(mtx *MultisigTx) error {
+	if wallet.privateKey == nil {
+		return ErrWalletClosed
+	}
+
+	return mtx.sign(wallet.privateKey)
+}
+
+// Finalize write signed transaction once the threshold of signatures is met,
+// surplus signatures are dropped and every input is verified
+func (mtx *MultisigTx) Finalize(writer io.Writer) error {
+	if !mtx.Complete() {
+		return ErrMultisigIncomplete
+	}
+
+	tx := mtx.tx.Copy()
+
+	prevOuts := make([]*wire.TxOut, len(tx.TxIn))
+
+	for i, txin := range tx.TxIn {
+		prevOut, err := mtx.inputs[i].WitnessUtxo()
+
+		if err != nil {
+			return err
+		}
+
+		prevOuts[i] = prevOut
+
+		// signatures in public key order, CHECKMULTISIG pops one extra item
+		stack := [][]byte{nil}
+
+		for _, sig := range mtx.signatures[i] {
+			if sig != nil && len(stack) <= mtx.wallet.threshold {
+				stack = append(stack, sig)
+			}
+		}
+
+		stack = append(stack, mtx.wallet.script)
+
+		if mtx.wallet.addressType == AddressTypeP2WSH {
+			txin.Witness = stack
+
+			continue
+		}
+
+		builder := txscript.NewScriptBuilder().AddOp(txscript.OP_0)
+
+		for _, data := range stack[1:] {
+			builder.AddData(data)
+		}
+
+		sigScript, err := builder.Script()
+
+		if err != nil {
+			return err
+		}
+
+		txin.SignatureScript = sigScript
+	}
+
+	if err := verifySignatures(tx, prevOuts); err != nil {
+		return err
+	}
+
+	return tx.Serialize(writer)
+}
@@ -0,0 +1,211 @@
+package btc
+
+import (
+	"bytes"
+	"encoding/hex"
+	"testing"
+
+	"github.com/btcsuite/btcd/btcec"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+)
+
+func TestMultisigBIP67(t *testing.T) {
+	// BIP67 test vector 1
+	wallet, err := NewMultisigWallet([]string{
+		"02ff12471208c14bd580709cb2358d98975247d8765f92bc25eab3b2763ed605f8",
+		"02fe6f0a5a297eb38c391581c4413e084773ea23954d93f7753db7dc0adc188b2f",
+	}, 2, NetTypeMainNet, AddressTypeP2SH)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	script := "522102fe6f0a5a297eb38c391581c4413e084773ea23954d93f7753db7dc0adc188b2f2102ff12471208c14bd580709cb2358d98975247d8765f92bc25eab3b2763ed605f852ae"
+
+	if hex.EncodeToString(wallet.Script()) != script {
+		t.Fatalf("expect %s got %x", script, wallet.Script())
+	}
+
+	if wallet.Address.EncodeAddress() != "39bgKC7RFbpoCRbtD5KEdkYKtNyhpsNa3Z" {
+		t.Fatalf("unexpected address %s", wallet.Address.EncodeAddress())
+	}
+
+	if _, err := NewMultisigWallet([]string{
+		"02ff12471208c14bd580709cb2358d98975247d8765f92bc25eab3b2763ed605f8",
+	}, 2, NetTypeMainNet, AddressTypeP2SH); err == nil {
+		t.Fatal("expect invalid threshold error")
+	}
+}
+
+func TestMultisigSign(t *testing.T) {
+	var (
+		cosigners  []*Wallet
+		publicKeys []string
+	)
+
+	for i := byte(1); i <= 3; i++ {
+		priv, _ := btcec.PrivKeyFromBytes(btcec.S256(), bytes.Repeat([]byte{i}, 32))
+
+		wallet, err := newWallet(priv, priv.PubKey(), true, AddressTypeP2PKH, NetTypeMainNet)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		cosigners = append(cosigners, wallet)
+		publicKeys = append(publicKeys, hex.EncodeToString(priv.PubKey().SerializeCompressed()))
+	}
+
+	priv, _ := btcec.PrivKeyFromBytes(btcec.S256(), bytes.Repeat([]byte{0x04}, 32))
+
+	outsider, err := newWallet(priv, priv.PubKey(), true, AddressTypeP2PKH, NetTypeMainNet)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, addressType := range []AddressType{AddressTypeP2SH, AddressTypeP2WSH} {
+		wallet, err := NewMultisigWallet(publicKeys, 2, NetTypeMainNet, addressType)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		pkScript, err := payToAddrScript(wallet.Address)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		utxos := []UTXO{
+			{TxID: chainhash.DoubleHashH([]byte{0}).String(), VOut: 0, ScriptPubKey: hex.EncodeToString(pkScript), Satoshis: 30000},
+			{TxID: chainhash.DoubleHashH([]byte{1}).String(), VOut: 1, ScriptPubKey: hex.EncodeToString(pkScript), Satoshis: 40000},
+		}
+
+		mtx, err := wallet.CreateTx(utxos, testPayTo, 50000, 2)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if err := outsider.SignMultisig(mtx); err != ErrNotCosigner {
+			t.Fatalf("expect not cosigner error got %v", err)
+		}
+
+		if err := cosigners[2].SignMultisig(mtx); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := mtx.Finalize(&bytes.Buffer{}); err != ErrMultisigIncomplete {
+			t.Fatalf("expect incomplete error got %v", err)
+		}
+
+		// pass the partially signed transaction to the next cosigner
+		txHash := mtx.Tx().TxHash()
+
+		data, err := mtx.Bytes()
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		other, err := NewMultisigWallet(publicKeys, 3, NetTypeMainNet, addressType)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err := ParseMultisigTx(data, other); err != ErrMultisigInvalidInput {
+			t.Fatalf("expect invalid input error got %v", err)
+		}
+
+		mtx, err = ParseMultisigTx(data, wallet)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if mtx.Signatures() != 1 || mtx.Tx().TxHash() != txHash {
+			t.Fatalf("expect 1 signature after round trip got %d", mtx.Signatures())
+		}
+
+		// signatures which do not verify or commit to another sighash type
+		// are dropped on import
+		for _, tamper := range []func(sig []byte){
+			func(sig []byte) { sig[len(sig)-2] ^= 1 },
+			func(sig []byte) { sig[len(sig)-1] = byte(txscript.SigHashNone) },
+		} {
+			tampered, err := ParseMultisigTx(data, wallet)
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			for _, sigs := range tampered.signatures {
+				for _, sig := range sigs {
+					if sig != nil {
+						tamper(sig)
+					}
+				}
+			}
+
+			tamperedData, err := tampered.Bytes()
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			tampered, err = ParseMultisigTx(tamperedData, wallet)
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if tampered.Signatures() != 0 {
+				t.Fatalf("expect tampered signature dropped got %d", tampered.Signatures())
+			}
+		}
+
+		if err := cosigners[0].SignMultisig(mtx); err != nil {
+			t.Fatal(err)
+		}
+
+		if !mtx.Complete() || mtx.Signatures() != 2 {
+			t.Fatalf("expect 2 signatures got %d", mtx.Signatures())
+		}
+
+		var buff bytes.Buffer
+
+		if err := mtx.Finalize(&buff); err != nil {
+			t.Fatal(err)
+		}
+
+		var tx wire.MsgTx
+
+		if err := tx.Deserialize(bytes.NewReader(buff.Bytes())); err != nil {
+			t.Fatal(err)
+		}
+
+		if tx.HasWitness() != (addressType == AddressTypeP2WSH) {
+			t.Fatalf("unexpected %s witness", addressType)
+		}
+
+		verifyTx(t, &tx, utxos)
+
+		// fee covers the signed virtual size including the change output,
+		// signatures are estimated at their maximum size
+		var out int64
+
+		for _, txout := range tx.TxOut {
+			out += txout.Value
+		}
+
+		vsize := (tx.SerializeSizeStripped()*3 + tx.SerializeSize() + 3) / 4
+
+		if fee := 70000 - out; fee < int64(vsize)*2 || fee > int64(vsize+12)*2 {
+			t.Fatalf("%s fee %d does not match vsize %d", addressType, fee, vsize)
+		}
+	}
+}
@@ -0,0 +1,1164 @@
+package btc
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/btcsuite/btcd/btcec"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+)
+
+// PSBT versions, BIP174 version 0 carries the unsigned transaction and
+// BIP370 version 2 carries its fields per input and output
+const (
+	PsbtVersion0 uint32 = 0
+	PsbtVersion2 uint32 = 2
+)
+
+var psbtMagic = []byte{0x70, 0x73, 0x62, 0x74, 0xff}
+
+// maxPsbtValueSize largest key or value of a psbt map entry
+const maxPsbtValueSize = 4000000
+
+// global key types
+const (
+	psbtGlobalUnsignedTx       = 0x00
+	psbtGlobalTxVersion        = 0x02
+	psbtGlobalFallbackLocktime = 0x03
+	psbtGlobalInputCount       = 0x04
+	psbtGlobalOutputCount      = 0x05
+	psbtGlobalTxModifiable     = 0x06
+	psbtGlobalVersion          = 0xfb
+)
+
+// input key types
+const (
+	psbtInNonWitnessUtxo         = 0x00
+	psbtInWitnessUtxo            = 0x01
+	psbtInPartialSig             = 0x02
+	psbtInSighashType            = 0x03
+	psbtInRedeemScript           = 0x04
+	psbtInWitnessScript          = 0x05
+	psbtInBip32Derivation        = 0x06
+	psbtInFinalScriptSig         = 0x07
+	psbtInFinalScriptWitness     = 0x08
+	psbtInPreviousTxid           = 0x0e
+	psbtInOutputIndex            = 0x0f
+	psbtInSequence               = 0x10
+	psbtInRequiredTimeLocktime   = 0x11
+	psbtInRequiredHeightLocktime = 0x12
+	psbtInTapKeySig              = 0x13
+	psbtInTapScriptSig           = 0x14
+	psbtInTapLeafScript          = 0x15
+	psbtInTapBip32Derivation     = 0x16
+	psbtInTapInternalKey         = 0x17
+	psbtInTapMerkleRoot          = 0x18
+)
+
+// output key types
+const (
+	psbtOutRedeemScript       = 0x00
+	psbtOutWitnessScript      = 0x01
+	psbtOutBip32Derivation    = 0x02
+	psbtOutAmount             = 0x03
+	psbtOutScript             = 0x04
+	psbtOutTapInternalKey     = 0x05
+	psbtOutTapBip32Derivation = 0x07
+)
+
+// PSBT errors
+var (
+	ErrPsbtMalformed    = errors.New("psbt is malformed")
+	ErrPsbtVersion      = errors.New("psbt version is not supported")
+	ErrPsbtMismatch     = errors.New("psbts of different transactions can not be combined")
+	ErrPsbtIncomplete   = errors.New("psbt input has not enough signatures to finalize")
+	ErrPsbtNotFinalized = errors.New("psbt is not finalized")
+	ErrPsbtSigHashType  = errors.New("psbt input requests a sighash type the signer does not allow")
+)
+
+// PsbtPartialSig signature of one public key
+type PsbtPartialSig struct {
+	PubKey    []byte
+	Signature []byte
+}
+
+// PsbtBip32Derivation bip32 origin of public key, the master key fingerprint
+// and the derivation path
+type PsbtBip32Derivation struct {
+	PubKey      []byte
+	Fingerprint uint32
+	Path        []uint32
+}
+
+// PsbtTaprootBip32Derivation bip32 origin of taproot x-only public key,
+// LeafHashes are the script leaves using the key, none for the internal key
+type PsbtTaprootBip32Derivation struct {
+	XOnlyPubKey []byte
+	LeafHashes  [][]byte
+	Fingerprint uint32
+	Path        []uint32
+}
+
+// psbtKV unknown or uninterpreted map entry, kept for round trips
+type psbtKV struct {
+	key   []byte
+	value []byte
+}
+
+// PsbtInput psbt input map
+type PsbtInput struct {
+	PreviousOutPoint       wire.OutPoint
+	Sequence               uint32
+	NonWitnessUtxo         *wire.MsgTx
+	WitnessUtxo            *wire.TxOut
+	PartialSigs            []PsbtPartialSig
+	SighashType            txscript.SigHashType
+	HasSighashType         bool
+	RedeemScript           []byte
+	WitnessScript          []byte
+	Bip32Derivation        []PsbtBip32Derivation
+	FinalScriptSig         []byte
+	FinalScriptWitness     wire.TxWitness
+	TaprootKeySpendSig     []byte
+	TaprootBip32Derivation []PsbtTaprootBip32Derivation
+	TaprootInternalKey     []byte
+	RequiredTimeLocktime   uint32
+	RequiredHeightLocktime uint32
+	unknowns               []psbtKV
+}
+
+// PsbtOutput psbt output map
+type PsbtOutput struct {
+	Amount                 int64
+	Script                 []byte
+	RedeemScript           []byte
+	WitnessScript          []byte
+	Bip32Derivation        []PsbtBip32Derivation
+	TaprootInternalKey     []byte
+	TaprootBip32Derivation []PsbtTaprootBip32Derivation
+	unknowns               []psbtKV
+}
+
+// Psbt partially signed bitcoin transaction
+type Psbt struct {
+	Version   uint32
+	TxVersion int32
+	// LockTime transaction lock time of version 0, fallback lock time of version 2
+	LockTime     uint32
+	TxModifiable uint8
+	Inputs       []*PsbtInput
+	Outputs      []*PsbtOutput
+	unknowns     []psbtKV
+}
+
+// NewPsbt create unsigned version 2 transaction psbt spending utxos to outputs
+func NewPsbt(inputs []UTXO, outputs []*wire.TxOut, version uint32) (*Psbt, error) {
+	// BIP370 requires transaction version 2
+	tx := wire.NewMsgTx(2)
+
+	for _, utxo := range inputs {
+		hash, err := chainhash.NewHashFromStr(utxo.TxID)
+
+		if err != nil {
+			return nil, err
+		}
+
+		tx.AddTxIn(wire.NewTxIn(wire.NewOutPoint(hash, utxo.VOut), nil, nil))
+	}
+
+	for _, output := range outputs {
+		tx.AddTxOut(output)
+	}
+
+	return newPsbtFromTx(tx, inputs, version)
+}
+
+// newPsbtFromTx create psbt of unsigned tx, utxos are spent by tx inputs in order
+func newPsbtFromTx(tx *wire.MsgTx, utxos []UTXO, version uint32) (*Psbt, error) {
+	if version != PsbtVersion0 && version != PsbtVersion2 {
+		return nil, ErrPsbtVersion
+	}
+
+	p := &Psbt{
+		Version:   version,
+		TxVersion: tx.Version,
+		LockTime:  tx.LockTime,
+	}
+
+	for i, txin := range tx.TxIn {
+		utxo := utxos[i]
+
+		input := &PsbtInput{
+			PreviousOutPoint: txin.PreviousOutPoint,
+			Sequence:         txin.Sequence,
+		}
+
+		witnessUtxo, err := utxo.WitnessUtxo()
+
+		if err != nil {
+			return nil, err
+		}
+
+		if utxo.PrevTx != "" {
+			prevTx, err := decodeTx(utxo.PrevTx)
+
+			if err != nil {
+				return nil, err
+			}
+
+			if prevTx.TxHash() != txin.PreviousOutPoint.Hash || int(utxo.VOut) >= len(prevTx.TxOut) {
+				return nil, fmt.Errorf("previous transaction of utxo %s:%d does not match", utxo.TxID, utxo.VOut)
+			}
+
+			input.NonWitnessUtxo = prevTx
+		}
+
+		// witness utxo of segwit and possibly nested segwit inputs, or the
+		// only utxo data of legacy inputs without previous transaction
+		switch txscript.GetScriptClass(witnessUtxo.PkScript) {
+		case txscript.PubKeyHashTy, txscript.MultiSigTy, txscript.PubKeyTy:
+			if input.NonWitnessUtxo == nil {
+				input.WitnessUtxo = witnessUtxo
+			}
+		default:
+			input.WitnessUtxo = witnessUtxo
+		}
+
+		p.Inputs = append(p.Inputs, input)
+	}
+
+	for _, txout := range tx.TxOut {
+		p.Outputs = append(p.Outputs, &PsbtOutput{
+			Amount: txout.Value,
+			Script: txout.PkScript,
+		})
+	}
+
+	return p, nil
+}
+
+// ParsePsbt parse serialized psbt of version 0 or 2
+func ParsePsbt(data []byte) (*Psbt, error) {
+	if !bytes.HasPrefix(data, psbtMagic) {
+		return nil, ErrPsbtMalformed
+	}
+
+	r := bytes.NewReader(data[len(psbtMagic):])
+
+	global, err := readPsbtMap(r)
+
+	if err != nil {
+		return nil, err
+	}
+
+	p := &Psbt{}
+
+	var (
+		unsignedTx              *wire.MsgTx
+		inputCount, outputCount uint64
+		hasTxVersion            bool
+		hasCounts               int
+	)
+
+	for _, kv := range global {
+		switch kv.key[0] {
+		case psbtGlobalUnsignedTx:
+			unsignedTx = &wire.MsgTx{}
+
+			if len(kv.key) != 1 || unsignedTx.DeserializeNoWitness(bytes.NewReader(kv.value)) != nil {
+				return nil, ErrPsbtMalformed
+			}
+		case psbtGlobalTxVersion:
+			if len(kv.key) != 1 || len(kv.value) != 4 {
+				return nil, ErrPsbtMalformed
+			}
+
+			p.TxVersion = int32(binary.LittleEndian.Uint32(kv.value))
+			hasTxVersion = true
+		case psbtGlobalFallbackLocktime:
+			if len(kv.key) != 1 || len(kv.value) != 4 {
+				return nil, ErrPsbtMalformed
+			}
+
+			p.LockTime = binary.LittleEndian.Uint32(kv.value)
+		case psbtGlobalInputCount, psbtGlobalOutputCount:
+			count, err := wire.ReadVarInt(bytes.NewReader(kv.value), 0)
+
+			if len(kv.key) != 1 || err != nil {
+				return nil, ErrPsbtMalformed
+			}
+
+			if kv.key[0] == psbtGlobalInputCount {
+				inputCount = count
+			} else {
+				outputCount = count
+			}
+
+			hasCounts++
+		case psbtGlobalTxModifiable:
+			if len(kv.key) != 1 || len(kv.value) != 1 {
+				return nil, ErrPsbtMalformed
+			}
+
+			p.TxModifiable = kv.value[0]
+		case psbtGlobalVersion:
+			if len(kv.key) != 1 || len(kv.value) != 4 {
+				return nil, ErrPsbtMalformed
+			}
+
+			p.Version = binary.LittleEndian.Uint32(kv.value)
+		default:
+			p.unknowns = append(p.unknowns, kv)
+		}
+	}
+
+	switch p.Version {
+	case PsbtVersion0:
+		if unsignedTx == nil || hasTxVersion || hasCounts != 0 || p.LockTime != 0 || p.TxModifiable != 0 {
+			return nil, ErrPsbtMalformed
+		}
+
+		for _, txin := range unsignedTx.TxIn {
+			if len(txin.SignatureScript) != 0 || len(txin.Witness) != 0 {
+				return nil, ErrPsbtMalformed
+			}
+		}
+
+		p.TxVersion = unsignedTx.Version
+		p.LockTime = unsignedTx.LockTime
+		inputCount = uint64(len(unsignedTx.TxIn))
+		outputCount = uint64(len(unsignedTx.TxOut))
+	case PsbtVersion2:
+		if unsignedTx != nil || !hasTxVersion || hasCounts != 2 || p.TxVersion < 2 {
+			return nil, ErrPsbtMalformed
+		}
+	default:
+		return nil, ErrPsbtVersion
+	}
+
+	if inputCount > uint64(r.Len()) || outputCount > uint64(r.Len()) {
+		return nil, ErrPsbtMalformed
+	}
+
+	for i := 0; i < int(inputCount); i++ {
+		kvs, err := readPsbtMap(r)
+
+		if err != nil {
+			return nil, err
+		}
+
+		input, err := parsePsbtInput(kvs, p.Version)
+
+		if err != nil {
+			return nil, err
+		}
+
+		if unsignedTx != nil {
+			input.PreviousOutPoint = unsignedTx.TxIn[i].PreviousOutPoint
+			input.Sequence = unsignedTx.TxIn[i].Sequence
+		}
+
+		if input.NonWitnessUtxo != nil && (input.NonWitnessUtxo.TxHash() != input.PreviousOutPoint.Hash ||
+			int(input.PreviousOutPoint.Index) >= len(input.NonWitnessUtxo.TxOut)) {
+			return nil, ErrPsbtMalformed
+		}
+
+		p.Inputs = append(p.Inputs, input)
+	}
+
+	for i := 0; i < int(outputCount); i++ {
+		kvs, err := readPsbtMap(r)
+
+		if err != nil {
+			return nil, err
+		}
+
+		output, err := parsePsbtOutput(kvs, p.Version)
+
+		if err != nil {
+			return nil, err
+		}
+
+		if unsignedTx != nil {
+			output.Amount = unsignedTx.TxOut[i].Value
+			output.Script = unsignedTx.TxOut[i].PkScript
+		}
+
+		p.Outputs = append(p.Outputs, output)
+	}
+
+	if r.Len() != 0 {
+		return nil, ErrPsbtMalformed
+	}
+
+	return p, nil
+}
+
+// ParsePsbtBase64 parse base64 encoded psbt
+func ParsePsbtBase64(encoded string) (*Psbt, error) {
+	data, err := base64.StdEncoding.DecodeString(encoded)
+
+	if err != nil {
+		return nil, ErrPsbtMalformed
+	}
+
+	return ParsePsbt(data)
+}
+
+func parsePsbtInput(kvs []psbtKV, version uint32) (*PsbtInput, error) {
+	input := &PsbtInput{
+		Sequence: wire.MaxTxInSequenceNum,
+	}
+
+	var hasTxid, hasIndex bool
+
+	for _, kv := range kvs {
+		keyType, keyData := kv.key[0], kv.key[1:]
+
+		// version 2 only fields, the same types with key data are unknowns
+		if version == PsbtVersion0 && keyType >= psbtInPreviousTxid && keyType <= psbtInRequiredHeightLocktime {
+			if len(keyData) == 0 {
+				return nil, ErrPsbtMalformed
+			}
+
+			input.unknowns = append(input.unknowns, kv)
+
+			continue
+		}
+
+		switch keyType {
+		case psbtInPartialSig:
+			if len(keyData) != btcec.PubKeyBytesLenCompressed && len(keyData) != btcec.PubKeyBytesLenUncompressed {
+				return nil, ErrPsbtMalformed
+			}
+
+			input.PartialSigs = append(input.PartialSigs, PsbtPartialSig{PubKey: keyData, Signature: kv.value})
+
+			continue
+		case psbtInBip32Derivation:
+			derivation, err := readBip32Derivation(keyData, kv.value)
+
+			if err != nil {
+				return nil, err
+			}
+
+			input.Bip32Derivation = append(input.Bip32Derivation, derivation)
+
+			continue
+		case psbtInTapBip32Derivation:
+			derivation, err := readTaprootBip32Derivation(keyData, kv.value)
+
+			if err != nil {
+				return nil, err
+			}
+
+			input.TaprootBip32Derivation = append(input.TaprootBip32Derivation, derivation)
+
+			continue
+		case psbtInTapScriptSig, psbtInTapLeafScript, psbtInTapMerkleRoot:
+			// script path data is kept for other signers, the wallet only
+			// spends the key path
+			if !validTaprootScriptPathEntry(keyType, keyData, kv.value) {
+				return nil, ErrPsbtMalformed
+			}
+
+			input.unknowns = append(input.unknowns, kv)
+
+			continue
+		}
+
+		if _, known := psbtInputKeys[keyType]; !known {
+			input.unknowns = append(input.unknowns, kv)
+
+			continue
+		}
+
+		if len(keyData) != 0 {
+			return nil, ErrPsbtMalformed
+		}
+
+		var err error
+
+		switch keyType {
+		case psbtInNonWitnessUtxo:
+			input.NonWitnessUtxo = &wire.MsgTx{}
+			err = input.NonWitnessUtxo.Deserialize(bytes.NewReader(kv.value))
+		case psbtInWitnessUtxo:
+			input.WitnessUtxo, err = readTxOut(kv.value)
+		case psbtInSighashType:
+			err = psbtUint32(kv.value, (*uint32)(&input.SighashType))
+			input.HasSighashType = true
+		case psbtInRedeemScript:
+			input.RedeemScript = kv.value
+		case psbtInWitnessScript:
+			input.WitnessScript = kv.value
+		case psbtInFinalScriptSig:
+			input.FinalScriptSig = kv.value
+		case psbtInFinalScriptWitness:
+			input.FinalScriptWitness, err = readWitness(kv.value)
+		case psbtInPreviousTxid:
+			if len(kv.value) != chainhash.HashSize {
+				return nil, ErrPsbtMalformed
+			}
+
+			copy(input.PreviousOutPoint.Hash[:], kv.value)
+			hasTxid = true
+		case psbtInOutputIndex:
+			err = psbtUint32(kv.value, &input.PreviousOutPoint.Index)
+			hasIndex = true
+		case psbtInSequence:
+			err = psbtUint32(kv.value, &input.Sequence)
+		case psbtInRequiredTimeLocktime:
+			err = psbtUint32(kv.value, &input.RequiredTimeLocktime)
+
+			if input.RequiredTimeLocktime < txscript.LockTimeThreshold {
+				return nil, ErrPsbtMalformed
+			}
+		case psbtInRequiredHeightLocktime:
+			err = psbtUint32(kv.value, &input.RequiredHeightLocktime)
+
+			if input.RequiredHeightLocktime == 0 || input.RequiredHeightLocktime >= txscript.LockTimeThreshold {
+				return nil, ErrPsbtMalformed
+			}
+		case psbtInTapKeySig:
+			if len(kv.value) != 64 && len(kv.value) != 65 {
+				return nil, ErrPsbtMalformed
+			}
+
+			input.TaprootKeySpendSig = kv.value
+		case psbtInTapInternalKey:
+			if len(kv.value) != 32 {
+				return nil, ErrPsbtMalformed
+			}
+
+			input.TaprootInternalKey = kv.value
+		}
+
+		if err != nil {
+			return nil, ErrPsbtMalformed
+		}
+	}
+
+	if version == PsbtVersion2 && (!hasTxid || !hasIndex) {
+		return nil, ErrPsbtMalformed
+	}
+
+	return input, nil
+}
+
+// validTaprootScriptPathEntry check sizes of BIP371 script path entries:
+// x-only key and leaf hash keyed signatures, control block keyed leaf
+// scripts and the merkle root
+func validTaprootScriptPathEntry(keyType byte, keyData []byte, value []byte) bool {
+	switch keyType {
+	case psbtInTapScriptSig:
+		return len(keyData) == 64 && (len(value) == 64 || len(value) == 65)
+	case psbtInTapLeafScript:
+		return len(keyData) >= 33 && (len(keyData)-33)%32 == 0 && len(keyData) <= 33+128*32 && len(value) >= 1
+	case psbtInTapMerkleRoot:
+		return len(keyData) == 0 && len(value) == 32
+	}
+
+	return false
+}
+
+// psbtInputKeys input key types without key data
+var psbtInputKeys = map[byte]struct{}{
+	psbtInNonWitnessUtxo:         {},
+	psbtInWitnessUtxo:            {},
+	psbtInSighashType:            {},
+	psbtInRedeemScript:           {},
+	psbtInWitnessScript:          {},
+	psbtInFinalScriptSig:         {},
+	psbtInFinalScriptWitness:     {},
+	psbtInPreviousTxid:           {},
+	psbtInOutputIndex:            {},
+	psbtInSequence:               {},
+	psbtInRequiredTimeLocktime:   {},
+	psbtInRequiredHeightLocktime: {},
+	psbtInTapKeySig:              {},
+	psbtInTapInternalKey:         {},
+}
+
+func parsePsbtOutput(kvs []psbtKV, version uint32) (*PsbtOutput, error) {
+	output := &PsbtOutput{}
+
+	var hasAmount, hasScript bool
+
+	for _, kv := range kvs {
+		keyType, keyData := kv.key[0], kv.key[1:]
+
+		switch keyType {
+		case psbtOutBip32Derivation:
+			derivation, err := readBip32Derivation(keyData, kv.value)
+
+			if err != nil {
+				return nil, err
+			}
+
+			output.Bip32Derivation = append(output.Bip32Derivation, derivation)
+
+			continue
+		case psbtOutTapBip32Derivation:
+			derivation, err := readTaprootBip32Derivation(keyData, kv.value)
+
+			if err != nil {
+				return nil, err
+			}
+
+			output.TaprootBip32Derivation = append(output.TaprootBip32Derivation, derivation)
+
+			continue
+		case psbtOutRedeemScript, psbtOutWitnessScript, psbtOutAmount, psbtOutScript, psbtOutTapInternalKey:
+		default:
+			output.unknowns = append(output.unknowns, kv)
+
+			continue
+		}
+
+		if len(kv.key) != 1 {
+			return nil, ErrPsbtMalformed
+		}
+
+		if version == PsbtVersion0 && (keyType == psbtOutAmount || keyType == psbtOutScript) {
+			return nil, ErrPsbtMalformed
+		}
+
+		switch keyType {
+		case psbtOutRedeemScript:
+			output.RedeemScript = kv.value
+		case psbtOutWitnessScript:
+			output.WitnessScript = kv.value
+		case psbtOutAmount:
+			if len(kv.value) != 8 {
+				return nil, ErrPsbtMalformed
+			}
+
+			output.Amount = int64(binary.LittleEndian.Uint64(kv.value))
+			hasAmount = true
+		case psbtOutScript:
+			output.Script = kv.value
+			hasScript = true
+		case psbtOutTapInternalKey:
+			if len(kv.value) != 32 {
+				return nil, ErrPsbtMalformed
+			}
+
+			output.TaprootInternalKey = kv.value
+		}
+	}
+
+	if version == PsbtVersion2 && (!hasAmount || !hasScript) {
+		return nil, ErrPsbtMalformed
+	}
+
+	return output, nil
+}
+
+// readPsbtMap read key value map up to its separator, duplicate keys are rejected
+func readPsbtMap(r *bytes.Reader) ([]psbtKV, error) {
+	var kvs []psbtKV
+
+	keys := make(map[string]bool)
+
+	for {
+		key, err := wire.ReadVarBytes(r, 0, maxPsbtValueSize, "psbt key")
+
+		if err != nil {
+			return nil, ErrPsbtMalformed
+		}
+
+		if len(key) == 0 {
+			return kvs, nil
+		}
+
+		if keys[string(key)] {
+			return nil, ErrPsbtMalformed
+		}
+
+		keys[string(key)] = true
+
+		value, err := wire.ReadVarBytes(r, 0, maxPsbtValueSize, "psbt value")
+
+		if err != nil {
+			return nil, ErrPsbtMalformed
+		}
+
+		kvs = append(kvs, psbtKV{key: key, value: value})
+	}
+}
+
+// readBip32Derivation parse derivation of public key: the 4 bytes master key
+// fingerprint followed by little endian path indexes
+func readBip32Derivation(pubKey []byte, value []byte) (PsbtBip32Derivation, error) {
+	if len(pubKey) != btcec.PubKeyBytesLenCompressed && len(pubKey) != btcec.PubKeyBytesLenUncompressed {
+		return PsbtBip32Derivation{}, ErrPsbtMalformed
+	}
+
+	fingerprint, path, err := readKeyOrigin(value)
+
+	if err != nil {
+		return PsbtBip32Derivation{}, err
+	}
+
+	return PsbtBip32Derivation{PubKey: pubKey, Fingerprint: fingerprint, Path: path}, nil
+}
+
+// readTaprootBip32Derivation parse derivation of x-only public key: the leaf
+// hashes followed by the key origin
+func readTaprootBip32Derivation(xOnlyPubKey []byte, value []byte) (PsbtTaprootBip32Derivation, error) {
+	if len(xOnlyPubKey) != 32 {
+		return PsbtTaprootBip32Derivation{}, ErrPsbtMalformed
+	}
+
+	r := bytes.NewReader(value)
+
+	count, err := wire.ReadVarInt(r, 0)
+
+	if err != nil || count > uint64(r.Len()/chainhash.HashSize) {
+		return PsbtTaprootBip32Derivation{}, ErrPsbtMalformed
+	}
+
+	derivation := PsbtTaprootBip32Derivation{XOnlyPubKey: xOnlyPubKey}
+
+	offset := len(value) - r.Len()
+
+	for i := 0; i < int(count); i++ {
+		derivation.LeafHashes = append(derivation.LeafHashes, value[offset:offset+chainhash.HashSize])
+
+		offset += chainhash.HashSize
+	}
+
+	derivation.Fingerprint, derivation.Path, err = readKeyOrigin(value[offset:])
+
+	if err != nil {
+		return PsbtTaprootBip32Derivation{}, err
+	}
+
+	return derivation, nil
+}
+
+func readKeyOrigin(value []byte) (uint32, []uint32, error) {
+	if len(value) < 4 || len(value)%4 != 0 {
+		return 0, nil, ErrPsbtMalformed
+	}
+
+	path := make([]uint32, 0, len(value)/4-1)
+
+	for i := 4; i < len(value); i += 4 {
+		path = append(path, binary.LittleEndian.Uint32(value[i:]))
+	}
+
+	return binary.BigEndian.Uint32(value), path, nil
+}
+
+func keyOriginBytes(fingerprint uint32, path []uint32) []byte {
+	value := make([]byte, 4, 4+len(path)*4)
+
+	binary.BigEndian.PutUint32(value, fingerprint)
+
+	for _, index := range path {
+		value = append(value, uint32Bytes(index)...)
+	}
+
+	return value
+}
+
+func (m *psbtMap) addBip32Derivations(keyType byte, derivations []PsbtBip32Derivation) {
+	for _, derivation := range derivations {
+		m.add(append([]byte{keyType}, derivation.PubKey...), keyOriginBytes(derivation.Fingerprint, derivation.Path))
+	}
+}
+
+func (m *psbtMap) addTaprootBip32Derivations(keyType byte, derivations []PsbtTaprootBip32Derivation) {
+	for _, derivation := range derivations {
+		var value bytes.Buffer
+
+		wire.WriteVarInt(&value, 0, uint64(len(derivation.LeafHashes)))
+
+		for _, leafHash := range derivation.LeafHashes {
+			value.Write(leafHash)
+		}
+
+		value.Write(keyOriginBytes(derivation.Fingerprint, derivation.Path))
+
+		m.add(append([]byte{keyType}, derivation.XOnlyPubKey...), value.Bytes())
+	}
+}
+
+func psbtUint32(value []byte, n *uint32) error {
+	if len(value) != 4 {
+		return ErrPsbtMalformed
+	}
+
+	*n = binary.LittleEndian.Uint32(value)
+
+	return nil
+}
+
+func readTxOut(data []byte) (*wire.TxOut, error) {
+	if len(data) < 9 {
+		return nil, ErrPsbtMalformed
+	}
+
+	r := bytes.NewReader(data[8:])
+
+	pkScript, err := wire.ReadVarBytes(r, 0, maxPsbtValueSize, "pkScript")
+
+	if err != nil || r.Len() != 0 {
+		return nil, ErrPsbtMalformed
+	}
+
+	return wire.NewTxOut(int64(binary.LittleEndian.Uint64(data)), pkScript), nil
+}
+
+func readWitness(data []byte) (wire.TxWitness, error) {
+	r := bytes.NewReader(data)
+
+	count, err := wire.ReadVarInt(r, 0)
+
+	if err != nil || count > uint64(len(data)) {
+		return nil, ErrPsbtMalformed
+	}
+
+	witness := make(wire.TxWitness, count)
+
+	for i := range witness {
+		witness[i], err = wire.ReadVarBytes(r, 0, maxPsbtValueSize, "witness item")
+
+		if err != nil {
+			return nil, ErrPsbtMalformed
+		}
+	}
+
+	if r.Len() != 0 {
+		return nil, ErrPsbtMalformed
+	}
+
+	return witness, nil
+}
+
+func serializeWitness(witness wire.TxWitness) []byte {
+	var buff bytes.Buffer
+
+	wire.WriteVarInt(&buff, 0, uint64(len(witness)))
+
+	for _, item := range witness {
+		wire.WriteVarBytes(&buff, 0, item)
+	}
+
+	return buff.Bytes()
+}
+
+// UnsignedTx unsigned transaction of the psbt
+func (p *Psbt) UnsignedTx() *wire.MsgTx {
+	tx := wire.NewMsgTx(p.TxVersion)
+
+	for _, input := range p.Inputs {
+		outPoint := input.PreviousOutPoint
+
+		txin := wire.NewTxIn(&outPoint, nil, nil)
+
+		txin.Sequence = input.Sequence
+
+		tx.AddTxIn(txin)
+	}
+
+	for _, output := range p.Outputs {
+		tx.AddTxOut(wire.NewTxOut(output.Amount, output.Script))
+	}
+
+	tx.LockTime = p.lockTime()
+
+	return tx
+}
+
+// lockTime BIP370 lock time determination of version 2, height lock
+// times are preferred when every input allows them
+func (p *Psbt) lockTime() uint32 {
+	if p.Version == PsbtVersion0 {
+		return p.LockTime
+	}
+
+	var (
+		height, time uint32
+		required     bool
+		heightOnly   = true
+	)
+
+	for _, input := range p.Inputs {
+		if input.RequiredHeightLocktime == 0 && input.RequiredTimeLocktime == 0 {
+			continue
+		}
+
+		required = true
+
+		if input.RequiredHeightLocktime == 0 {
+			heightOnly = false
+		}
+
+		if input.RequiredHeightLocktime > height {
+			height = input.RequiredHeightLocktime
+		}
+
+		if input.RequiredTimeLocktime > time {
+			time = input.RequiredTimeLocktime
+		}
+	}
+
+	switch {
+	case !required:
+		return p.LockTime
+	case heightOnly:
+		return height
+	default:
+		return time
+	}
+}
+
+// Bytes serialize psbt
+func (p *Psbt) Bytes() ([]byte, error) {
+	var buff bytes.Buffer
+
+	buff.Write(psbtMagic)
+
+	if err := p.serialize(&buff); err != nil {
+		return nil, err
+	}
+
+	return buff.Bytes(), nil
+}
+
+// Base64 base64 encoding of serialized psbt
+func (p *Psbt) Base64() (string, error) {
+	data, err := p.Bytes()
+
+	if err != nil {
+		return "", err
+	}
+
+	return base64.StdEncoding.EncodeToString(data), nil
+}
+
+func (p *Psbt) serialize(w *bytes.Buffer) error {
+	var m psbtMap
+
+	switch p.Version {
+	case PsbtVersion0:
+		var tx bytes.Buffer
+
+		if err := p.UnsignedTx().SerializeNoWitness(&tx); err != nil {
+			return err
+		}
+
+		m.add([]byte{psbtGlobalUnsignedTx}, tx.Bytes())
+	case PsbtVersion2:
+		var inputCount, outputCount bytes.Buffer
+
+		wire.WriteVarInt(&inputCount, 0, uint64(len(p.Inputs)))
+		wire.WriteVarInt(&outputCount, 0, uint64(len(p.Outputs)))
+
+		m.add([]byte{psbtGlobalTxVersion}, uint32Bytes(uint32(p.TxVersion)))
+
+		if p.LockTime != 0 {
+			m.add([]byte{psbtGlobalFallbackLocktime}, uint32Bytes(p.LockTime))
+		}
+
+		m.add([]byte{psbtGlobalInputCount}, inputCount.Bytes())
+		m.add([]byte{psbtGlobalOutputCount}, outputCount.Bytes())
+
+		if p.TxModifiable != 0 {
+			m.add([]byte{psbtGlobalTxModifiable}, []byte{p.TxModifiable})
+		}
+
+		m.add([]byte{psbtGlobalVersion}, uint32Bytes(p.Version))
+	default:
+		return ErrPsbtVersion
+	}
+
+	m.write(w, p.unknowns)
+
+	for _, input := range p.Inputs {
+		if err := input.serialize(w, p.Version); err != nil {
+			return err
+		}
+	}
+
+	for _, output := range p.Outputs {
+		output.serialize(w, p.Version)
+	}
+
+	return nil
+}
+
+func (input *PsbtInput) serialize(w *bytes.Buffer, version uint32) error {
+	var m psbtMap
+
+	if input.NonWitnessUtxo != nil {
+		var tx bytes.Buffer
+
+		if err := input.NonWitnessUtxo.Serialize(&tx); err != nil {
+			return err
+		}
+
+		m.add([]byte{psbtInNonWitnessUtxo}, tx.Bytes())
+	}
+
+	if input.WitnessUtxo != nil {
+		var txout bytes.Buffer
+
+		if err := wire.WriteTxOut(&txout, 0, 0, input.WitnessUtxo); err != nil {
+			return err
+		}
+
+		m.add([]byte{psbtInWitnessUtxo}, txout.Bytes())
+	}
+
+	for _, sig := range input.PartialSigs {
+		m.add(append([]byte{psbtInPartialSig}, sig.PubKey...), sig.Signature)
+	}
+
+	if input.HasSighashType {
+		m.add([]byte{psbtInSighashType}, uint32Bytes(uint32(input.SighashType)))
+	}
+
+	if input.RedeemScript != nil {
+		m.add([]byte{psbtInRedeemScript}, input.RedeemScript)
+	}
+
+	if input.WitnessScript != nil {
+		m.add([]byte{psbtInWitnessScript}, input.WitnessScript)
+	}
+
+	m.addBip32Derivations(psbtInBip32Derivation, input.Bip32Derivation)
+
+	if input.FinalScriptSig != nil {
+		m.add([]byte{psbtInFinalScriptSig}, input.FinalScriptSig)
+	}
+
+	if input.FinalScriptWitness != nil {
+		m.add([]byte{psbtInFinalScriptWitness}, serializeWitness(input.FinalScriptWitness))
+	}
+
+	if version == PsbtVersion2 {
+		m.add([]byte{psbtInPreviousTxid}, input.PreviousOutPoint.Hash[:])
+		m.add([]byte{psbtInOutputIndex}, uint32Bytes(input.PreviousOutPoint.Index))
+
+		if input.Sequence != wire.MaxTxInSequenceNum {
+			m.add([]byte{psbtInSequence}, uint32Bytes(input.Sequence))
+		}
+
+		if input.RequiredTimeLocktime != 0 {
+			m.add([]byte{psbtInRequiredTimeLocktime}, uint32Bytes(input.RequiredTimeLocktime))
+		}
+
+		if input.RequiredHeightLocktime != 0 {
+			m.add([]byte{psbtInRequiredHeightLocktime}, uint32Bytes(input.RequiredHeightLocktime))
+		}
+	}
+
+	if input.TaprootKeySpendSig != nil {
+		m.add([]byte{psbtInTapKeySig}, input.TaprootKeySpendSig)
+	}
+
+	m.addTaprootBip32Derivations(psbtInTapBip32Derivation, input.TaprootBip32Derivation)
+
+	if input.TaprootInternalKey != nil {
+		m.add([]byte{psbtInTapInternalKey}, input.TaprootInternalKey)
+	}
+
+	m.write(w, input.unknowns)
+
+	return nil
+}
+
+func (output *PsbtOutput) serialize(w *bytes.Buffer, version uint32) {
+	var m psbtMap
+
+	if output.RedeemScript != nil {
+		m.add([]byte{psbtOutRedeemScript}, output.RedeemScript)
+	}
+
+	if output.WitnessScript != nil {
+		m.add([]byte{psbtOutWitnessScript}, output.WitnessScript)
+	}
+
+	m.addBip32Derivations(psbtOutBip32Derivation, output.Bip32Derivation)
+
+	if version == PsbtVersion2 {
+		amount := make([]byte, 8)
+
+		binary.LittleEndian.PutUint64(amount, uint64(output.Amount))
+
+		m.add([]byte{psbtOutAmount}, amount)
+		m.add([]byte{psbtOutScript}, output.Script)
+	}
+
+	if output.TaprootInternalKey != nil {
+		m.add([]byte{psbtOutTapInternalKey}, output.TaprootInternalKey)
+	}
+
+	m.addTaprootBip32Derivations(psbtOutTapBip32Derivation, output.TaprootBip32Derivation)
+
+	m.write(w, output.unknowns)
+}
+
+func writePsbtKV(w io.Writer, key []byte, value []byte) {
+	wire.WriteVarBytes(w, 0, key)
+	wire.WriteVarBytes(w, 0, value)
+}
+
+// psbtMap entries of one psbt map
+type psbtMap []psbtKV
+
+func (m *psbtMap) add(key []byte, value []byte) {
+	*m = append(*m, psbtKV{key: key, value: value})
+}
+
+// write write entries and unknowns in key order as bitcoind does, followed
+// by the map separator
+func (m psbtMap) write(w io.Writer, unknowns []psbtKV) {
+	kvs := append(m, unknowns...)
+
+	sort.SliceStable(kvs, func(i, j int) bool {
+		return bytes.Compare(kvs[i].key, kvs[j].key) < 0
+	})
+
+	for _, kv := range kvs {
+		writePsbtKV(w, kv.key, kv.value)
+	}
+
+	w.Write([]byte{0x00})
+}
+
+func uint32Bytes(n uint32) []byte {
+	buff := make([]byte, 4)
+
+	binary.LittleEndian.PutUint32(buff, n)
+
+	return buff
+}
+
+func decodeTx(rawTx string) (*wire.MsgTx, error) {
+	data, err := hex.DecodeString(rawTx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var tx wire.MsgTx
+
+	if err := tx.Deserialize(bytes.NewReader(data)); err != nil {
+		return nil, err
+	}
+
+	return &tx, nil
+}
@@ -0,0 +1,737 @@
+package btc
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/sha256"
+	"fmt"
+
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/btcsuite/btcutil"
+)
+
+// CreatePsbt create unsigned psbt paying amount to address, the change is
+// returned to the wallet address. Inputs and the change output carry the bip32
+// origin of the wallet key if the wallet is derived from a seed
+func (wallet *Wallet) CreatePsbt(
+	inputs []UTXO,
+	to string,
+	amount btcutil.Amount,
+	feeRate btcutil.Amount,
+	options ...PayOption) (*Psbt, error) {
+
+	addr, err := decodeAddress(to, wallet.net)
+
+	if err != nil {
+		return nil, err
+	}
+
+	p, err := createPsbt(txFrom(inputs).to(addr, amount).change(wallet.Address).feeRate(feeRate).options(options))
+
+	if err != nil {
+		return nil, err
+	}
+
+	pubKey := wallet.publicKey.SerializeCompressed()
+
+	for _, input := range p.Inputs {
+		switch wallet.addressType {
+		case AddressTypeP2SHP2WPKH:
+			input.RedeemScript = witnessRedeemScript(btcutil.Hash160(pubKey))
+		case AddressTypeP2TR:
+			input.TaprootInternalKey = pubKey[1:]
+		}
+
+		input.Bip32Derivation, input.TaprootBip32Derivation = wallet.psbtDerivation()
+	}
+
+	changeScript, err := payToAddrScript(wallet.Address)
+
+	if err != nil {
+		return nil, err
+	}
+
+	for _, output := range p.Outputs {
+		if bytes.Equal(output.Script, changeScript) {
+			output.Bip32Derivation, output.TaprootBip32Derivation = wallet.psbtDerivation()
+		}
+	}
+
+	return p, nil
+}
+
+// psbtDerivation bip32 origin of the wallet key for psbt inputs and outputs,
+// none unless the wallet is derived from a seed
+func (wallet *Wallet) psbtDerivation() ([]PsbtBip32Derivation, []PsbtTaprootBip32Derivation) {
+	if wallet.origin == nil {
+		return nil, nil
+	}
+
+	pubKey := wallet.serializePublicKey()
+
+	if wallet.addressType == AddressTypeP2TR {
+		return nil, []PsbtTaprootBip32Derivation{{
+			XOnlyPubKey: pubKey[1:],
+			Fingerprint: wallet.origin.fingerprint,
+			Path:        wallet.origin.path,
+		}}
+	}
+
+	return []PsbtBip32Derivation{{
+		PubKey:      pubKey,
+		Fingerprint: wallet.origin.fingerprint,
+		Path:        wallet.origin.path,
+	}}, nil
+}
+
+// CreatePsbt create unsigned psbt paying amount to address, the change is
+// returned to the multisig address
+func (wallet *MultisigWallet) CreatePsbt(
+	inputs []UTXO,
+	to string,
+	amount btcutil.Amount,
+	feeRate btcutil.Amount,
+	options ...PayOption) (*Psbt, error) {
+
+	addr, err := decodeAddress(to, wallet.net)
+
+	if err != nil {
+		return nil, err
+	}
+
+	p, err := createPsbt(txFrom(inputs).to(addr, amount).change(wallet.Address).feeRate(feeRate).options(options).multisig(wallet.script, wallet.threshold))
+
+	if err != nil {
+		return nil, err
+	}
+
+	for _, input := range p.Inputs {
+		if wallet.addressType == AddressTypeP2WSH {
+			input.WitnessScript = wallet.script
+		} else {
+			input.RedeemScript = wallet.script
+		}
+	}
+
+	return p, nil
+}
+
+func createPsbt(trans *transaction) (*Psbt, error) {
+	tx, err := trans.done()
+
+	if err != nil {
+		return nil, err
+	}
+
+	utxos := make([]UTXO, 0, len(tx.TxIn))
+
+	for _, txin := range tx.TxIn {
+		utxos = append(utxos, trans.txIn[txin])
+	}
+
+	return newPsbtFromTx(tx, utxos, PsbtVersion0)
+}
+
+// prevOut output spent by input, nil if the psbt carries no utxo data
+func (p *Psbt) prevOut(idx int) *wire.TxOut {
+	input := p.Inputs[idx]
+
+	if input.WitnessUtxo != nil {
+		return input.WitnessUtxo
+	}
+
+	if input.NonWitnessUtxo != nil {
+		return input.NonWitnessUtxo.TxOut[input.PreviousOutPoint.Index]
+	}
+
+	return nil
+}
+
+// finalized check input has final scriptSig or witness
+func (input *PsbtInput) finalized() bool {
+	return input.FinalScriptSig != nil || input.FinalScriptWitness != nil
+}
+
+// addPartialSig add or replace signature of public key
+func (input *PsbtInput) addPartialSig(pubKey []byte, sig []byte) {
+	for i := range input.PartialSigs {
+		if bytes.Equal(input.PartialSigs[i].PubKey, pubKey) {
+			input.PartialSigs[i].Signature = sig
+			return
+		}
+	}
+
+	input.PartialSigs = append(input.PartialSigs, PsbtPartialSig{PubKey: pubKey, Signature: sig})
+}
+
+func (input *PsbtInput) partialSig(pubKey []byte) []byte {
+	for _, sig := range input.PartialSigs {
+		if bytes.Equal(sig.PubKey, pubKey) {
+			return sig.Signature
+		}
+	}
+
+	return nil
+}
+
+// SignPsbt add wallet signatures to every psbt input the wallet key can
+// spend, returns the number of signed inputs. Inputs are signed with
+// SIGHASH_ALL, or SIGHASH_DEFAULT for taproot, and inputs requesting another
+// sighash type are refused unless it is allowed by WithSigHashType
+func (wallet *Wallet) SignPsbt(p *Psbt, options ...PayOption) (int, error) {
+	if wallet.privateKey == nil {
+		return 0, ErrWalletClosed
+	}
+
+	allowed := txFrom(nil).options(options).sigHashType
+
+	tx := p.UnsignedTx()
+
+	sigHashes := txscript.NewTxSigHashes(tx)
+
+	pubKey := wallet.serializePublicKey()
+	pubKeyHash := btcutil.Hash160(pubKey)
+
+	signed := 0
+
+	for i, input := range p.Inputs {
+		prevOut := p.prevOut(i)
+
+		if input.finalized() || prevOut == nil {
+			continue
+		}
+
+		pkScript := prevOut.PkScript
+
+		hashType, err := psbtSigHashType(input, i, allowed, isTaprootScript(pkScript))
+
+		if err != nil {
+			return signed, err
+		}
+
+		if isTaprootScript(pkScript) {
+			ok, err := wallet.signPsbtTaproot(p, tx, i, hashType)
+
+			if err != nil {
+				return signed, err
+			}
+
+			if ok {
+				signed++
+			}
+
+			continue
+		}
+
+		if err := checkSigHashType(tx, i, hashType); err != nil {
+			return signed, err
+		}
+
+		var sig []byte
+
+		switch txscript.GetScriptClass(pkScript) {
+		case txscript.PubKeyHashTy:
+			if !bytes.Equal(pkScript[3:23], pubKeyHash) {
+				continue
+			}
+
+			sig, err = txscript.RawTxInSignature(tx, i, pkScript, hashType, wallet.privateKey)
+		case txscript.WitnessV0PubKeyHashTy:
+			if !wallet.compressed || !bytes.Equal(pkScript[2:22], pubKeyHash) {
+				continue
+			}
+
+			sig, err = txscript.RawTxInWitnessSignature(tx, sigHashes, i, prevOut.Value, pkScript, hashType, wallet.privateKey)
+		case txscript.ScriptHashTy:
+			redeemScript := input.RedeemScript
+
+			if redeemScript == nil && wallet.compressed {
+				redeemScript = witnessRedeemScript(pubKeyHash)
+			}
+
+			if !bytes.Equal(pkScript[2:22], btcutil.Hash160(redeemScript)) {
+				continue
+			}
+
+			input.RedeemScript = redeemScript
+
+			sig, err = wallet.signPsbtScript(tx, sigHashes, i, prevOut.Value, redeemScript, input.WitnessScript, hashType)
+		case txscript.WitnessV0ScriptHashTy:
+			witnessScript := sha256.Sum256(input.WitnessScript)
+
+			if input.WitnessScript == nil || !bytes.Equal(pkScript[2:], witnessScript[:]) {
+				continue
+			}
+
+			sig, err = wallet.signPsbtScript(tx, sigHashes, i, prevOut.Value, pkScript, input.WitnessScript, hashType)
+		default:
+			continue
+		}
+
+		if err != nil {
+			return signed, err
+		}
+
+		if sig != nil {
+			input.addPartialSig(pubKey, sig)
+			signed++
+		}
+	}
+
+	return signed, nil
+}
+
+// signPsbtScript sign p2sh redeem script or p2wsh witness script spend,
+// returns nil signature if the wallet key is not part of the script
+func (wallet *Wallet) signPsbtScript(tx *wire.MsgTx, sigHashes *txscript.TxSigHashes, idx int, amount int64, script []byte, witnessScript []byte, hashType txscript.SigHashType) ([]byte, error) {
+	pubKey := wallet.serializePublicKey()
+
+	switch txscript.GetScriptClass(script) {
+	case txscript.WitnessV0PubKeyHashTy:
+		if !wallet.compressed || !bytes.Equal(script[2:22], btcutil.Hash160(pubKey)) {
+			return nil, nil
+		}
+
+		return txscript.RawTxInWitnessSignature(tx, sigHashes, idx, amount, script, hashType, wallet.privateKey)
+	case txscript.WitnessV0ScriptHashTy:
+		scriptHash := sha256.Sum256(witnessScript)
+
+		if !bytes.Equal(script[2:], scriptHash[:]) || !multisigHasKey(witnessScript, pubKey) {
+			return nil, nil
+		}
+
+		return txscript.RawTxInWitnessSignature(tx, sigHashes, idx, amount, witnessScript, hashType, wallet.privateKey)
+	case txscript.MultiSigTy:
+		if !multisigHasKey(script, pubKey) {
+			return nil, nil
+		}
+
+		return txscript.RawTxInSignature(tx, idx, script, hashType, wallet.privateKey)
+	}
+
+	return nil, nil
+}
+
+// psbtSigHashType sighash type to sign psbt input idx with, the type
+// requested by the input must be the allowed type
+func psbtSigHashType(input *PsbtInput, idx int, allowed txscript.SigHashType, taproot bool) (txscript.SigHashType, error) {
+	hashType := allowed
+
+	// SIGHASH_DEFAULT commits to the same data as SIGHASH_ALL
+	if taproot && allowed == txscript.SigHashAll {
+		hashType = SigHashDefault
+	}
+
+	if !input.HasSighashType {
+		return hashType, nil
+	}
+
+	if input.SighashType == allowed || input.SighashType == hashType {
+		return input.SighashType, nil
+	}
+
+	return 0, fmt.Errorf("%w: input %d requests %#x", ErrPsbtSigHashType, idx, uint32(input.SighashType))
+}
+
+// signPsbtTaproot sign BIP86 key path spend of input idx
+func (wallet *Wallet) signPsbtTaproot(p *Psbt, tx *wire.MsgTx, idx int, hashType txscript.SigHashType) (bool, error) {
+	input := p.Inputs[idx]
+
+	if !wallet.compressed {
+		return false, nil
+	}
+
+	outputKey, err := taprootOutputKey(wallet.publicKey)
+
+	if err != nil {
+		return false, err
+	}
+
+	if !bytes.Equal(p.prevOut(idx).PkScript[2:], outputKey) {
+		return false, nil
+	}
+
+	prevOuts := make([]*wire.TxOut, len(p.Inputs))
+
+	for i := range p.Inputs {
+		if prevOuts[i] = p.prevOut(i); prevOuts[i] == nil {
+			return false, fmt.Errorf("taproot signing requires utxo of psbt input %d", i)
+		}
+	}
+
+	aux := make([]byte, 32)
+
+	if _, err := rand.Read(aux); err != nil {
+		return false, err
+	}
+
+	sig, err := taprootSignature(tx, idx, prevOuts, hashType, wallet.privateKey, aux)
+
+	if err != nil {
+		return false, err
+	}
+
+	input.TaprootKeySpendSig = sig
+	input.TaprootInternalKey = bytes32(wallet.publicKey.X)
+
+	return true, nil
+}
+
+// multisigPubKeys public keys of multisig script in script order
+func multisigPubKeys(script []byte) ([][]byte, int, error) {
+	if txscript.GetScriptClass(script) != txscript.MultiSigTy {
+		return nil, 0, fmt.Errorf("script is not a multisig script")
+	}
+
+	pubKeys, err := txscript.PushedData(script)
+
+	if err != nil {
+		return nil, 0, err
+	}
+
+	_, threshold, err := txscript.CalcMultiSigStats(script)
+
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return pubKeys, threshold, nil
+}
+
+func multisigHasKey(script []byte, pubKey []byte) bool {
+	pubKeys, _, err := multisigPubKeys(script)
+
+	if err != nil {
+		return false
+	}
+
+	for _, key := range pubKeys {
+		if bytes.Equal(key, pubKey) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// CombinePsbt merge signatures and data of psbts of the same transaction
+func CombinePsbt(psbts ...*Psbt) (*Psbt, error) {
+	if len(psbts) == 0 {
+		return nil, ErrPsbtMalformed
+	}
+
+	data, err := psbts[0].Bytes()
+
+	if err != nil {
+		return nil, err
+	}
+
+	combined, err := ParsePsbt(data)
+
+	if err != nil {
+		return nil, err
+	}
+
+	txHash := combined.UnsignedTx().TxHash()
+
+	for _, p := range psbts[1:] {
+		if p.Version != combined.Version || p.UnsignedTx().TxHash() != txHash {
+			return nil, ErrPsbtMismatch
+		}
+
+		combined.unknowns = combineUnknowns(combined.unknowns, p.unknowns)
+
+		for i, input := range p.Inputs {
+			combined.Inputs[i].combine(input)
+		}
+
+		for i, output := range p.Outputs {
+			to := combined.Outputs[i]
+
+			to.RedeemScript = combineBytes(to.RedeemScript, output.RedeemScript)
+			to.WitnessScript = combineBytes(to.WitnessScript, output.WitnessScript)
+			to.Bip32Derivation = combineBip32Derivations(to.Bip32Derivation, output.Bip32Derivation)
+			to.TaprootInternalKey = combineBytes(to.TaprootInternalKey, output.TaprootInternalKey)
+			to.TaprootBip32Derivation = combineTaprootBip32Derivations(to.TaprootBip32Derivation, output.TaprootBip32Derivation)
+			to.unknowns = combineUnknowns(to.unknowns, output.unknowns)
+		}
+	}
+
+	return combined, nil
+}
+
+func (input *PsbtInput) combine(other *PsbtInput) {
+	if input.NonWitnessUtxo == nil {
+		input.NonWitnessUtxo = other.NonWitnessUtxo
+	}
+
+	if input.WitnessUtxo == nil {
+		input.WitnessUtxo = other.WitnessUtxo
+	}
+
+	for _, sig := range other.PartialSigs {
+		if input.partialSig(sig.PubKey) == nil {
+			input.PartialSigs = append(input.PartialSigs, sig)
+		}
+	}
+
+	if !input.HasSighashType {
+		input.SighashType, input.HasSighashType = other.SighashType, other.HasSighashType
+	}
+
+	if input.FinalScriptWitness == nil {
+		input.FinalScriptWitness = other.FinalScriptWitness
+	}
+
+	input.RedeemScript = combineBytes(input.RedeemScript, other.RedeemScript)
+	input.WitnessScript = combineBytes(input.WitnessScript, other.WitnessScript)
+	input.FinalScriptSig = combineBytes(input.FinalScriptSig, other.FinalScriptSig)
+	input.TaprootKeySpendSig = combineBytes(input.TaprootKeySpendSig, other.TaprootKeySpendSig)
+	input.TaprootInternalKey = combineBytes(input.TaprootInternalKey, other.TaprootInternalKey)
+	input.Bip32Derivation = combineBip32Derivations(input.Bip32Derivation, other.Bip32Derivation)
+	input.TaprootBip32Derivation = combineTaprootBip32Derivations(input.TaprootBip32Derivation, other.TaprootBip32Derivation)
+	input.unknowns = combineUnknowns(input.unknowns, other.unknowns)
+}
+
+func combineBytes(data []byte, other []byte) []byte {
+	if data == nil {
+		return other
+	}
+
+	return data
+}
+
+func combineBip32Derivations(derivations []PsbtBip32Derivation, other []PsbtBip32Derivation) []PsbtBip32Derivation {
+	for _, derivation := range other {
+		found := false
+
+		for _, known := range derivations {
+			found = found || bytes.Equal(known.PubKey, derivation.PubKey)
+		}
+
+		if !found {
+			derivations = append(derivations, derivation)
+		}
+	}
+
+	return derivations
+}
+
+func combineTaprootBip32Derivations(derivations []PsbtTaprootBip32Derivation, other []PsbtTaprootBip32Derivation) []PsbtTaprootBip32Derivation {
+	for _, derivation := range other {
+		found := false
+
+		for _, known := range derivations {
+			found = found || bytes.Equal(known.XOnlyPubKey, derivation.XOnlyPubKey)
+		}
+
+		if !found {
+			derivations = append(derivations, derivation)
+		}
+	}
+
+	return derivations
+}
+
+func combineUnknowns(kvs []psbtKV, other []psbtKV) []psbtKV {
+	for _, kv := range other {
+		found := false
+
+		for _, known := range kvs {
+			found = found || bytes.Equal(known.key, kv.key)
+		}
+
+		if !found {
+			kvs = append(kvs, kv)
+		}
+	}
+
+	return kvs
+}
+
+// Finalize build final scriptSig and witness of every input, fails if an
+// input has not enough signatures
+func (p *Psbt) Finalize() error {
+	for i, input := range p.Inputs {
+		if input.finalized() {
+			continue
+		}
+
+		prevOut := p.prevOut(i)
+
+		if prevOut == nil {
+			return fmt.Errorf("psbt input %d has no utxo", i)
+		}
+
+		if err := input.finalize(prevOut.PkScript); err != nil {
+			return fmt.Errorf("psbt input %d: %s", i, err)
+		}
+
+		// finalized inputs keep only utxo and unknown data
+		input.PartialSigs = nil
+		input.SighashType, input.HasSighashType = 0, false
+		input.RedeemScript = nil
+		input.WitnessScript = nil
+		input.TaprootKeySpendSig = nil
+		input.TaprootInternalKey = nil
+		input.Bip32Derivation = nil
+		input.TaprootBip32Derivation = nil
+	}
+
+	return nil
+}
+
+func (input *PsbtInput) finalize(pkScript []byte) error {
+	if isTaprootScript(pkScript) {
+		if input.TaprootKeySpendSig == nil {
+			return ErrPsbtIncomplete
+		}
+
+		input.FinalScriptWitness = wire.TxWitness{input.TaprootKeySpendSig}
+
+		return nil
+	}
+
+	switch txscript.GetScriptClass(pkScript) {
+	case txscript.PubKeyHashTy:
+		sig, pubKey := input.pubKeyHashSig(pkScript[3:23])
+
+		if sig == nil {
+			return ErrPsbtIncomplete
+		}
+
+		sigScript, err := txscript.NewScriptBuilder().AddData(sig).AddData(pubKey).Script()
+
+		if err != nil {
+			return err
+		}
+
+		input.FinalScriptSig = sigScript
+	case txscript.WitnessV0PubKeyHashTy:
+		sig, pubKey := input.pubKeyHashSig(pkScript[2:22])
+
+		if sig == nil {
+			return ErrPsbtIncomplete
+		}
+
+		input.FinalScriptWitness = wire.TxWitness{sig, pubKey}
+	case txscript.ScriptHashTy:
+		if input.RedeemScript == nil {
+			return fmt.Errorf("p2sh input requires redeem script")
+		}
+
+		redeemScript, err := txscript.NewScriptBuilder().AddData(input.RedeemScript).Script()
+
+		if err != nil {
+			return err
+		}
+
+		switch txscript.GetScriptClass(input.RedeemScript) {
+		case txscript.WitnessV0PubKeyHashTy, txscript.WitnessV0ScriptHashTy:
+			if err := input.finalize(input.RedeemScript); err != nil {
+				return err
+			}
+
+			input.FinalScriptSig = redeemScript
+		default:
+			stack, err := input.multisigStack(input.RedeemScript)
+
+			if err != nil {
+				return err
+			}
+
+			builder := txscript.NewScriptBuilder().AddOp(txscript.OP_0)
+
+			for _, data := range stack[1:] {
+				builder.AddData(data)
+			}
+
+			sigScript, err := builder.Script()
+
+			if err != nil {
+				return err
+			}
+
+			input.FinalScriptSig = sigScript
+		}
+	case txscript.WitnessV0ScriptHashTy:
+		if input.WitnessScript == nil {
+			return fmt.Errorf("p2wsh input requires witness script")
+		}
+
+		stack, err := input.multisigStack(input.WitnessScript)
+
+		if err != nil {
+			return err
+		}
+
+		input.FinalScriptWitness = stack
+	default:
+		return fmt.Errorf("finalizing %s input is not supported", txscript.GetScriptClass(pkScript))
+	}
+
+	return nil
+}
+
+// pubKeyHashSig partial signature of public key with hash
+func (input *PsbtInput) pubKeyHashSig(pubKeyHash []byte) ([]byte, []byte) {
+	for _, sig := range input.PartialSigs {
+		if bytes.Equal(btcutil.Hash160(sig.PubKey), pubKeyHash) {
+			return sig.Signature, sig.PubKey
+		}
+	}
+
+	return nil, nil
+}
+
+// multisigStack CHECKMULTISIG stack: dummy item, threshold signatures in
+// public key order and the script
+func (input *PsbtInput) multisigStack(script []byte) ([][]byte, error) {
+	pubKeys, threshold, err := multisigPubKeys(script)
+
+	if err != nil {
+		return nil, err
+	}
+
+	stack := [][]byte{nil}
+
+	for _, pubKey := range pubKeys {
+		if sig := input.partialSig(pubKey); sig != nil && len(stack) <= threshold {
+			stack = append(stack, sig)
+		}
+	}
+
+	if len(stack) <= threshold {
+		return nil, ErrPsbtIncomplete
+	}
+
+	return append(stack, script), nil
+}
+
+// Extract network transaction of finalized psbt, every input is verified
+// against its utxo
+func (p *Psbt) Extract() (*wire.MsgTx, error) {
+	tx := p.UnsignedTx()
+
+	prevOuts := make([]*wire.TxOut, len(p.Inputs))
+
+	for i, input := range p.Inputs {
+		if !input.finalized() {
+			return nil, ErrPsbtNotFinalized
+		}
+
+		if prevOuts[i] = p.prevOut(i); prevOuts[i] == nil {
+			return nil, fmt.Errorf("verify psbt input %d: missing utxo", i)
+		}
+
+		tx.TxIn[i].SignatureScript = input.FinalScriptSig
+		tx.TxIn[i].Witness = input.FinalScriptWitness
+	}
+
+	if err := verifySignatures(tx, prevOuts); err != nil {
+		return nil, err
+	}
+
+	return tx, nil
+}
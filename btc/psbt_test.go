@@ -0,0 +1,515 @@
+package btc
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"reflect"
+	"testing"
+
+	"github.com/btcsuite/btcd/btcec"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/goany/hdkey"
+)
+
+// verifyPsbtTx verify every input of extracted psbt transaction
+func verifyPsbtTx(t *testing.T, p *Psbt) {
+	tx, err := p.Extract()
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	prevOuts := make([]*wire.TxOut, len(p.Inputs))
+
+	for i := range p.Inputs {
+		prevOuts[i] = p.prevOut(i)
+	}
+
+	sigHashes := txscript.NewTxSigHashes(tx)
+
+	for i, txin := range tx.TxIn {
+		if isTaprootScript(prevOuts[i].PkScript) {
+			hash, err := taprootSigHash(tx, i, prevOuts, SigHashDefault)
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if err := schnorrVerify(prevOuts[i].PkScript[2:], hash, txin.Witness[0]); err != nil {
+				t.Fatal(err)
+			}
+
+			continue
+		}
+
+		engine, err := txscript.NewEngine(prevOuts[i].PkScript, tx, i, txscript.StandardVerifyFlags, nil, sigHashes, prevOuts[i].Value)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if err := engine.Execute(); err != nil {
+			t.Fatalf("input %d: %s", i, err)
+		}
+	}
+}
+
+func TestPsbtSign(t *testing.T) {
+	for _, addressType := range []AddressType{AddressTypeP2PKH, AddressTypeP2WPKH, AddressTypeP2SHP2WPKH, AddressTypeP2TR} {
+		wallet := testWallet(t, WithAddressType(addressType))
+
+		utxos := []UTXO{
+			testUTXO(t, wallet, 30000, 0),
+			testUTXO(t, wallet, 40000, 1),
+		}
+
+		// previous transaction of the second utxo
+		prevTx := wire.NewMsgTx(wire.TxVersion)
+
+		prevTx.AddTxIn(wire.NewTxIn(&wire.OutPoint{}, nil, nil))
+		prevTx.AddTxOut(wire.NewTxOut(10000, nil))
+
+		witnessUtxo, err := utxos[1].WitnessUtxo()
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		prevTx.AddTxOut(witnessUtxo)
+
+		var buff bytes.Buffer
+
+		if err := prevTx.Serialize(&buff); err != nil {
+			t.Fatal(err)
+		}
+
+		utxos[1].PrevTx = hex.EncodeToString(buff.Bytes())
+		utxos[1].TxID = prevTx.TxHash().String()
+
+		p, err := wallet.CreatePsbt(utxos, testPayTo, 50000, 2)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		encoded, err := p.Base64()
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		p, err = ParsePsbtBase64(encoded)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if reencoded, _ := p.Base64(); reencoded != encoded {
+			t.Fatalf("%s: psbt round trip changed encoding", addressType)
+		}
+
+		if err := p.Finalize(); err == nil {
+			t.Fatalf("%s: expect unsigned psbt finalize error", addressType)
+		}
+
+		signed, err := wallet.SignPsbt(p)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if signed != 2 {
+			t.Fatalf("%s: expect 2 signed inputs got %d", addressType, signed)
+		}
+
+		if _, err := p.Extract(); err != ErrPsbtNotFinalized {
+			t.Fatalf("expect not finalized error got %v", err)
+		}
+
+		if err := p.Finalize(); err != nil {
+			t.Fatal(err)
+		}
+
+		verifyPsbtTx(t, p)
+	}
+}
+
+func TestPsbtMultisig(t *testing.T) {
+	var (
+		cosigners  []*Wallet
+		publicKeys []string
+	)
+
+	for i := byte(1); i <= 3; i++ {
+		priv, _ := btcec.PrivKeyFromBytes(btcec.S256(), bytes.Repeat([]byte{i}, 32))
+
+		wallet, err := newWallet(priv, priv.PubKey(), true, AddressTypeP2PKH, NetTypeMainNet)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		cosigners = append(cosigners, wallet)
+		publicKeys = append(publicKeys, hex.EncodeToString(priv.PubKey().SerializeCompressed()))
+	}
+
+	for _, addressType := range []AddressType{AddressTypeP2SH, AddressTypeP2WSH} {
+		wallet, err := NewMultisigWallet(publicKeys, 2, NetTypeMainNet, addressType)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		pkScript, err := payToAddrScript(wallet.Address)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		utxos := []UTXO{
+			{TxID: chainhash.DoubleHashH([]byte{0}).String(), VOut: 0, ScriptPubKey: hex.EncodeToString(pkScript), Satoshis: 30000},
+			{TxID: chainhash.DoubleHashH([]byte{1}).String(), VOut: 1, ScriptPubKey: hex.EncodeToString(pkScript), Satoshis: 40000},
+		}
+
+		p, err := wallet.CreatePsbt(utxos, testPayTo, 50000, 2)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		data, err := p.Bytes()
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// every cosigner signs its own copy
+		var psbts []*Psbt
+
+		for _, cosigner := range []*Wallet{cosigners[0], cosigners[2]} {
+			copied, err := ParsePsbt(data)
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if signed, err := cosigner.SignPsbt(copied); err != nil || signed != 2 {
+				t.Fatalf("expect 2 signed inputs got %d %v", signed, err)
+			}
+
+			if err := copied.Finalize(); err == nil {
+				t.Fatal("expect single signature finalize error")
+			}
+
+			psbts = append(psbts, copied)
+		}
+
+		other, err := NewPsbt(utxos[:1], []*wire.TxOut{wire.NewTxOut(1000, pkScript)}, PsbtVersion0)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err := CombinePsbt(psbts[0], other); err != ErrPsbtMismatch {
+			t.Fatalf("expect mismatch error got %v", err)
+		}
+
+		combined, err := CombinePsbt(psbts...)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if err := combined.Finalize(); err != nil {
+			t.Fatal(err)
+		}
+
+		verifyPsbtTx(t, combined)
+	}
+}
+
+func TestPsbtVersion2(t *testing.T) {
+	wallet := testWallet(t, WithAddressType(AddressTypeP2WPKH))
+
+	pkScript, _ := hex.DecodeString(testUTXO(t, wallet, 0, 0).ScriptPubKey)
+
+	utxos := []UTXO{testUTXO(t, wallet, 30000, 0)}
+	outputs := []*wire.TxOut{wire.NewTxOut(20000, pkScript)}
+
+	v0, err := NewPsbt(utxos, outputs, PsbtVersion0)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	v2, err := NewPsbt(utxos, outputs, PsbtVersion2)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	v2.Inputs[0].RequiredHeightLocktime = 800000
+
+	data, err := v2.Bytes()
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	parsed, err := ParsePsbt(data)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if parsed.Version != PsbtVersion2 || parsed.UnsignedTx().LockTime != 800000 {
+		t.Fatalf("unexpected version %d lock time %d", parsed.Version, parsed.UnsignedTx().LockTime)
+	}
+
+	parsed.Inputs[0].RequiredHeightLocktime = 0
+
+	if parsed.UnsignedTx().TxHash() != v0.UnsignedTx().TxHash() {
+		t.Fatal("expect version 0 and 2 of the same transaction")
+	}
+
+	if signed, err := wallet.SignPsbt(parsed); err != nil || signed != 1 {
+		t.Fatalf("expect 1 signed input got %d %v", signed, err)
+	}
+
+	if err := parsed.Finalize(); err != nil {
+		t.Fatal(err)
+	}
+
+	verifyPsbtTx(t, parsed)
+}
+
+func TestParsePsbtMalformed(t *testing.T) {
+	wallet := testWallet(t)
+
+	p, err := NewPsbt([]UTXO{testUTXO(t, wallet, 30000, 0)}, nil, PsbtVersion0)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := p.Bytes()
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// witness utxo entry of the only input: key, 8 bytes amount and p2pkh script
+	entry := data[len(data)-1-37 : len(data)-1]
+
+	if entry[0] != 1 || entry[1] != psbtInWitnessUtxo {
+		t.Fatalf("unexpected input entry %x", entry)
+	}
+
+	for name, malformed := range map[string][]byte{
+		"magic":     append([]byte("psbu"), data[4:]...),
+		"truncated": data[:len(data)-1],
+		"trailing":  append(append([]byte{}, data...), 0x00),
+		"duplicate": append(append(append([]byte{}, data[:len(data)-1]...), entry...), 0x00),
+		"v2 field":  append(append([]byte{}, psbtMagic...), append([]byte{0x01, psbtGlobalInputCount, 0x01, 0x01}, data[5:]...)...),
+	} {
+		if _, err := ParsePsbt(malformed); err == nil {
+			t.Fatalf("%s: expect malformed psbt error", name)
+		}
+	}
+}
+
+// TestPsbtBIP174Vectors parse the BIP174 and BIP371 test vectors, valid
+// psbts of other signers must re-encode unchanged
+func TestPsbtBIP174Vectors(t *testing.T) {
+	data, err := ioutil.ReadFile("testdata/psbt_bip174.json")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var vectors struct {
+		Valid   []string `json:"valid"`
+		Invalid []struct {
+			Comment string `json:"comment"`
+			Psbt    string `json:"psbt"`
+		} `json:"invalid"`
+	}
+
+	if err := json.Unmarshal(data, &vectors); err != nil {
+		t.Fatal(err)
+	}
+
+	for i, encoded := range vectors.Valid {
+		p, err := ParsePsbtBase64(encoded)
+
+		if err != nil {
+			t.Fatalf("valid %d: %s", i, err)
+		}
+
+		if reencoded, _ := p.Base64(); reencoded != encoded {
+			t.Fatalf("valid %d: round trip changed encoding", i)
+		}
+	}
+
+	for _, vector := range vectors.Invalid {
+		if _, err := ParsePsbtBase64(vector.Psbt); err == nil {
+			t.Fatalf("%s: expect parse error", vector.Comment)
+		}
+	}
+}
+
+func TestPsbtSigHashType(t *testing.T) {
+	for _, addressType := range []AddressType{AddressTypeP2WPKH, AddressTypeP2TR} {
+		wallet := testWallet(t, WithAddressType(addressType))
+
+		p, err := wallet.CreatePsbt([]UTXO{testUTXO(t, wallet, 30000, 0)}, testPayTo, 20000, 2)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		p.Inputs[0].SighashType = txscript.SigHashNone
+		p.Inputs[0].HasSighashType = true
+
+		if _, err := wallet.SignPsbt(p); !errors.Is(err, ErrPsbtSigHashType) {
+			t.Fatalf("%s: expect sighash type error got %v", addressType, err)
+		}
+
+		if _, err := wallet.SignPsbt(p, WithSigHashType(txscript.SigHashSingle)); !errors.Is(err, ErrPsbtSigHashType) {
+			t.Fatalf("%s: expect sighash type error got %v", addressType, err)
+		}
+
+		if signed, err := wallet.SignPsbt(p, WithSigHashType(txscript.SigHashNone)); err != nil || signed != 1 {
+			t.Fatalf("%s: expect 1 signed input got %d %v", addressType, signed, err)
+		}
+
+		if err := p.Finalize(); err != nil {
+			t.Fatal(err)
+		}
+
+		tx, err := p.Extract()
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if sig := tx.TxIn[0].Witness[0]; txscript.SigHashType(sig[len(sig)-1]) != txscript.SigHashNone {
+			t.Fatalf("%s: expect sighash none signature", addressType)
+		}
+	}
+}
+
+func TestPsbtBip32Derivation(t *testing.T) {
+	// bip32 test vector 1 seed, master key fingerprint 3442193e
+	seed, _ := hex.DecodeString("000102030405060708090a0b0c0d0e0f")
+
+	path := []uint32{hdkey.HardenedKeyStart + 84, hdkey.HardenedKeyStart, hdkey.HardenedKeyStart, 0, 1}
+
+	for _, addressType := range []AddressType{AddressTypeP2WPKH, AddressTypeP2SHP2WPKH, AddressTypeP2TR} {
+		wallet, err := WalletFromSeed(seed, "m/84'/0'/0'/0/1", NetTypeMainNet, WithAddressType(addressType))
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		p, err := wallet.CreatePsbt([]UTXO{testUTXO(t, wallet, 30000, 0)}, testPayTo, 10000, 2)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		data, err := p.Bytes()
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		p, err = ParsePsbt(data)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if reencoded, _ := p.Bytes(); !bytes.Equal(reencoded, data) {
+			t.Fatalf("%s: psbt round trip changed encoding", addressType)
+		}
+
+		pubKey := wallet.publicKey.SerializeCompressed()
+
+		// payment output first, change output second
+		for i, derivations := range [][]PsbtBip32Derivation{p.Inputs[0].Bip32Derivation, p.Outputs[1].Bip32Derivation} {
+			taproot := [][]PsbtTaprootBip32Derivation{p.Inputs[0].TaprootBip32Derivation, p.Outputs[1].TaprootBip32Derivation}[i]
+
+			var (
+				key         []byte
+				fingerprint uint32
+				keyPath     []uint32
+			)
+
+			if addressType == AddressTypeP2TR {
+				if len(derivations) != 0 || len(taproot) != 1 || len(taproot[0].LeafHashes) != 0 {
+					t.Fatalf("%s: expect one taproot derivation got %v %v", addressType, derivations, taproot)
+				}
+
+				key, fingerprint, keyPath = taproot[0].XOnlyPubKey, taproot[0].Fingerprint, taproot[0].Path
+				pubKey = pubKey[len(pubKey)-32:]
+			} else {
+				if len(derivations) != 1 || len(taproot) != 0 {
+					t.Fatalf("%s: expect one derivation got %v %v", addressType, derivations, taproot)
+				}
+
+				key, fingerprint, keyPath = derivations[0].PubKey, derivations[0].Fingerprint, derivations[0].Path
+			}
+
+			if !bytes.Equal(key, pubKey) || fingerprint != 0x3442193e || !reflect.DeepEqual(keyPath, path) {
+				t.Fatalf("%s: unexpected derivation %x %08x %v", addressType, key, fingerprint, keyPath)
+			}
+		}
+
+		if p.Outputs[0].Bip32Derivation != nil || p.Outputs[0].TaprootBip32Derivation != nil {
+			t.Fatalf("%s: expect no derivation of payment output", addressType)
+		}
+
+		if _, err := wallet.SignPsbt(p); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := p.Finalize(); err != nil {
+			t.Fatal(err)
+		}
+
+		if p.Inputs[0].Bip32Derivation != nil || p.Inputs[0].TaprootBip32Derivation != nil {
+			t.Fatalf("%s: expect finalizer to clear derivations", addressType)
+		}
+
+		verifyPsbtTx(t, p)
+	}
+
+	p, err := testWallet(t).CreatePsbt([]UTXO{testUTXO(t, testWallet(t), 30000, 0)}, testPayTo, 10000, 2)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if p.Inputs[0].Bip32Derivation != nil {
+		t.Fatal("expect no derivation of wif wallet")
+	}
+
+	// fingerprint without a full path index
+	p.Inputs[0].unknowns = []psbtKV{{key: append([]byte{psbtInBip32Derivation}, testWallet(t).publicKey.SerializeCompressed()...), value: []byte{1, 2, 3, 4, 5}}}
+
+	data, err := p.Bytes()
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := ParsePsbt(data); err != ErrPsbtMalformed {
+		t.Fatalf("expect malformed derivation error got %v", err)
+	}
+}
@@ -0,0 +1,211 @@
+package btc
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/btcsuite/btcd/wire"
+	"github.com/btcsuite/btcutil"
+)
+
+// SequenceRBF input sequence signalling BIP125 replaceability
+const SequenceRBF = wire.MaxTxInSequenceNum - 2
+
+// IncrementalRelayFeeRate fee rate in satoshis per vbyte a replacement pays
+// on top of the replaced transaction fee, BIP125 rule 4
+const IncrementalRelayFeeRate btcutil.Amount = 1
+
+// Replace by fee errors
+var (
+	ErrNotReplaceable = errors.New("transaction does not signal replace by fee")
+	ErrBumpFeeInput   = errors.New("missing utxo of replaced transaction input")
+	ErrBumpFeeFunds   = errors.New("change can not pay the replacement fee")
+)
+
+// WithRBF signal BIP125 replace by fee on every input
+func WithRBF() PayOption {
+	return func(trans *transaction) {
+		trans.sequence = SequenceRBF
+	}
+}
+
+// SignalsRBF check if any input of tx signals BIP125 replaceability
+func SignalsRBF(tx *wire.MsgTx) bool {
+	for _, txin := range tx.TxIn {
+		if txin.Sequence < wire.MaxTxInSequenceNum-1 {
+			return true
+		}
+	}
+
+	return false
+}
+
+// BumpFee sign BIP125 replacement of original paying feeRate, utxos are the
+// outputs spent by original. The replacement spends the same inputs and
+// keeps every output except the wallet change, which pays the fee increase
+// and is dropped once it falls below the dust limit. The fee is raised by
+// at least the incremental relay fee even if feeRate is lower
+func (wallet *Wallet) BumpFee(
+	original *wire.MsgTx,
+	utxos []UTXO,
+	feeRate btcutil.Amount,
+	writer io.Writer,
+	options ...PayOption) error {
+
+	if wallet.privateKey == nil {
+		return ErrWalletClosed
+	}
+
+	tx, err := txFrom(utxos).
+		change(wallet.Address).
+		feeRate(feeRate).
+		options(options).
+		sign(wallet.privateKey, wallet.compressed).
+		replace(original)
+
+	if err != nil {
+		return err
+	}
+
+	return tx.Serialize(writer)
+}
+
+// replace build replacement of original, inputs are signed if a private key
+// is set
+func (trans *transaction) replace(original *wire.MsgTx) (*wire.MsgTx, error) {
+	if !SignalsRBF(original) {
+		return nil, ErrNotReplaceable
+	}
+
+	tx := wire.NewMsgTx(original.Version)
+
+	tx.LockTime = original.LockTime
+
+	var total, out btcutil.Amount
+
+	for _, txin := range original.TxIn {
+		utxo, ok := findUTXO(trans.inputs, txin.PreviousOutPoint)
+
+		if !ok {
+			return nil, fmt.Errorf("%w: %s", ErrBumpFeeInput, txin.PreviousOutPoint)
+		}
+
+		outPoint := txin.PreviousOutPoint
+
+		replaced := wire.NewTxIn(&outPoint, nil, nil)
+
+		replaced.Sequence = txin.Sequence
+
+		tx.AddTxIn(replaced)
+
+		trans.txIn[replaced] = utxo
+
+		total += btcutil.Amount(utxo.Satoshis)
+	}
+
+	changeScript, err := payToAddrScript(trans.paychange)
+
+	if err != nil {
+		return nil, err
+	}
+
+	change := -1
+
+	for i, txout := range original.TxOut {
+		tx.AddTxOut(wire.NewTxOut(txout.Value, txout.PkScript))
+
+		out += btcutil.Amount(txout.Value)
+
+		if bytes.Equal(txout.PkScript, changeScript) {
+			change = i
+		}
+	}
+
+	if total < out {
+		return nil, fmt.Errorf("utxos %d do not cover outputs %d of replaced transaction", total, out)
+	}
+
+	if change < 0 {
+		return nil, ErrBumpFeeFunds
+	}
+
+	oldFee := total - out
+
+	weight, err := trans.estimateWeight(tx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	changeValue := btcutil.Amount(tx.TxOut[change].Value)
+
+	newFee := replacementFee(weight, trans.payFeeRate, oldFee)
+
+	if changeValue-(newFee-oldFee) >= dustLimit(changeScript) {
+		tx.TxOut[change].Value -= int64(newFee - oldFee)
+	} else {
+		weight -= tx.TxOut[change].SerializeSize() * 4
+
+		tx.TxOut = append(tx.TxOut[:change], tx.TxOut[change+1:]...)
+
+		if oldFee+changeValue < replacementFee(weight, trans.payFeeRate, oldFee) {
+			return nil, ErrBumpFeeFunds
+		}
+
+		newFee = oldFee + changeValue
+	}
+
+	trans.Debug("replace fee: ", oldFee, " new fee: ", newFee)
+
+	if trans.privatekey == nil {
+		return tx, nil
+	}
+
+	if err := trans.signInputs(tx); err != nil {
+		return nil, err
+	}
+
+	return tx, nil
+}
+
+// replacementFee fee of replacement with weight, at least feeRate and the
+// replaced fee plus the incremental relay fee
+func replacementFee(weight int, feeRate btcutil.Amount, oldFee btcutil.Amount) btcutil.Amount {
+	required := oldFee + fee(weight, IncrementalRelayFeeRate)
+
+	if byRate := fee(weight, feeRate); byRate > required {
+		return byRate
+	}
+
+	return required
+}
+
+// estimateWeight signed weight of tx, inputs are sized by their utxos
+func (trans *transaction) estimateWeight(tx *wire.MsgTx) (int, error) {
+	outputs := tx.Copy()
+
+	outputs.TxIn = nil
+
+	weight := (outputs.SerializeSizeStripped() - 1 + wire.VarIntSerializeSize(uint64(len(tx.TxIn)))) * 4
+
+	witness := false
+
+	for _, txin := range tx.TxIn {
+		inputWeight, isWitness, err := trans.inputWeight(trans.txIn[txin])
+
+		if err != nil {
+			return 0, err
+		}
+
+		weight += inputWeight
+		witness = witness || isWitness
+	}
+
+	if witness {
+		weight += witnessHeaderSize
+	}
+
+	return weight, nil
+}
@@ -0,0 +1,176 @@
+package btc
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/btcsuite/btcd/wire"
+	"github.com/btcsuite/btcutil"
+)
+
+// testReplaceable pay amount from utxos signalling replace by fee
+func testReplaceable(t *testing.T, wallet *Wallet, utxos []UTXO, amount btcutil.Amount) *wire.MsgTx {
+	var buff bytes.Buffer
+
+	if err := wallet.Pay(utxos, testPayTo, amount, 2, &buff, WithRBF()); err != nil {
+		t.Fatal(err)
+	}
+
+	var tx wire.MsgTx
+
+	if err := tx.Deserialize(bytes.NewReader(buff.Bytes())); err != nil {
+		t.Fatal(err)
+	}
+
+	return &tx
+}
+
+// testFee fee paid by tx spending utxos
+func testFee(tx *wire.MsgTx, utxos []UTXO) int64 {
+	var fee int64
+
+	for _, utxo := range utxos {
+		fee += int64(utxo.Satoshis)
+	}
+
+	for _, txout := range tx.TxOut {
+		fee -= txout.Value
+	}
+
+	return fee
+}
+
+func TestWithRBF(t *testing.T) {
+	wallet := testWallet(t, WithAddressType(AddressTypeP2WPKH))
+
+	utxos := []UTXO{testUTXO(t, wallet, 30000, 0), testUTXO(t, wallet, 40000, 1)}
+
+	tx := testReplaceable(t, wallet, utxos, 50000)
+
+	for _, txin := range tx.TxIn {
+		if txin.Sequence != SequenceRBF {
+			t.Fatalf("expect rbf sequence got %x", txin.Sequence)
+		}
+	}
+
+	if !SignalsRBF(tx) {
+		t.Fatal("expect rbf signalled")
+	}
+
+	var buff bytes.Buffer
+
+	if err := wallet.Pay(utxos, testPayTo, 50000, 2, &buff); err != nil {
+		t.Fatal(err)
+	}
+
+	var final wire.MsgTx
+
+	if err := final.Deserialize(bytes.NewReader(buff.Bytes())); err != nil {
+		t.Fatal(err)
+	}
+
+	if SignalsRBF(&final) {
+		t.Fatal("expect final sequence without rbf")
+	}
+
+	if err := wallet.BumpFee(&final, utxos, 10, &buff); err != ErrNotReplaceable {
+		t.Fatalf("expect not replaceable error got %v", err)
+	}
+}
+
+func TestBumpFee(t *testing.T) {
+	for _, addressType := range []AddressType{AddressTypeP2PKH, AddressTypeP2WPKH, AddressTypeP2TR} {
+		wallet := testWallet(t, WithAddressType(addressType))
+
+		utxos := []UTXO{testUTXO(t, wallet, 30000, 0), testUTXO(t, wallet, 40000, 1)}
+
+		original := testReplaceable(t, wallet, utxos, 50000)
+
+		var buff bytes.Buffer
+
+		if err := wallet.BumpFee(original, utxos, 10, &buff); err != nil {
+			t.Fatal(err)
+		}
+
+		var tx wire.MsgTx
+
+		if err := tx.Deserialize(bytes.NewReader(buff.Bytes())); err != nil {
+			t.Fatal(err)
+		}
+
+		if len(tx.TxIn) != len(original.TxIn) || len(tx.TxOut) != len(original.TxOut) {
+			t.Fatalf("%s: expect same inputs and outputs", addressType)
+		}
+
+		for i, txin := range tx.TxIn {
+			if txin.PreviousOutPoint != original.TxIn[i].PreviousOutPoint || txin.Sequence != SequenceRBF {
+				t.Fatalf("%s: input %d changed", addressType, i)
+			}
+		}
+
+		if tx.TxOut[0].Value != 50000 || tx.TxOut[1].Value >= original.TxOut[1].Value {
+			t.Fatalf("%s: expect reduced change got %d", addressType, tx.TxOut[1].Value)
+		}
+
+		vsize := int64((tx.SerializeSizeStripped()*3 + tx.SerializeSize() + 3) / 4)
+
+		oldFee, newFee := testFee(original, utxos), testFee(&tx, utxos)
+
+		if newFee < vsize*10 || newFee < oldFee+vsize {
+			t.Fatalf("%s: fee %d does not replace fee %d at vsize %d", addressType, newFee, oldFee, vsize)
+		}
+
+		prevOuts := make([]*wire.TxOut, len(tx.TxIn))
+
+		for i, txin := range tx.TxIn {
+			prevOuts[i], _ = utxos[txin.PreviousOutPoint.Index].WitnessUtxo()
+		}
+
+		if err := verifySignatures(&tx, prevOuts); err != nil {
+			t.Fatal(err)
+		}
+
+		// a lower fee rate still pays the incremental relay fee
+		if err := wallet.BumpFee(&tx, utxos, 1, &buff); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestBumpFeeChange(t *testing.T) {
+	wallet := testWallet(t, WithAddressType(AddressTypeP2WPKH))
+
+	utxos := []UTXO{testUTXO(t, wallet, 30000, 0)}
+
+	// change of about 1000 satoshis
+	original := testReplaceable(t, wallet, utxos, 28700)
+
+	if len(original.TxOut) != 2 {
+		t.Fatalf("expect change output got %d outputs", len(original.TxOut))
+	}
+
+	var buff bytes.Buffer
+
+	if err := wallet.BumpFee(original, utxos, 8, &buff); err != nil {
+		t.Fatal(err)
+	}
+
+	var tx wire.MsgTx
+
+	if err := tx.Deserialize(bytes.NewReader(buff.Bytes())); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(tx.TxOut) != 1 || tx.TxOut[0].Value != 28700 {
+		t.Fatalf("expect change dropped to fees got %d outputs", len(tx.TxOut))
+	}
+
+	if err := wallet.BumpFee(original, utxos, 50, &buff); err != ErrBumpFeeFunds {
+		t.Fatalf("expect bump fee funds error got %v", err)
+	}
+
+	if err := wallet.BumpFee(original, nil, 8, &buff); !errors.Is(err, ErrBumpFeeInput) {
+		t.Fatalf("expect missing utxo error got %v", err)
+	}
+}
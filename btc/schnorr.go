@@ -0,0 +1,161 @@
+package btc
+
+import (
+	"crypto/sha256"
+	"errors"
+	"math/big"
+
+	"github.com/btcsuite/btcd/btcec"
+	"github.com/goany/secure"
+)
+
+// BIP340 schnorr errors
+var (
+	ErrSchnorrPubKey    = errors.New("invalid bip340 x-only public key")
+	ErrSchnorrSignature = errors.New("invalid bip340 schnorr signature")
+)
+
+// taggedHash BIP340 tagged hash sha256(sha256(tag) || sha256(tag) || msg...)
+func taggedHash(tag string, msg ...[]byte) []byte {
+	tagHash := sha256.Sum256([]byte(tag))
+
+	hash := sha256.New()
+
+	hash.Write(tagHash[:])
+	hash.Write(tagHash[:])
+
+	for _, m := range msg {
+		hash.Write(m)
+	}
+
+	return hash.Sum(nil)
+}
+
+// bytes32 big endian 32 bytes encoding of field element or scalar
+func bytes32(n *big.Int) []byte {
+	buff := make([]byte, 32)
+
+	return n.FillBytes(buff)
+}
+
+// liftX point with x coordinate and even y, the BIP340 lift_x
+func liftX(x []byte) (*btcec.PublicKey, error) {
+	if len(x) != 32 || new(big.Int).SetBytes(x).Cmp(btcec.S256().P) >= 0 {
+		return nil, ErrSchnorrPubKey
+	}
+
+	pub, err := btcec.ParsePubKey(append([]byte{0x02}, x...), btcec.S256())
+
+	if err != nil {
+		return nil, ErrSchnorrPubKey
+	}
+
+	return pub, nil
+}
+
+// schnorrSign BIP340 signature of 32 bytes hash, aux is 32 bytes of fresh
+// randomness mixed into the nonce
+func schnorrSign(priv *btcec.PrivateKey, hash []byte, aux []byte) ([]byte, error) {
+	curve := btcec.S256()
+
+	if len(hash) != 32 || len(aux) != 32 {
+		return nil, errors.New("schnorr message and aux data must be 32 bytes")
+	}
+
+	d := new(big.Int).Set(priv.D)
+
+	defer secure.WipeBigInt(d)
+
+	if d.Sign() == 0 || d.Cmp(curve.N) >= 0 {
+		return nil, errors.New("invalid schnorr private key")
+	}
+
+	px, py := curve.ScalarBaseMult(bytes32(d))
+
+	if py.Bit(0) == 1 {
+		d.Sub(curve.N, d)
+	}
+
+	pubX := bytes32(px)
+
+	t := bytes32(d)
+
+	defer secure.Wipe(t)
+
+	auxHash := taggedHash("BIP0340/aux", aux)
+
+	for i := range t {
+		t[i] ^= auxHash[i]
+	}
+
+	k := new(big.Int).SetBytes(taggedHash("BIP0340/nonce", t, pubX, hash))
+
+	defer secure.WipeBigInt(k)
+
+	k.Mod(k, curve.N)
+
+	if k.Sign() == 0 {
+		return nil, errors.New("schnorr nonce is zero")
+	}
+
+	rx, ry := curve.ScalarBaseMult(bytes32(k))
+
+	if ry.Bit(0) == 1 {
+		k.Sub(curve.N, k)
+	}
+
+	r := bytes32(rx)
+
+	e := new(big.Int).SetBytes(taggedHash("BIP0340/challenge", r, pubX, hash))
+
+	e.Mod(e, curve.N)
+
+	s := e.Mul(e, d)
+
+	s.Add(s, k).Mod(s, curve.N)
+
+	sig := append(r, bytes32(s)...)
+
+	if err := schnorrVerify(pubX, hash, sig); err != nil {
+		return nil, err
+	}
+
+	return sig, nil
+}
+
+// schnorrVerify verify BIP340 signature of 32 bytes hash by x-only public key
+func schnorrVerify(pubX []byte, hash []byte, sig []byte) error {
+	curve := btcec.S256()
+
+	if len(sig) != 64 || len(hash) != 32 {
+		return ErrSchnorrSignature
+	}
+
+	pub, err := liftX(pubX)
+
+	if err != nil {
+		return err
+	}
+
+	r := new(big.Int).SetBytes(sig[:32])
+	s := new(big.Int).SetBytes(sig[32:])
+
+	if r.Cmp(curve.P) >= 0 || s.Cmp(curve.N) >= 0 {
+		return ErrSchnorrSignature
+	}
+
+	e := new(big.Int).SetBytes(taggedHash("BIP0340/challenge", sig[:32], pubX, hash))
+
+	e.Mod(e, curve.N)
+
+	// R = s*G - e*P
+	sx, sy := curve.ScalarBaseMult(bytes32(s))
+	ex, ey := curve.ScalarMult(pub.X, pub.Y, bytes32(e.Sub(curve.N, e)))
+	rx, ry := curve.Add(sx, sy, ex, ey)
+
+	if (rx.Sign() == 0 && ry.Sign() == 0) || ry.Bit(0) == 1 || rx.Cmp(r) != 0 {
+		return ErrSchnorrSignature
+	}
+
+	return nil
+}
@@ -0,0 +1,437 @@
+package btc
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/btcsuite/btcd/btcec"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/btcsuite/btcutil"
+	"github.com/btcsuite/btcutil/bech32"
+	"github.com/goany/secure"
+)
+
+// BIP341 signature hash types, SigHashDefault commits to the same data as
+// SigHashAll and produces 64 bytes signatures
+const (
+	SigHashDefault txscript.SigHashType = 0x00
+)
+
+// p2trWitnessSize is the key path witness spending a p2tr output:
+// item count, OP_DATA_64 <schnorr sig>
+const p2trWitnessSize = 1 + 1 + 64
+
+// bech32mConst BIP350 checksum constant of witness version 1+ addresses
+const bech32mConst = 0x2bc830a3
+
+const bech32Charset = "qpzry9x8gf2tvdw0s3jn54khce6mua7l"
+
+// ErrTaprootAddress invalid bech32m taproot address
+var ErrTaprootAddress = errors.New("invalid taproot address")
+
+// AddressTaproot BIP86 pay to taproot address, witness version 1 with the
+// 32 bytes x-only output key as witness program
+type AddressTaproot struct {
+	hrp       string
+	outputKey [32]byte
+}
+
+// NewAddressTaproot create taproot address of x-only output key
+func NewAddressTaproot(outputKey []byte, net *chaincfg.Params) (*AddressTaproot, error) {
+	if len(outputKey) != 32 {
+		return nil, ErrTaprootAddress
+	}
+
+	address := &AddressTaproot{
+		hrp: net.Bech32HRPSegwit,
+	}
+
+	copy(address.outputKey[:], outputKey)
+
+	return address, nil
+}
+
+// EncodeAddress bech32m encoding of the address
+func (address *AddressTaproot) EncodeAddress() string {
+	data, err := bech32.ConvertBits(address.outputKey[:], 8, 5, true)
+
+	if err != nil {
+		return ""
+	}
+
+	return bech32mEncode(address.hrp, append([]byte{1}, data...))
+}
+
+// ScriptAddress x-only output key
+func (address *AddressTaproot) ScriptAddress() []byte {
+	return address.outputKey[:]
+}
+
+// IsForNet check address network
+func (address *AddressTaproot) IsForNet(net *chaincfg.Params) bool {
+	return address.hrp == net.Bech32HRPSegwit
+}
+
+// String bech32m encoding of the address
+func (address *AddressTaproot) String() string {
+	return address.EncodeAddress()
+}
+
+// decodeAddress decode address string including taproot addresses
+func decodeAddress(addr string, net *chaincfg.Params) (btcutil.Address, error) {
+	if address, err := decodeTaprootAddress(addr, net); err == nil {
+		return address, nil
+	}
+
+	return btcutil.DecodeAddress(addr, net)
+}
+
+func decodeTaprootAddress(addr string, net *chaincfg.Params) (*AddressTaproot, error) {
+	hrp, data, err := bech32mDecode(addr)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if hrp != net.Bech32HRPSegwit || len(data) == 0 || data[0] != 1 {
+		return nil, ErrTaprootAddress
+	}
+
+	program, err := bech32.ConvertBits(data[1:], 5, 8, false)
+
+	if err != nil {
+		return nil, ErrTaprootAddress
+	}
+
+	return NewAddressTaproot(program, net)
+}
+
+// payToAddrScript output script paying to address including taproot addresses
+func payToAddrScript(addr btcutil.Address) ([]byte, error) {
+	if address, ok := addr.(*AddressTaproot); ok {
+		return append([]byte{txscript.OP_1, txscript.OP_DATA_32}, address.outputKey[:]...), nil
+	}
+
+	return txscript.PayToAddrScript(addr)
+}
+
+// isTaprootScript check pkScript is a witness version 1 p2tr output
+func isTaprootScript(pkScript []byte) bool {
+	return len(pkScript) == 34 && pkScript[0] == txscript.OP_1 && pkScript[1] == txscript.OP_DATA_32
+}
+
+func bech32Polymod(values []byte) uint32 {
+	generator := [5]uint32{0x3b6a57b2, 0x26508e6d, 0x1ea119fa, 0x3d4233dd, 0x2a1462b3}
+
+	chk := uint32(1)
+
+	for _, v := range values {
+		top := chk >> 25
+
+		chk = (chk&0x1ffffff)<<5 ^ uint32(v)
+
+		for i := 0; i < 5; i++ {
+			if (top>>uint(i))&1 == 1 {
+				chk ^= generator[i]
+			}
+		}
+	}
+
+	return chk
+}
+
+func bech32HRPExpand(hrp string) []byte {
+	values := make([]byte, 0, len(hrp)*2+1)
+
+	for i := 0; i < len(hrp); i++ {
+		values = append(values, hrp[i]>>5)
+	}
+
+	values = append(values, 0)
+
+	for i := 0; i < len(hrp); i++ {
+		values = append(values, hrp[i]&31)
+	}
+
+	return values
+}
+
+// bech32mEncode BIP350 encoding of 5 bits data
+func bech32mEncode(hrp string, data []byte) string {
+	values := append(bech32HRPExpand(hrp), data...)
+
+	polymod := bech32Polymod(append(values, 0, 0, 0, 0, 0, 0)) ^ bech32mConst
+
+	var buff strings.Builder
+
+	buff.WriteString(hrp)
+	buff.WriteByte('1')
+
+	for _, v := range data {
+		buff.WriteByte(bech32Charset[v])
+	}
+
+	for i := 0; i < 6; i++ {
+		buff.WriteByte(bech32Charset[(polymod>>uint(5*(5-i)))&31])
+	}
+
+	return buff.String()
+}
+
+// bech32mDecode BIP350 decoding, returns hrp and 5 bits data without checksum
+func bech32mDecode(addr string) (string, []byte, error) {
+	if len(addr) > 90 || (strings.ToLower(addr) != addr && strings.ToUpper(addr) != addr) {
+		return "", nil, ErrTaprootAddress
+	}
+
+	addr = strings.ToLower(addr)
+
+	pos := strings.LastIndexByte(addr, '1')
+
+	if pos < 1 || pos+7 > len(addr) {
+		return "", nil, ErrTaprootAddress
+	}
+
+	hrp := addr[:pos]
+
+	data := make([]byte, 0, len(addr)-pos-1)
+
+	for _, c := range addr[pos+1:] {
+		v := strings.IndexRune(bech32Charset, c)
+
+		if v < 0 {
+			return "", nil, ErrTaprootAddress
+		}
+
+		data = append(data, byte(v))
+	}
+
+	if bech32Polymod(append(bech32HRPExpand(hrp), data...)) != bech32mConst {
+		return "", nil, ErrTaprootAddress
+	}
+
+	return hrp, data[:len(data)-6], nil
+}
+
+// taprootTweak BIP86 tweak of internal key without script tree
+func taprootTweak(internalKey []byte) *big.Int {
+	return new(big.Int).SetBytes(taggedHash("TapTweak", internalKey))
+}
+
+// taprootOutputKey x-only output key Q = P + tG of internal public key
+func taprootOutputKey(pub *btcec.PublicKey) ([]byte, error) {
+	curve := btcec.S256()
+
+	internalKey := bytes32(pub.X)
+
+	p, err := liftX(internalKey)
+
+	if err != nil {
+		return nil, err
+	}
+
+	tweak := taprootTweak(internalKey)
+
+	if tweak.Cmp(curve.N) >= 0 {
+		return nil, errors.New("taproot tweak out of range")
+	}
+
+	tx, ty := curve.ScalarBaseMult(bytes32(tweak))
+	qx, qy := curve.Add(p.X, p.Y, tx, ty)
+
+	if qx.Sign() == 0 && qy.Sign() == 0 {
+		return nil, errors.New("taproot output key is infinity")
+	}
+
+	return bytes32(qx), nil
+}
+
+// taprootPrivateKey tweak private key for key path spending of the BIP86
+// output key, the caller should wipe the returned key
+func taprootPrivateKey(priv *btcec.PrivateKey) (*btcec.PrivateKey, error) {
+	curve := btcec.S256()
+
+	d := new(big.Int).Set(priv.D)
+
+	if priv.PublicKey.Y.Bit(0) == 1 {
+		d.Sub(curve.N, d)
+	}
+
+	tweak := taprootTweak(bytes32(priv.PublicKey.X))
+
+	if tweak.Cmp(curve.N) >= 0 {
+		secure.WipeBigInt(d)
+		return nil, errors.New("taproot tweak out of range")
+	}
+
+	d.Add(d, tweak).Mod(d, curve.N)
+
+	if d.Sign() == 0 {
+		return nil, errors.New("taproot tweaked private key is zero")
+	}
+
+	buff := bytes32(d)
+
+	defer secure.Wipe(buff)
+
+	secure.WipeBigInt(d)
+
+	tweaked, _ := btcec.PrivKeyFromBytes(curve, buff)
+
+	return tweaked, nil
+}
+
+// taprootSigHash BIP341 key path signature hash of input idx, prevOuts
+// are the outputs spent by every input of tx
+func taprootSigHash(tx *wire.MsgTx, idx int, prevOuts []*wire.TxOut, hashType txscript.SigHashType) ([]byte, error) {
+	switch hashType {
+	case SigHashDefault, txscript.SigHashAll, txscript.SigHashNone, txscript.SigHashSingle,
+		txscript.SigHashAll | txscript.SigHashAnyOneCanPay,
+		txscript.SigHashNone | txscript.SigHashAnyOneCanPay,
+		txscript.SigHashSingle | txscript.SigHashAnyOneCanPay:
+	default:
+		return nil, fmt.Errorf("invalid taproot sighash type :%d", hashType)
+	}
+
+	if idx < 0 || idx >= len(tx.TxIn) || len(prevOuts) != len(tx.TxIn) {
+		return nil, errors.New("taproot sighash requires every spent output")
+	}
+
+	anyoneCanPay := hashType&txscript.SigHashAnyOneCanPay != 0
+	outputType := hashType & 0x03
+
+	var msg bytes.Buffer
+
+	// sighash epoch
+	msg.WriteByte(0)
+	msg.WriteByte(byte(hashType))
+
+	binary.Write(&msg, binary.LittleEndian, tx.Version)
+	binary.Write(&msg, binary.LittleEndian, tx.LockTime)
+
+	if !anyoneCanPay {
+		var prevouts, amounts, scriptPubKeys, sequences bytes.Buffer
+
+		for i, txin := range tx.TxIn {
+			writeOutPoint(&prevouts, &txin.PreviousOutPoint)
+			binary.Write(&amounts, binary.LittleEndian, prevOuts[i].Value)
+			wire.WriteVarBytes(&scriptPubKeys, 0, prevOuts[i].PkScript)
+			binary.Write(&sequences, binary.LittleEndian, txin.Sequence)
+		}
+
+		for _, buff := range []*bytes.Buffer{&prevouts, &amounts, &scriptPubKeys, &sequences} {
+			hash := sha256.Sum256(buff.Bytes())
+			msg.Write(hash[:])
+		}
+	}
+
+	if outputType != txscript.SigHashNone && outputType != txscript.SigHashSingle {
+		var outputs bytes.Buffer
+
+		for _, txout := range tx.TxOut {
+			wire.WriteTxOut(&outputs, 0, 0, txout)
+		}
+
+		hash := sha256.Sum256(outputs.Bytes())
+		msg.Write(hash[:])
+	}
+
+	// spend type, key path without annex
+	msg.WriteByte(0)
+
+	if anyoneCanPay {
+		writeOutPoint(&msg, &tx.TxIn[idx].PreviousOutPoint)
+		binary.Write(&msg, binary.LittleEndian, prevOuts[idx].Value)
+		wire.WriteVarBytes(&msg, 0, prevOuts[idx].PkScript)
+		binary.Write(&msg, binary.LittleEndian, tx.TxIn[idx].Sequence)
+	} else {
+		binary.Write(&msg, binary.LittleEndian, uint32(idx))
+	}
+
+	if outputType == txscript.SigHashSingle {
+		if idx >= len(tx.TxOut) {
+			return nil, errors.New("taproot sighash single without matching output")
+		}
+
+		var output bytes.Buffer
+
+		wire.WriteTxOut(&output, 0, 0, tx.TxOut[idx])
+
+		hash := sha256.Sum256(output.Bytes())
+		msg.Write(hash[:])
+	}
+
+	return taggedHash("TapSighash", msg.Bytes()), nil
+}
+
+func writeOutPoint(buff *bytes.Buffer, outPoint *wire.OutPoint) {
+	buff.Write(outPoint.Hash[:])
+	binary.Write(buff, binary.LittleEndian, outPoint.Index)
+}
+
+// taprootSignature key path spend signature, the sighash type is appended
+// unless it is SigHashDefault
+func taprootSignature(tx *wire.MsgTx, idx int, prevOuts []*wire.TxOut, hashType txscript.SigHashType, priv *btcec.PrivateKey, aux []byte) ([]byte, error) {
+	hash, err := taprootSigHash(tx, idx, prevOuts, hashType)
+
+	if err != nil {
+		return nil, err
+	}
+
+	tweaked, err := taprootPrivateKey(priv)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer secure.WipePrivateKey(tweaked.ToECDSA())
+
+	sig, err := schnorrSign(tweaked, hash, aux)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if hashType != SigHashDefault {
+		sig = append(sig, byte(hashType))
+	}
+
+	return sig, nil
+}
+
+// taprootVerify verify key path spend signature of input idx
+func taprootVerify(tx *wire.MsgTx, idx int, prevOuts []*wire.TxOut) error {
+	witness := tx.TxIn[idx].Witness
+
+	if len(witness) != 1 {
+		return errors.New("taproot key path spend expects one witness item")
+	}
+
+	sig := witness[0]
+	hashType := SigHashDefault
+
+	switch len(sig) {
+	case 64:
+	case 65:
+		if hashType = txscript.SigHashType(sig[64]); hashType == SigHashDefault {
+			return ErrSchnorrSignature
+		}
+	default:
+		return ErrSchnorrSignature
+	}
+
+	hash, err := taprootSigHash(tx, idx, prevOuts, hashType)
+
+	if err != nil {
+		return err
+	}
+
+	return schnorrVerify(prevOuts[idx].PkScript[2:], hash, sig[:64])
+}
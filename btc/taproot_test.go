@@ -0,0 +1,309 @@
+package btc
+
+import (
+	"bytes"
+	"encoding/hex"
+	"strings"
+	"testing"
+
+	"github.com/btcsuite/btcd/btcec"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/goany/bip39"
+)
+
+func TestSchnorr(t *testing.T) {
+	// BIP340 test vectors 0 and 1
+	vectors := []struct {
+		seckey, pubkey, aux, msg, sig string
+	}{
+		{
+			"0000000000000000000000000000000000000000000000000000000000000003",
+			"f9308a019258c31049344f85f89d5229b531c845836f99b08601f113bce036f9",
+			"0000000000000000000000000000000000000000000000000000000000000000",
+			"0000000000000000000000000000000000000000000000000000000000000000",
+			"e907831f80848d1069a5371b402410364bdf1c5f8307b0084c55f1ce2dca821525f66a4a85ea8b71e482a74f382d2ce5ebeee8fdb2172f477df4900d310536c0",
+		},
+		{
+			"b7e151628aed2a6abf7158809cf4f3c762e7160f38b4da56a784d9045190cfef",
+			"dff1d77f2a671c5f36183726db2341be58feae1da2deced843240f7b502ba659",
+			"0000000000000000000000000000000000000000000000000000000000000001",
+			"243f6a8885a308d313198a2e03707344a4093822299f31d0082efa98ec4e6c89",
+			"6896bd60eeae296db48a229ff71dfe071bde413e6d43f917dc8dcf8c78de33418906d11ac976abccb20b091292bff4ea897efcb639ea871cfa95f6de339e4b0a",
+		},
+	}
+
+	for _, vector := range vectors {
+		seckey, _ := hex.DecodeString(vector.seckey)
+		pubkey, _ := hex.DecodeString(vector.pubkey)
+		aux, _ := hex.DecodeString(vector.aux)
+		msg, _ := hex.DecodeString(vector.msg)
+
+		priv, _ := btcec.PrivKeyFromBytes(btcec.S256(), seckey)
+
+		if x := hex.EncodeToString(bytes32(priv.PublicKey.X)); x != vector.pubkey {
+			t.Fatalf("expect %s got %s", vector.pubkey, x)
+		}
+
+		sig, err := schnorrSign(priv, msg, aux)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if hex.EncodeToString(sig) != vector.sig {
+			t.Fatalf("expect %s got %x", vector.sig, sig)
+		}
+
+		if err := schnorrVerify(pubkey, msg, sig); err != nil {
+			t.Fatal(err)
+		}
+
+		sig[63] ^= 1
+
+		if err := schnorrVerify(pubkey, msg, sig); err != ErrSchnorrSignature {
+			t.Fatalf("expect signature error got %v", err)
+		}
+	}
+}
+
+func TestTaprootAddress(t *testing.T) {
+	// BIP341 wallet test vector without script tree
+	internalKey, _ := hex.DecodeString("d6889cb081036e0faefa3a35157ad71086b123b2b144b649798b494c300a961d")
+
+	pub, err := liftX(internalKey)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	outputKey, err := taprootOutputKey(pub)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if hex.EncodeToString(outputKey) != "53a1f6e454df1aa2776a2814a721372d6258050de330b3c6d10ee8f4e0dda343" {
+		t.Fatalf("unexpected output key %x", outputKey)
+	}
+
+	address, err := NewAddressTaproot(outputKey, &chaincfg.MainNetParams)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expect := "bc1p2wsldez5mud2yam29q22wgfh9439spgduvct83k3pm50fcxa5dps59h4z5"
+
+	if address.EncodeAddress() != expect {
+		t.Fatalf("expect %s got %s", expect, address.EncodeAddress())
+	}
+
+	pkScript, err := payToAddrScript(address)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if hex.EncodeToString(pkScript) != "512053a1f6e454df1aa2776a2814a721372d6258050de330b3c6d10ee8f4e0dda343" {
+		t.Fatalf("unexpected script %x", pkScript)
+	}
+
+	decoded, err := decodeAddress(strings.ToUpper(expect), &chaincfg.MainNetParams)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if decoded.EncodeAddress() != expect {
+		t.Fatalf("expect %s got %s", expect, decoded.EncodeAddress())
+	}
+
+	for _, invalid := range []string{
+		// bech32 checksum of witness version 1
+		"bc1pw508d6qejxtdg4y5r3zarvary0c5xw7kw508d6qejxtdg4y5r3zarvary0c5xw7k7grplx",
+		expect[:len(expect)-1] + "6",
+		"tb1p2wsldez5mud2yam29q22wgfh9439spgduvct83k3pm50fcxa5dps59h4z5",
+	} {
+		if _, err := decodeTaprootAddress(invalid, &chaincfg.MainNetParams); err == nil {
+			t.Fatalf("expect invalid address %s", invalid)
+		}
+	}
+
+	if _, err := decodeAddress("bc1qw508d6qejxtdg4y5r3zarvary0c5xw7kv8f3t4", &chaincfg.MainNetParams); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestTaprootSigHash(t *testing.T) {
+	// BIP341 keyPathSpending wallet test vector
+	raw, _ := hex.DecodeString("02000000097de20cbff686da83a54981d2b9bab3586f4ca7e48f57f5b55963115f3b334e9c010000000000000000d7b7cab57b1393ace2d064f4d4a2cb8af6def61273e127517d44759b6dafdd990000000000fffffffff8e1f583384333689228c5d28eac13366be082dc57441760d957275419a418420000000000fffffffff0689180aa63b30cb162a73c6d2a38b7eeda2a83ece74310fda0843ad604853b0100000000feffffffaa5202bdf6d8ccd2ee0f0202afbbb7461d9264a25e5bfd3c5a52ee1239e0ba6c0000000000feffffff956149bdc66faa968eb2be2d2faa29718acbfe3941215893a2a3446d32acd050000000000000000000e664b9773b88c09c32cb70a2a3e4da0ced63b7ba3b22f848531bbb1d5d5f4c94010000000000000000e9aa6b8e6c9de67619e6a3924ae25696bb7b694bb677a632a74ef7eadfd4eabf0000000000ffffffffa778eb6a263dc090464cd125c466b5a99667720b1c110468831d058aa1b82af10100000000ffffffff0200ca9a3b000000001976a91406afd46bcdfd22ef94ac122aa11f241244a37ecc88ac807840cb0000000020ac9a87f5594be208f8532db38cff670c450ed2fea8fcdefcc9a663f78bab962b0065cd1d")
+
+	tx := wire.NewMsgTx(wire.TxVersion)
+
+	if err := tx.Deserialize(bytes.NewReader(raw)); err != nil {
+		t.Fatal(err)
+	}
+
+	utxos := []struct {
+		pkScript string
+		amount   int64
+	}{
+		{"512053a1f6e454df1aa2776a2814a721372d6258050de330b3c6d10ee8f4e0dda343", 420000000},
+		{"5120147c9c57132f6e7ecddba9800bb0c4449251c92a1e60371ee77557b6620f3ea3", 462000000},
+		{"76a914751e76e8199196d454941c45d1b3a323f1433bd688ac", 294000000},
+		{"5120e4d810fd50586274face62b8a807eb9719cef49c04177cc6b76a9a4251d5450e", 504000000},
+		{"512091b64d5324723a985170e4dc5a0f84c041804f2cd12660fa5dec09fc21783605", 630000000},
+		{"00147dd65592d0ab2fe0d0257d571abf032cd9db93dc", 378000000},
+		{"512075169f4001aa68f15bbed28b218df1d0a62cbbcf1188c6665110c293c907b831", 672000000},
+		{"5120712447206d7a5238acc7ff53fbe94a3b64539ad291c7cdbc490b7577e4b17df5", 546000000},
+		{"512077e30a5522dd9f894c3f8b8bd4c4b2cf82ca7da8a3ea6a239655c39c050ab220", 588000000},
+	}
+
+	var prevOuts []*wire.TxOut
+
+	for _, utxo := range utxos {
+		pkScript, _ := hex.DecodeString(utxo.pkScript)
+		prevOuts = append(prevOuts, wire.NewTxOut(utxo.amount, pkScript))
+	}
+
+	// only input 0 commits to no script tree, the witnesses of the others
+	// are checked against the output key of the spent script
+	vectors := []struct {
+		index    int
+		privkey  string
+		hashType txscript.SigHashType
+		sigHash  string
+		witness  string
+	}{
+		{0, "6b973d88838f27366ed61c9ad6367663045cb456e28335c109e30717ae0c6baa", txscript.SigHashSingle, "2514a6272f85cfa0f45eb907fcb0d121b808ed37c6ea160a5a9046ed5526d555", "ed7c1647cb97379e76892be0cacff57ec4a7102aa24296ca39af7541246d8ff14d38958d4cc1e2e478e4d4a764bbfd835b16d4e314b72937b29833060b87276c03"},
+		{1, "", txscript.SigHashSingle | txscript.SigHashAnyOneCanPay, "325a644af47e8a5a2591cda0ab0723978537318f10e6a63d4eed783b96a71a4d", "052aedffc554b41f52b521071793a6b88d6dbca9dba94cf34c83696de0c1ec35ca9c5ed4ab28059bd606a4f3a657eec0bb96661d42921b5f50a95ad33675b54f83"},
+		{3, "", txscript.SigHashAll, "bf013ea93474aa67815b1b6cc441d23b64fa310911d991e713cd34c7f5d46669", "ff45f742a876139946a149ab4d9185574b98dc919d2eb6754f8abaa59d18b025637a3aa043b91817739554f4ed2026cf8022dbd83e351ce1fabc272841d2510a01"},
+		{4, "", SigHashDefault, "4f900a0bae3f1446fd48490c2958b5a023228f01661cda3496a11da502a7f7ef", "b4010dd48a617db09926f729e79c33ae0b4e94b79f04a1ae93ede6315eb3669de185a17d2b0ac9ee09fd4c64b678a0b61a0a86fa888a273c8511be83bfd6810f"},
+		{6, "", txscript.SigHashNone, "15f25c298eb5cdc7eb1d638dd2d45c97c4c59dcaec6679cfc16ad84f30876b85", "a3785919a2ce3c4ce26f298c3d51619bc474ae24014bcdd31328cd8cfbab2eff3395fa0a16fe5f486d12f22a9cedded5ae74feb4bbe5351346508c5405bcfee002"},
+		{7, "", txscript.SigHashNone | txscript.SigHashAnyOneCanPay, "cd292de50313804dabe4685e83f923d2969577191a3e1d2882220dca88cbeb10", "ea0c6ba90763c2d3a296ad82ba45881abb4f426b3f87af162dd24d5109edc1cdd11915095ba47c3a9963dc1e6c432939872bc49212fe34c632cd3ab9fed429c482"},
+		{8, "", txscript.SigHashAll | txscript.SigHashAnyOneCanPay, "cccb739eca6c13a8a89e6e5cd317ffe55669bbda23f2fd37b0f18755e008edd2", "bbc9584a11074e83bc8c6759ec55401f0ae7b03ef290c3139814f545b58a9f8127258000874f44bc46db7646322107d4d86aec8e73b8719a61fff761d75b5dd981"},
+	}
+
+	for _, v := range vectors {
+		hash, err := taprootSigHash(tx, v.index, prevOuts, v.hashType)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if hex.EncodeToString(hash) != v.sigHash {
+			t.Fatalf("input %d: unexpected sighash %x", v.index, hash)
+		}
+
+		witness, _ := hex.DecodeString(v.witness)
+
+		if v.privkey == "" {
+			if err := schnorrVerify(prevOuts[v.index].PkScript[2:], hash, witness[:64]); err != nil {
+				t.Fatalf("input %d: %s", v.index, err)
+			}
+
+			continue
+		}
+
+		key, _ := hex.DecodeString(v.privkey)
+		priv, _ := btcec.PrivKeyFromBytes(btcec.S256(), key)
+
+		sig, err := taprootSignature(tx, v.index, prevOuts, v.hashType, priv, make([]byte, 32))
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !bytes.Equal(sig, witness) {
+			t.Fatalf("input %d: unexpected witness %x", v.index, sig)
+		}
+	}
+}
+
+func TestTaprootWallet(t *testing.T) {
+	// BIP86 test vectors
+	seed := bip39.NewSeed("abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about", "")
+
+	for path, expect := range map[string]string{
+		"m/86'/0'/0'/0/0": "bc1p5cyxnuxmeuwuvkwfem96lqzszd02n6xdcjrs20cac6yqjjwudpxqkedrcr",
+		"m/86'/0'/0'/0/1": "bc1p4qhjn9zdvkux4e44uhx8tc55attvtyu358kutcqkudyccelu0was9fqzwh",
+		"m/86'/0'/0'/1/0": "bc1p3qkhfews2uk44qtvauqyr2ttdsw7svhkl9nkm9s9c3x4ax5h60wqwruhk7",
+	} {
+		wallet, err := WalletFromSeed(seed, path, NetTypeMainNet, WithAddressType(AddressTypeP2TR))
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if wallet.Address.EncodeAddress() != expect {
+			t.Fatalf("%s: expect %s got %s", path, expect, wallet.Address.EncodeAddress())
+		}
+	}
+}
+
+func TestPayP2TR(t *testing.T) {
+	wallet := testWallet(t, WithAddressType(AddressTypeP2TR))
+
+	segwit := testWallet(t, WithAddressType(AddressTypeP2WPKH))
+
+	utxos := []UTXO{
+		testUTXO(t, wallet, 30000, 0),
+		testUTXO(t, segwit, 40000, 1),
+	}
+
+	var buff bytes.Buffer
+
+	if err := wallet.Pay(utxos, wallet.Address.EncodeAddress(), 50000, 2, &buff); err != nil {
+		t.Fatal(err)
+	}
+
+	var tx wire.MsgTx
+
+	if err := tx.Deserialize(bytes.NewReader(buff.Bytes())); err != nil {
+		t.Fatal(err)
+	}
+
+	prevOuts := make([]*wire.TxOut, len(tx.TxIn))
+
+	for i, txin := range tx.TxIn {
+		pkScript, _ := hex.DecodeString(utxos[txin.PreviousOutPoint.Index].ScriptPubKey)
+
+		prevOuts[i] = wire.NewTxOut(int64(utxos[txin.PreviousOutPoint.Index].Satoshis), pkScript)
+	}
+
+	for i, txin := range tx.TxIn {
+		if !isTaprootScript(prevOuts[i].PkScript) {
+			engine, err := txscript.NewEngine(prevOuts[i].PkScript, &tx, i, txscript.StandardVerifyFlags, nil, txscript.NewTxSigHashes(&tx), prevOuts[i].Value)
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if err := engine.Execute(); err != nil {
+				t.Fatal(err)
+			}
+
+			continue
+		}
+
+		if len(txin.Witness) != 1 || len(txin.Witness[0]) != 64 {
+			t.Fatal("expect key path witness with 64 bytes signature")
+		}
+
+		hash, err := taprootSigHash(&tx, i, prevOuts, SigHashDefault)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if err := schnorrVerify(prevOuts[i].PkScript[2:], hash, txin.Witness[0]); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if !isTaprootScript(tx.TxOut[0].PkScript) {
+		t.Fatalf("expect p2tr output got %x", tx.TxOut[0].PkScript)
+	}
+}
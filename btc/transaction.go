@@ -1,7 +1,10 @@
 package btc
 
 import (
+	"bytes"
+	"crypto/rand"
 	"encoding/hex"
+	"errors"
 	"fmt"
 
 	"github.com/btcsuite/btcd/btcec"
@@ -22,32 +25,118 @@ type UTXO struct {
 	Satoshis      float64 `json:"satoshis"`
 	Height        float64 `json:"height"`
 	Confirmations float64 `json:"confirmations"`
+	// PrevTx hex encoded transaction creating the utxo, psbt signers
+	// require it for non segwit inputs
+	PrevTx string `json:"prevtx,omitempty"`
+}
+
+// WitnessUtxo spent output as serialized in segwit signatures and psbt
+func (utxo UTXO) WitnessUtxo() (*wire.TxOut, error) {
+	pkScript, err := hex.DecodeString(utxo.ScriptPubKey)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return wire.NewTxOut(int64(utxo.Satoshis), pkScript), nil
+}
+
+// Transaction errors
+var (
+	ErrSigHashType     = errors.New("unsupported sighash type")
+	ErrNoPayments      = errors.New("transaction without payment outputs")
+	ErrDuplicateOutput = errors.New("duplicate payment output")
+	ErrDustOutput      = errors.New("payment output below dust limit")
+)
+
+// payment output of transaction, subtractFee outputs pay the fee in equal
+// shares
+type payment struct {
+	addr        btcutil.Address
+	amount      btcutil.Amount
+	subtractFee bool
 }
 
 // Transaction btc transaction object
 type transaction struct {
 	slf4go.Logger
 	inputs     []UTXO // input utxos
-	payto      btcutil.Address
-	amount     btcutil.Amount
+	payments   []payment
 	paychange  btcutil.Address
 	payFeeRate btcutil.Amount
 	privatekey *btcec.PrivateKey
 	txIn       map[*wire.TxIn]UTXO
 	compressed bool
+	// multisig redeem or witness script of the spent outputs
+	redeemScript []byte
+	threshold    int
+	selector     CoinSelector
+	selection    *CoinSelection
+	sigHashType  txscript.SigHashType
+	sequence     uint32
+}
+
+// PayOption optional transaction setting
+type PayOption func(trans *transaction)
+
+// WithCoinSelector select inputs with selector, the default InOrderSelector
+// spends utxos in the given order
+func WithCoinSelector(selector CoinSelector) PayOption {
+	return func(trans *transaction) {
+		trans.selector = selector
+	}
+}
+
+// WithSigHashType sign inputs with hashType instead of SIGHASH_ALL, one of
+// ALL, NONE or SINGLE optionally combined with ANYONECANPAY. SignPsbt only
+// signs psbt inputs requesting hashType with this option
+func WithSigHashType(hashType txscript.SigHashType) PayOption {
+	return func(trans *transaction) {
+		trans.sigHashType = hashType
+	}
+}
+
+// checkSigHashType check hashType is supported for signing input idx of tx,
+// SIGHASH_SINGLE requires an output at the input index
+func checkSigHashType(tx *wire.MsgTx, idx int, hashType txscript.SigHashType) error {
+	switch hashType &^ txscript.SigHashAnyOneCanPay {
+	case txscript.SigHashAll, txscript.SigHashNone:
+	case txscript.SigHashSingle:
+		if idx >= len(tx.TxOut) {
+			return fmt.Errorf("sighash single input %d without matching output", idx)
+		}
+	default:
+		return ErrSigHashType
+	}
+
+	return nil
 }
 
 func txFrom(inputs []UTXO) *transaction {
 	return &transaction{
-		inputs: inputs,
-		Logger: slf4go.Get("tx"),
-		txIn:   make(map[*wire.TxIn]UTXO),
+		inputs:      inputs,
+		Logger:      slf4go.Get("tx"),
+		txIn:        make(map[*wire.TxIn]UTXO),
+		selector:    InOrderSelector{},
+		sigHashType: txscript.SigHashAll,
+		sequence:    wire.MaxTxInSequenceNum,
 	}
 }
 
+func (trans *transaction) options(options []PayOption) *transaction {
+	for _, option := range options {
+		option(trans)
+	}
+
+	return trans
+}
+
 func (trans *transaction) to(addr btcutil.Address, amount btcutil.Amount) *transaction {
-	trans.payto = addr
-	trans.amount = amount
+	return trans.pay(addr, amount, false)
+}
+
+func (trans *transaction) pay(addr btcutil.Address, amount btcutil.Amount, subtractFee bool) *transaction {
+	trans.payments = append(trans.payments, payment{addr: addr, amount: amount, subtractFee: subtractFee})
 	return trans
 }
 
@@ -67,105 +156,442 @@ func (trans *transaction) sign(privatekey *btcec.PrivateKey, compressed bool) *t
 	return trans
 }
 
+func (trans *transaction) multisig(redeemScript []byte, threshold int) *transaction {
+	trans.redeemScript = redeemScript
+	trans.threshold = threshold
+	return trans
+}
+
+// done build transaction, inputs are signed if a private key is set
 func (trans *transaction) done() (*wire.MsgTx, error) {
 	tx := wire.NewMsgTx(wire.TxVersion)
 
-	trans.Debug(trans.payto.EncodeAddress(), hex.EncodeToString(trans.payto.ScriptAddress()))
-
-	addrScript, err := txscript.PayToAddrScript(trans.payto)
+	err := trans.addPayments(tx)
 
 	if err != nil {
 		return nil, err
 	}
 
-	tx.AddTxOut(wire.NewTxOut(int64(trans.amount), addrScript))
-
 	err = trans.calcChange(tx)
 
 	if err != nil {
 		return nil, err
 	}
 
-	for _, txin := range tx.TxIn {
+	if trans.privatekey == nil {
+		return tx, nil
+	}
+
+	if err := trans.signInputs(tx); err != nil {
+		return nil, err
+	}
+
+	return tx, nil
+}
+
+// signInputs sign every input of tx with the transaction private key and
+// verify the signatures
+func (trans *transaction) signInputs(tx *wire.MsgTx) error {
+	sigHashes := txscript.NewTxSigHashes(tx)
+
+	// taproot signatures commit to every spent output
+	prevOuts := make([]*wire.TxOut, len(tx.TxIn))
+
+	for i, txin := range tx.TxIn {
 		utxo := trans.txIn[txin]
 
-		pkScript, err := hex.DecodeString(utxo.ScriptPubKey)
+		prevOut, err := utxo.WitnessUtxo()
 
 		if err != nil {
-			return nil, err
+			return err
 		}
 
-		sigScript, err := txscript.SignatureScript(tx, len(tx.TxIn)-1, pkScript, txscript.SigHashNone, trans.privatekey, trans.compressed)
+		prevOuts[i] = prevOut
+	}
 
-		if err != nil {
-			return nil, err
+	for i, txin := range tx.TxIn {
+		utxo := trans.txIn[txin]
+
+		pkScript := prevOuts[i].PkScript
+
+		if err := checkSigHashType(tx, i, trans.sigHashType); err != nil {
+			return err
 		}
 
-		txin.SignatureScript = sigScript
+		if isTaprootScript(pkScript) {
+			outputKey, err := taprootOutputKey(trans.privatekey.PubKey())
+
+			if err != nil {
+				return err
+			}
+
+			if !bytes.Equal(pkScript[2:], outputKey) {
+				return fmt.Errorf("utxo %s:%d is not a p2tr output of the wallet", utxo.TxID, utxo.VOut)
+			}
+
+			aux := make([]byte, 32)
+
+			if _, err := rand.Read(aux); err != nil {
+				return err
+			}
+
+			// SIGHASH_DEFAULT commits to the same data as SIGHASH_ALL
+			hashType := trans.sigHashType
+
+			if hashType == txscript.SigHashAll {
+				hashType = SigHashDefault
+			}
+
+			sig, err := taprootSignature(tx, i, prevOuts, hashType, trans.privatekey, aux)
+
+			if err != nil {
+				return err
+			}
+
+			txin.Witness = wire.TxWitness{sig}
+
+			continue
+		}
+
+		switch txscript.GetScriptClass(pkScript) {
+		case txscript.WitnessV0PubKeyHashTy:
+			witness, err := txscript.WitnessSignature(tx, sigHashes, i, int64(utxo.Satoshis), pkScript, trans.sigHashType, trans.privatekey, true)
+
+			if err != nil {
+				return err
+			}
+
+			txin.Witness = witness
+		case txscript.ScriptHashTy:
+			redeemScript := witnessRedeemScript(btcutil.Hash160(trans.privatekey.PubKey().SerializeCompressed()))
+
+			if !bytes.Equal(pkScript[2:22], btcutil.Hash160(redeemScript)) {
+				return fmt.Errorf("utxo %s:%d is not a p2sh-p2wpkh output of the wallet", utxo.TxID, utxo.VOut)
+			}
+
+			witness, err := txscript.WitnessSignature(tx, sigHashes, i, int64(utxo.Satoshis), redeemScript, trans.sigHashType, trans.privatekey, true)
+
+			if err != nil {
+				return err
+			}
+
+			sigScript, err := txscript.NewScriptBuilder().AddData(redeemScript).Script()
+
+			if err != nil {
+				return err
+			}
+
+			txin.SignatureScript = sigScript
+			txin.Witness = witness
+		default:
+			sigScript, err := txscript.SignatureScript(tx, i, pkScript, trans.sigHashType, trans.privatekey, trans.compressed)
+
+			if err != nil {
+				return err
+			}
+
+			txin.SignatureScript = sigScript
+		}
 	}
 
-	return tx, nil
+	return verifySignatures(tx, prevOuts)
 }
 
-func (trans *transaction) calcChange(tx *wire.MsgTx) error {
-	const (
-		// spendSize is the largest number of bytes of a sigScript
-		// which spends a p2pkh output: OP_DATA_73 <sig> OP_DATA_33 <pubkey>
-		spendSize = 1 + 73 + 1 + 33
-	)
+// addPayments add payment outputs to tx, duplicate and dust outputs are
+// rejected
+func (trans *transaction) addPayments(tx *wire.MsgTx) error {
+	if len(trans.payments) == 0 {
+		return ErrNoPayments
+	}
+
+	scripts := make(map[string]bool)
 
+	for _, payment := range trans.payments {
+		trans.Debug(payment.addr.EncodeAddress(), hex.EncodeToString(payment.addr.ScriptAddress()))
+
+		pkScript, err := payToAddrScript(payment.addr)
+
+		if err != nil {
+			return err
+		}
+
+		if scripts[string(pkScript)] {
+			return fmt.Errorf("%w: %s", ErrDuplicateOutput, payment.addr.EncodeAddress())
+		}
+
+		scripts[string(pkScript)] = true
+
+		if limit := dustLimit(pkScript); payment.amount < limit {
+			return fmt.Errorf("%w: %s amount %d below %d", ErrDustOutput, payment.addr.EncodeAddress(), payment.amount, limit)
+		}
+
+		tx.AddTxOut(wire.NewTxOut(int64(payment.amount), pkScript))
+	}
+
+	return nil
+}
+
+// subtractFee take the part of the fee not paid by the inputs from the
+// subtractFee payment outputs, the first of them pays the remainder
+func (trans *transaction) subtractFee(tx *wire.MsgTx, selection *CoinSelection) error {
 	var (
-		amtSelected btcutil.Amount
-		txSize      int
+		total   btcutil.Amount
+		amount  btcutil.Amount
+		indexes []int
 	)
 
-	for _, utxo := range trans.inputs {
+	for _, utxo := range selection.Inputs {
+		total += btcutil.Amount(utxo.Satoshis)
+	}
 
-		amtSelected += btcutil.Amount(utxo.Satoshis)
+	for i, payment := range trans.payments {
+		amount += payment.amount
 
-		hash, err := chainhash.NewHashFromStr(utxo.TxID)
+		if payment.subtractFee {
+			indexes = append(indexes, i)
+		}
+	}
+
+	// negative if the excess of a changeless selection goes to the outputs
+	share := amount + selection.Fee + selection.Change - total
+
+	for n, i := range indexes {
+		value := share / btcutil.Amount(len(indexes))
+
+		if n == 0 {
+			value += share % btcutil.Amount(len(indexes))
+		}
+
+		tx.TxOut[i].Value -= int64(value)
+
+		if limit := dustLimit(tx.TxOut[i].PkScript); btcutil.Amount(tx.TxOut[i].Value) < limit {
+			return fmt.Errorf("%w: %s can not pay fee share %d", ErrDustOutput, trans.payments[i].addr.EncodeAddress(), value)
+		}
+	}
+
+	return nil
+}
+
+// verifySignatures run every input of signed tx through the script engine,
+// prevOuts are the spent outputs in input order
+func verifySignatures(tx *wire.MsgTx, prevOuts []*wire.TxOut) error {
+	sigHashes := txscript.NewTxSigHashes(tx)
+
+	for i := range tx.TxIn {
+		pkScript := prevOuts[i].PkScript
+
+		// the engine does not know witness v1 programs
+		if isTaprootScript(pkScript) {
+			if err := taprootVerify(tx, i, prevOuts); err != nil {
+				return fmt.Errorf("verify input %d: %s", i, err)
+			}
+
+			continue
+		}
+
+		engine, err := txscript.NewEngine(pkScript, tx, i, txscript.StandardVerifyFlags, nil, sigHashes, prevOuts[i].Value)
 
 		if err != nil {
 			return err
 		}
 
-		outPoint := wire.OutPoint{
-			Hash:  *hash,
-			Index: utxo.VOut,
+		if err := engine.Execute(); err != nil {
+			return fmt.Errorf("verify input %d: %s", i, err)
+		}
+	}
+
+	return nil
+}
+
+// unlocking data size of spent outputs, witness data in weight units
+const (
+	// p2pkhSpendSize is the largest number of bytes of a sigScript
+	// which spends a p2pkh output: OP_DATA_73 <sig> OP_DATA_33 <pubkey>
+	p2pkhSpendSize = 1 + 73 + 1 + 33
+	// p2pkhUncompressedSpendSize is the p2pkh sigScript of an
+	// uncompressed key: OP_DATA_73 <sig> OP_DATA_65 <pubkey>
+	p2pkhUncompressedSpendSize = 1 + 73 + 1 + 65
+	// p2wpkhWitnessSize is the largest witness which spends a p2wpkh
+	// output: item count, OP_DATA_73 <sig> OP_DATA_33 <pubkey>
+	p2wpkhWitnessSize = 1 + 1 + 73 + 1 + 33
+	// p2shP2WPKHSpendSize is the sigScript pushing the p2wpkh redeem
+	// script: OP_DATA_22 OP_0 OP_DATA_20 <pubkey hash>
+	p2shP2WPKHSpendSize = 1 + 22
+	// multisigSigSize is one signature push of a multisig spend
+	multisigSigSize = 1 + 73
+	// witnessHeaderSize segwit marker and flag bytes
+	witnessHeaderSize = 2
+)
+
+// inputWeight weight of the signed input spending utxo, witness reports
+// if the input has witness data. P2pkh inputs signed with an uncompressed
+// key push the 65 bytes public key
+func (trans *transaction) inputWeight(utxo UTXO) (weight int, witness bool, err error) {
+	pkScript, err := hex.DecodeString(utxo.ScriptPubKey)
+
+	if err != nil {
+		return 0, false, err
+	}
+
+	var sigScript, witnessSize int
+
+	switch class := txscript.GetScriptClass(pkScript); {
+	case isTaprootScript(pkScript):
+		witnessSize = p2trWitnessSize
+	case class == txscript.PubKeyHashTy && trans.privatekey != nil && !trans.compressed:
+		sigScript = p2pkhUncompressedSpendSize
+	case class == txscript.PubKeyHashTy:
+		sigScript = p2pkhSpendSize
+	case class == txscript.WitnessV0PubKeyHashTy:
+		witnessSize = p2wpkhWitnessSize
+	case class == txscript.ScriptHashTy && trans.redeemScript != nil:
+		// OP_0 <sig>... <redeem script>
+		sigScript = 1 + trans.threshold*multisigSigSize + pushDataSize(len(trans.redeemScript))
+	case class == txscript.ScriptHashTy:
+		// the only p2sh outputs owned by single key wallets are p2sh-p2wpkh
+		sigScript = p2shP2WPKHSpendSize
+		witnessSize = p2wpkhWitnessSize
+	case class == txscript.WitnessV0ScriptHashTy && trans.redeemScript != nil:
+		// item count, empty item, <sig>... <witness script>
+		witnessSize = 1 + 1 + trans.threshold*multisigSigSize + wire.VarIntSerializeSize(uint64(len(trans.redeemScript))) + len(trans.redeemScript)
+	case class == txscript.WitnessV0ScriptHashTy:
+		return 0, false, fmt.Errorf("spending %s utxo requires witness script", class)
+	default:
+		return 0, false, fmt.Errorf("spending %s utxo is not supported", class)
+	}
+
+	// outpoint, sequence and sigScript are non witness data
+	base := 32 + 4 + 4 + wire.VarIntSerializeSize(uint64(sigScript)) + sigScript
+
+	return base*4 + witnessSize, witnessSize > 0, nil
+}
+
+// dustLimit smallest output value worth spending at the default relay fee of
+// 3 satoshis per vbyte, including the size of the input spending it later
+func dustLimit(pkScript []byte) btcutil.Amount {
+	size := wire.NewTxOut(0, pkScript).SerializeSize()
+
+	// spending input of 107 bytes sigScript or witness, discounted for witness
+	if txscript.IsWitnessProgram(pkScript) {
+		size += 67
+	} else {
+		size += 148
+	}
+
+	return btcutil.Amount(size * 3)
+}
+
+// pushDataSize size of canonical data push of n bytes
+func pushDataSize(n int) int {
+	switch {
+	case n < txscript.OP_PUSHDATA1:
+		return 1 + n
+	case n <= 0xff:
+		return 2 + n
+	case n <= 0xffff:
+		return 3 + n
+	default:
+		return 5 + n
+	}
+}
+
+// calcChange select inputs with the coin selector and add the change output
+func (trans *transaction) calcChange(tx *wire.MsgTx) error {
+	changeScript, err := payToAddrScript(trans.paychange)
+
+	if err != nil {
+		return err
+	}
+
+	params, err := trans.selectionParams(tx, changeScript)
+
+	if err != nil {
+		return err
+	}
+
+	selection, err := trans.selector.Select(trans.inputs, params)
+
+	if err != nil {
+		return err
+	}
+
+	if params.SubtractFee {
+		if err := trans.subtractFee(tx, selection); err != nil {
+			return err
+		}
+	}
+
+	for _, utxo := range selection.Inputs {
+		hash, err := chainhash.NewHashFromStr(utxo.TxID)
+
+		if err != nil {
+			return err
 		}
 
-		trans.Debug(trans.privatekey)
+		txin := wire.NewTxIn(wire.NewOutPoint(hash, utxo.VOut), nil, nil)
 
-		txin := wire.NewTxIn(&outPoint, nil)
+		txin.Sequence = trans.sequence
 
 		tx.AddTxIn(txin)
 
 		trans.txIn[txin] = utxo
+	}
 
-		txSize = tx.SerializeSize() + spendSize*len(tx.TxIn)
+	if selection.Change > 0 {
+		tx.AddTxOut(wire.NewTxOut(int64(selection.Change), changeScript))
+	}
 
-		reqFee := btcutil.Amount(txSize * int(trans.payFeeRate))
-		if amtSelected-reqFee < trans.amount {
-			continue
-		}
+	trans.selection = selection
 
-		changeVal := amtSelected - trans.amount - reqFee
-		if changeVal > 0 {
-			pkScript, err := txscript.PayToAddrScript(trans.paychange)
-			if err != nil {
-				return err
-			}
-			changeOutput := &wire.TxOut{
-				Value:    int64(changeVal),
-				PkScript: pkScript,
-			}
-			tx.AddTxOut(changeOutput)
+	trans.Debug("reqFee: ", selection.Fee, " change: ", selection.Change, " waste: ", selection.Waste)
+
+	return nil
+}
+
+// selectionParams coin selection parameters of tx paying its outputs
+func (trans *transaction) selectionParams(tx *wire.MsgTx, changeScript []byte) (*CoinSelectionParams, error) {
+	var amount btcutil.Amount
+
+	for _, txout := range tx.TxOut {
+		amount += btcutil.Amount(txout.Value)
+	}
+
+	subtractFee := false
+
+	for _, payment := range trans.payments {
+		subtractFee = subtractFee || payment.subtractFee
+	}
+
+	baseWeight := tx.SerializeSizeStripped() * 4
+
+	for _, utxo := range trans.inputs {
+		if _, witness, err := trans.inputWeight(utxo); err != nil {
+			return nil, err
+		} else if witness {
+			baseWeight += witnessHeaderSize
+			break
 		}
+	}
 
-		trans.Debug("reqFee: ", reqFee, " change: ", changeVal)
+	changeSpendWeight, _, err := trans.inputWeight(UTXO{ScriptPubKey: hex.EncodeToString(changeScript)})
 
-		return nil
+	if err != nil {
+		return nil, err
 	}
 
-	return fmt.Errorf("not enough funds for coin selection")
+	return &CoinSelectionParams{
+		Amount:            amount,
+		FeeRate:           trans.payFeeRate,
+		LongTermFeeRate:   DefaultLongTermFeeRate,
+		BaseWeight:        baseWeight,
+		ChangeWeight:      wire.NewTxOut(0, changeScript).SerializeSize() * 4,
+		ChangeSpendWeight: changeSpendWeight,
+		DustLimit:         dustLimit(changeScript),
+		SubtractFee:       subtractFee,
+		InputWeight: func(utxo UTXO) (int, error) {
+			weight, _, err := trans.inputWeight(utxo)
+
+			return weight, err
+		},
+	}, nil
 }
@@ -0,0 +1,353 @@
+package btc
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/btcsuite/btcd/btcec"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+)
+
+const testPayTo = "bc1qw508d6qejxtdg4y5r3zarvary0c5xw7kv8f3t4"
+
+func testWallet(t *testing.T, options ...WalletOption) *Wallet {
+	priv, _ := btcec.PrivKeyFromBytes(btcec.S256(), bytes.Repeat([]byte{0x03}, 32))
+
+	wallet, err := newWallet(priv, priv.PubKey(), true, AddressTypeP2PKH, NetTypeMainNet, options...)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return wallet
+}
+
+// testUTXO utxo paying satoshis to wallet address
+func testUTXO(t *testing.T, wallet *Wallet, satoshis int64, vout uint32) UTXO {
+	pkScript, err := payToAddrScript(wallet.Address)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return UTXO{
+		Address:      wallet.Address.EncodeAddress(),
+		TxID:         chainhash.DoubleHashH([]byte{byte(vout)}).String(),
+		VOut:         vout,
+		ScriptPubKey: hex.EncodeToString(pkScript),
+		Satoshis:     float64(satoshis),
+	}
+}
+
+// verifyTx run every input of tx through the script engine
+func verifyTx(t *testing.T, tx *wire.MsgTx, utxos []UTXO) {
+	sigHashes := txscript.NewTxSigHashes(tx)
+
+	for i, txin := range tx.TxIn {
+		var utxo *UTXO
+
+		for j := range utxos {
+			if utxos[j].VOut == txin.PreviousOutPoint.Index {
+				utxo = &utxos[j]
+			}
+		}
+
+		pkScript, _ := hex.DecodeString(utxo.ScriptPubKey)
+
+		engine, err := txscript.NewEngine(pkScript, tx, i, txscript.StandardVerifyFlags, nil, sigHashes, int64(utxo.Satoshis))
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if err := engine.Execute(); err != nil {
+			t.Fatalf("input %d: %s", i, err)
+		}
+	}
+}
+
+func TestPaySegwit(t *testing.T) {
+	for addressType, prefix := range map[AddressType]string{
+		AddressTypeP2WPKH:     "bc1q",
+		AddressTypeP2SHP2WPKH: "3",
+	} {
+		wallet := testWallet(t, WithAddressType(addressType))
+
+		if !strings.HasPrefix(wallet.Address.EncodeAddress(), prefix) {
+			t.Fatalf("expect %s address got %s", addressType, wallet.Address.EncodeAddress())
+		}
+
+		utxos := []UTXO{
+			testUTXO(t, wallet, 30000, 0),
+			testUTXO(t, wallet, 40000, 1),
+		}
+
+		var buff bytes.Buffer
+
+		if err := wallet.Pay(utxos, testPayTo, 50000, 2, &buff); err != nil {
+			t.Fatal(err)
+		}
+
+		var tx wire.MsgTx
+
+		if err := tx.Deserialize(bytes.NewReader(buff.Bytes())); err != nil {
+			t.Fatal(err)
+		}
+
+		if !tx.HasWitness() || len(tx.TxIn) != 2 {
+			t.Fatalf("expect 2 witness inputs got %d", len(tx.TxIn))
+		}
+
+		for _, txin := range tx.TxIn {
+			if len(txin.Witness) != 2 {
+				t.Fatalf("expect 2 witness items got %d", len(txin.Witness))
+			}
+
+			if (addressType == AddressTypeP2WPKH) != (len(txin.SignatureScript) == 0) {
+				t.Fatalf("unexpected %s script sig %x", addressType, txin.SignatureScript)
+			}
+		}
+
+		verifyTx(t, &tx, utxos)
+
+		// fee covers the signed virtual size including the change output
+		var out int64
+
+		for _, txout := range tx.TxOut {
+			out += txout.Value
+		}
+
+		vsize := (tx.SerializeSizeStripped()*3 + tx.SerializeSize() + 3) / 4
+
+		if fee := 70000 - out; fee < int64(vsize)*2 || fee > int64(vsize+2)*2 {
+			t.Fatalf("%s fee %d does not match vsize %d", addressType, fee, vsize)
+		}
+	}
+}
+
+func TestPaySigHashType(t *testing.T) {
+	for _, addressType := range []AddressType{AddressTypeP2PKH, AddressTypeP2WPKH, AddressTypeP2SHP2WPKH, AddressTypeP2TR} {
+		wallet := testWallet(t, WithAddressType(addressType))
+
+		utxos := []UTXO{
+			testUTXO(t, wallet, 30000, 0),
+			testUTXO(t, wallet, 40000, 1),
+		}
+
+		for _, hashType := range []txscript.SigHashType{
+			txscript.SigHashAll,
+			txscript.SigHashNone,
+			txscript.SigHashSingle,
+			txscript.SigHashAll | txscript.SigHashAnyOneCanPay,
+			txscript.SigHashNone | txscript.SigHashAnyOneCanPay,
+			txscript.SigHashSingle | txscript.SigHashAnyOneCanPay,
+		} {
+			var buff bytes.Buffer
+
+			if err := wallet.Pay(utxos, testPayTo, 50000, 2, &buff, WithSigHashType(hashType)); err != nil {
+				t.Fatalf("%s %d: %s", addressType, hashType, err)
+			}
+
+			var tx wire.MsgTx
+
+			if err := tx.Deserialize(bytes.NewReader(buff.Bytes())); err != nil {
+				t.Fatal(err)
+			}
+
+			if len(tx.TxIn) != 2 {
+				t.Fatalf("expect 2 inputs got %d", len(tx.TxIn))
+			}
+
+			for _, txin := range tx.TxIn {
+				var sig []byte
+
+				if len(txin.Witness) > 0 {
+					sig = txin.Witness[0]
+				} else {
+					pushes, err := txscript.PushedData(txin.SignatureScript)
+
+					if err != nil {
+						t.Fatal(err)
+					}
+
+					sig = pushes[0]
+				}
+
+				if addressType == AddressTypeP2TR && hashType == txscript.SigHashAll {
+					if len(sig) != 64 {
+						t.Fatalf("expect sighash default signature got %d bytes", len(sig))
+					}
+
+					continue
+				}
+
+				if txscript.SigHashType(sig[len(sig)-1]) != hashType {
+					t.Fatalf("%s: expect sighash %d got %d", addressType, hashType, sig[len(sig)-1])
+				}
+			}
+
+			prevOuts := make([]*wire.TxOut, len(tx.TxIn))
+
+			for i, txin := range tx.TxIn {
+				prevOuts[i], _ = utxos[txin.PreviousOutPoint.Index].WitnessUtxo()
+			}
+
+			if err := verifySignatures(&tx, prevOuts); err != nil {
+				t.Fatalf("%s %d: %s", addressType, hashType, err)
+			}
+		}
+	}
+}
+
+func TestPaySigHashTypeInvalid(t *testing.T) {
+	wallet := testWallet(t)
+
+	utxos := []UTXO{
+		testUTXO(t, wallet, 30000, 0),
+		testUTXO(t, wallet, 30000, 1),
+		testUTXO(t, wallet, 30000, 2),
+	}
+
+	var buff bytes.Buffer
+
+	if err := wallet.Pay(utxos, testPayTo, 50000, 2, &buff, WithSigHashType(0x04)); err != ErrSigHashType {
+		t.Fatalf("expect sighash type error got %v", err)
+	}
+
+	// the third input has no output at its index
+	if err := wallet.Pay(utxos, testPayTo, 80000, 2, &buff, WithSigHashType(txscript.SigHashSingle)); err == nil {
+		t.Fatal("expect sighash single error")
+	}
+}
+
+func TestVerifySignatures(t *testing.T) {
+	for _, addressType := range []AddressType{AddressTypeP2PKH, AddressTypeP2WPKH, AddressTypeP2TR} {
+		wallet := testWallet(t, WithAddressType(addressType))
+
+		utxos := []UTXO{testUTXO(t, wallet, 30000, 0)}
+
+		var buff bytes.Buffer
+
+		if err := wallet.Pay(utxos, testPayTo, 20000, 2, &buff); err != nil {
+			t.Fatal(err)
+		}
+
+		var tx wire.MsgTx
+
+		if err := tx.Deserialize(bytes.NewReader(buff.Bytes())); err != nil {
+			t.Fatal(err)
+		}
+
+		prevOut, _ := utxos[0].WitnessUtxo()
+
+		tx.TxOut[0].Value++
+
+		if err := verifySignatures(&tx, []*wire.TxOut{prevOut}); err == nil {
+			t.Fatalf("%s: expect tampered output verify error", addressType)
+		}
+	}
+}
+
+func TestPayMany(t *testing.T) {
+	wallet := testWallet(t, WithAddressType(AddressTypeP2WPKH))
+
+	other := testWallet(t, WithAddressType(AddressTypeP2TR))
+
+	utxos := []UTXO{
+		testUTXO(t, wallet, 30000, 0),
+		testUTXO(t, wallet, 40000, 1),
+	}
+
+	for _, subtractFee := range []bool{false, true} {
+		payments := []Payment{
+			{Address: testPayTo, Amount: 20000, SubtractFee: subtractFee},
+			{Address: other.Address.EncodeAddress(), Amount: 15000},
+			{Address: "1BgGZ9tcN4rm9KBzDn7KprQz87SZ26SAMH", Amount: 10001, SubtractFee: subtractFee},
+		}
+
+		var buff bytes.Buffer
+
+		if err := wallet.PayMany(utxos, payments, 2, &buff); err != nil {
+			t.Fatal(err)
+		}
+
+		var tx wire.MsgTx
+
+		if err := tx.Deserialize(bytes.NewReader(buff.Bytes())); err != nil {
+			t.Fatal(err)
+		}
+
+		if len(tx.TxOut) != len(payments)+1 {
+			t.Fatalf("expect %d outputs with change got %d", len(payments)+1, len(tx.TxOut))
+		}
+
+		verifyTx(t, &tx, utxos)
+
+		var out int64
+
+		for _, txout := range tx.TxOut {
+			out += txout.Value
+		}
+
+		if len(tx.TxIn) != 2 {
+			t.Fatalf("expect 2 inputs got %d", len(tx.TxIn))
+		}
+
+		fee := 70000 - out
+
+		if vsize := (tx.SerializeSizeStripped()*3 + tx.SerializeSize() + 3) / 4; fee < int64(vsize)*2 || fee > int64(vsize+2)*2 {
+			t.Fatalf("fee %d does not match vsize %d", fee, vsize)
+		}
+
+		if tx.TxOut[1].Value != 15000 {
+			t.Fatalf("expect untouched output got %d", tx.TxOut[1].Value)
+		}
+
+		if !subtractFee {
+			if tx.TxOut[0].Value != 20000 || tx.TxOut[2].Value != 10001 {
+				t.Fatalf("unexpected outputs %d %d", tx.TxOut[0].Value, tx.TxOut[2].Value)
+			}
+
+			continue
+		}
+
+		// the first subtracting output pays the odd satoshi
+		paid0, paid2 := 20000-tx.TxOut[0].Value, 10001-tx.TxOut[2].Value
+
+		if paid0+paid2 != fee || paid0-paid2 > 1 || paid0 < paid2 {
+			t.Fatalf("unexpected fee shares %d %d of %d", paid0, paid2, fee)
+		}
+
+		// the change keeps the whole input excess
+		if tx.TxOut[3].Value != 70000-20000-15000-10001 {
+			t.Fatalf("unexpected change %d", tx.TxOut[3].Value)
+		}
+	}
+}
+
+func TestPayManyInvalid(t *testing.T) {
+	wallet := testWallet(t)
+
+	utxos := []UTXO{testUTXO(t, wallet, 30000, 0)}
+
+	for name, test := range map[string]struct {
+		payments []Payment
+		err      error
+	}{
+		"empty":     {nil, ErrNoPayments},
+		"duplicate": {[]Payment{{Address: testPayTo, Amount: 1000}, {Address: testPayTo, Amount: 2000}}, ErrDuplicateOutput},
+		"dust":      {[]Payment{{Address: testPayTo, Amount: 1000}, {Address: wallet.Address.EncodeAddress(), Amount: 545}}, ErrDustOutput},
+		"fee share": {[]Payment{{Address: testPayTo, Amount: 300, SubtractFee: true}}, ErrDustOutput},
+	} {
+		var buff bytes.Buffer
+
+		if err := wallet.PayMany(utxos, test.payments, 2, &buff); !errors.Is(err, test.err) {
+			t.Fatalf("%s: expect %v got %v", name, test.err, err)
+		}
+	}
+}
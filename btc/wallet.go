@@ -1,17 +1,24 @@
 package btc
 
 import (
+	"errors"
 	"io"
 
 	"fmt"
 
 	"github.com/btcsuite/btcd/btcec"
 	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/txscript"
 	"github.com/btcsuite/btcutil"
-	"github.com/btcsuite/btcutil/base58"
+	"github.com/goany/hdkey"
+	"github.com/goany/secure"
 	"github.com/goany/slf4go"
+	"github.com/goany/slip39"
 )
 
+// ErrWalletClosed wallet keys are wiped by Close
+var ErrWalletClosed = errors.New("wallet is closed")
+
 // NetType btc net type
 type NetType string
 
@@ -22,59 +29,203 @@ const (
 	NetTypeMainNet  NetType = "mainnet"
 )
 
+// AddressType btc wallet address type
+type AddressType string
+
+// btc address types
+const (
+	AddressTypeP2PKH      AddressType = "p2pkh"
+	AddressTypeP2WPKH     AddressType = "p2wpkh"
+	AddressTypeP2SHP2WPKH AddressType = "p2sh-p2wpkh"
+	AddressTypeP2TR       AddressType = "p2tr"
+	AddressTypeP2SH       AddressType = "p2sh"
+	AddressTypeP2WSH      AddressType = "p2wsh"
+)
+
 // Wallet BTC wallet
 type Wallet struct {
 	slf4go.Logger
-	privateKey *btcec.PrivateKey
-	publicKey  *btcec.PublicKey
-	Address    btcutil.Address
-	net        *chaincfg.Params
-	compressed bool
+	privateKey  *btcec.PrivateKey
+	publicKey   *btcec.PublicKey
+	Address     btcutil.Address
+	net         *chaincfg.Params
+	compressed  bool
+	addressType AddressType
+	legacyP2PKH bool
+	origin      *keyOrigin
 }
 
-// NewWallet create wallet from private key
-func NewWallet(privateKeyString string, chainname NetType) (*Wallet, error) {
+// keyOrigin bip32 origin of wallet key, the master key fingerprint and the
+// derivation path
+type keyOrigin struct {
+	fingerprint uint32
+	path        []uint32
+}
 
-	logger := slf4go.Get("BTCWallet")
+// WalletOption optional wallet setting
+type WalletOption func(wallet *Wallet)
 
-	compressed := false
+// WithAddressType set wallet address type, the default is AddressTypeP2PKH
+func WithAddressType(addressType AddressType) WalletOption {
+	return func(wallet *Wallet) {
+		wallet.addressType = addressType
+	}
+}
+
+// NewWallet create wallet from wif private key, p2pkh addresses are derived
+// from the uncompressed public key whatever the wif compression flag
+func NewWallet(privateKeyString string, chainname NetType, options ...WalletOption) (*Wallet, error) {
 
-	switch privateKeyString[0] {
-	case 'L', 'K', 'c':
-		compressed = true
+	wif, err := btcutil.DecodeWIF(privateKeyString)
+
+	if err != nil {
+		return nil, err
 	}
 
-	bytes, _, _ := base58.CheckDecode(privateKeyString)
-	priv, pub := btcec.PrivKeyFromBytes(btcec.S256(), bytes)
-	// bytes, err := hex.DecodeString(privateKeyString)
+	return newWallet(wif.PrivKey, wif.PrivKey.PubKey(), wif.CompressPubKey, AddressTypeP2PKH, chainname, append([]WalletOption{withLegacyP2PKH}, options...)...)
+}
+
+// withLegacyP2PKH keep the p2pkh address of wif wallets on the uncompressed
+// public key, as derived by earlier releases
+func withLegacyP2PKH(wallet *Wallet) {
+	wallet.legacyP2PKH = true
+}
+
+// WalletFromSeed create wallet from bip39 seed and bip32 derivation path, e.g. m/44'/0'/0'/0/0
+func WalletFromSeed(seed []byte, path string, chainname NetType, options ...WalletOption) (*Wallet, error) {
+
+	priv, origin, err := derivePrivateKey(seed, path)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return newWallet(priv, priv.PubKey(), true, AddressTypeP2PKH, chainname, append([]WalletOption{withKeyOrigin(origin)}, options...)...)
+}
+
+// withKeyOrigin set bip32 origin of seed derived wallet key
+func withKeyOrigin(origin *keyOrigin) WalletOption {
+	return func(wallet *Wallet) {
+		wallet.origin = origin
+	}
+}
+
+// derivePrivateKey derive private key of bip32 path from seed, the
+// intermediate keys are wiped
+func derivePrivateKey(seed []byte, path string) (*btcec.PrivateKey, *keyOrigin, error) {
+
+	indexes, err := hdkey.ParsePath(path)
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	master, err := hdkey.NewMasterKey(seed)
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	defer master.Wipe()
+
+	key, err := master.DerivePath(indexes)
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	defer key.Wipe()
+
+	priv, err := key.ECPrivKey()
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return priv, &keyOrigin{fingerprint: master.Fingerprint(), path: indexes}, nil
+}
+
+// WalletFromShares create wallet from slip39 mnemonic shares and passphrase,
+// the recovered master secret is used as bip32 seed
+func WalletFromShares(shares []string, passphrase string, path string, chainname NetType, options ...WalletOption) (*Wallet, error) {
+
+	seed, err := slip39.CombineMnemonics(shares, passphrase)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer secure.Wipe(seed)
+
+	return WalletFromSeed(seed, path, chainname, options...)
+}
 
-	// priv, pub := btcec.PrivKeyFromBytes(btcec.S256(), bytes)
+func newWallet(priv *btcec.PrivateKey, pub *btcec.PublicKey, compressed bool, addressType AddressType, chainname NetType, options ...WalletOption) (*Wallet, error) {
+
+	logger := slf4go.Get("BTCWallet")
 
 	wallet := &Wallet{
-		Logger:     logger,
-		privateKey: priv,
-		publicKey:  pub,
-		compressed: compressed,
+		Logger:      logger,
+		privateKey:  priv,
+		publicKey:   pub,
+		compressed:  compressed,
+		addressType: addressType,
 	}
 
-	switch chainname {
-	case NetTypeTestNet3:
-		wallet.net = &chaincfg.TestNet3Params
-	case NetTypeRegTest:
-		wallet.net = &chaincfg.RegressionNetParams
-	case NetTypeMainNet:
-		wallet.net = &chaincfg.MainNetParams
-	default:
-		return nil, fmt.Errorf("unknown btc net :%s", chainname)
+	for _, option := range options {
+		option(wallet)
 	}
 
-	address, err := btcutil.NewAddressPubKeyHash(
-		btcutil.Hash160(
-			wallet.publicKey.SerializeUncompressed(),
-		),
-		wallet.net,
+	if wallet.legacyP2PKH && wallet.addressType == AddressTypeP2PKH {
+		wallet.compressed = false
+	}
+
+	var (
+		address btcutil.Address
+		err     error
 	)
 
+	wallet.net, err = netParams(chainname)
+
+	if err != nil {
+		return nil, err
+	}
+
+	pubKeyHash := btcutil.Hash160(wallet.serializePublicKey())
+
+	switch wallet.addressType {
+	case AddressTypeP2PKH:
+		address, err = btcutil.NewAddressPubKeyHash(pubKeyHash, wallet.net)
+	case AddressTypeP2WPKH:
+		if !compressed {
+			return nil, fmt.Errorf("segwit address requires compressed public key")
+		}
+
+		address, err = btcutil.NewAddressWitnessPubKeyHash(pubKeyHash, wallet.net)
+	case AddressTypeP2SHP2WPKH:
+		if !compressed {
+			return nil, fmt.Errorf("segwit address requires compressed public key")
+		}
+
+		address, err = btcutil.NewAddressScriptHash(witnessRedeemScript(pubKeyHash), wallet.net)
+	case AddressTypeP2TR:
+		if !compressed {
+			return nil, fmt.Errorf("taproot address requires compressed public key")
+		}
+
+		var outputKey []byte
+
+		outputKey, err = taprootOutputKey(pub)
+
+		if err != nil {
+			return nil, err
+		}
+
+		address, err = NewAddressTaproot(outputKey, wallet.net)
+	default:
+		return nil, fmt.Errorf("unknown btc address type :%s", wallet.addressType)
+	}
+
 	if err != nil {
 		return nil, err
 	}
@@ -84,26 +235,98 @@ func NewWallet(privateKeyString string, chainname NetType) (*Wallet, error) {
 	return wallet, nil
 }
 
-// Pay pay btc to address
+// netParams chain params of net type
+func netParams(chainname NetType) (*chaincfg.Params, error) {
+	switch chainname {
+	case NetTypeTestNet3:
+		return &chaincfg.TestNet3Params, nil
+	case NetTypeRegTest:
+		return &chaincfg.RegressionNetParams, nil
+	case NetTypeMainNet:
+		return &chaincfg.MainNetParams, nil
+	}
+
+	return nil, fmt.Errorf("unknown btc net :%s", chainname)
+}
+
+// serializePublicKey public key bytes matching the compressed flag used for signing
+func (wallet *Wallet) serializePublicKey() []byte {
+	if wallet.compressed {
+		return wallet.publicKey.SerializeCompressed()
+	}
+
+	return wallet.publicKey.SerializeUncompressed()
+}
+
+// witnessRedeemScript p2wpkh program wrapped by p2sh-p2wpkh outputs: OP_0 <20-byte pubkey hash>
+func witnessRedeemScript(pubKeyHash []byte) []byte {
+	return append([]byte{txscript.OP_0, txscript.OP_DATA_20}, pubKeyHash...)
+}
+
+// Close wipe wallet private key, the wallet can not sign afterwards
+func (wallet *Wallet) Close() {
+	if wallet.privateKey != nil {
+		secure.WipePrivateKey(wallet.privateKey.ToECDSA())
+	}
+
+	wallet.privateKey = nil
+}
+
+// AddressType get wallet address type
+func (wallet *Wallet) AddressType() AddressType {
+	return wallet.addressType
+}
+
+// Payment batch payout output, SubtractFee outputs pay the transaction fee
+// in equal shares instead of the inputs
+type Payment struct {
+	Address     string         `json:"address"`
+	Amount      btcutil.Amount `json:"amount"`
+	SubtractFee bool           `json:"subtractFee"`
+}
+
+// Pay pay btc to address, every signed input is verified before the
+// transaction is written
 func (wallet *Wallet) Pay(
 	inputs []UTXO,
 	to string,
 	amount btcutil.Amount,
 	feeRate btcutil.Amount,
-	writer io.Writer) error {
+	writer io.Writer,
+	options ...PayOption) error {
+
+	return wallet.PayMany(inputs, []Payment{{Address: to, Amount: amount}}, feeRate, writer, options...)
+}
+
+// PayMany pay btc to every payment address in one transaction, coin
+// selection and change are done once for the batch
+func (wallet *Wallet) PayMany(
+	inputs []UTXO,
+	payments []Payment,
+	feeRate btcutil.Amount,
+	writer io.Writer,
+	options ...PayOption) error {
 
-	addr, err := btcutil.DecodeAddress(to, wallet.net)
+	if wallet.privateKey == nil {
+		return ErrWalletClosed
+	}
 
-	wallet.Debug("???????", addr.EncodeAddress())
+	trans := txFrom(inputs)
 
-	if err != nil {
-		return err
+	for _, payment := range payments {
+		addr, err := decodeAddress(payment.Address, wallet.net)
+
+		if err != nil {
+			return err
+		}
+
+		trans.pay(addr, payment.Amount, payment.SubtractFee)
 	}
 
-	tx, err := txFrom(inputs).
-		to(addr, amount).
+	tx, err := trans.
 		change(wallet.Address).
 		feeRate(feeRate).
+		options(options).
 		sign(wallet.privateKey, wallet.compressed).
 		done()
 
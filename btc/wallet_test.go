@@ -0,0 +1,98 @@
+package btc
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/btcsuite/btcd/btcec"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/btcsuite/btcutil"
+)
+
+func TestNewWallet(t *testing.T) {
+	priv, _ := btcec.PrivKeyFromBytes(btcec.S256(), bytes.Repeat([]byte{0x01}, 32))
+
+	for _, compressed := range []bool{true, false} {
+		wif, err := btcutil.NewWIF(priv, &chaincfg.MainNetParams, compressed)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		wallet, err := NewWallet(wif.String(), NetTypeMainNet)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		expect, err := btcutil.NewAddressPubKeyHash(btcutil.Hash160(priv.PubKey().SerializeUncompressed()), &chaincfg.MainNetParams)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if wallet.Address.EncodeAddress() != expect.EncodeAddress() {
+			t.Fatalf("expect %s got %s", expect.EncodeAddress(), wallet.Address.EncodeAddress())
+		}
+
+		utxos := []UTXO{testUTXO(t, wallet, 20000, 0), testUTXO(t, wallet, 20000, 1), testUTXO(t, wallet, 20000, 2)}
+
+		var buff bytes.Buffer
+
+		if err := wallet.Pay(utxos, testPayTo, 50000, 1, &buff); err != nil {
+			t.Fatal(err)
+		}
+
+		var tx wire.MsgTx
+
+		if err := tx.Deserialize(bytes.NewReader(buff.Bytes())); err != nil {
+			t.Fatal(err)
+		}
+
+		var out int64
+
+		for _, txout := range tx.TxOut {
+			out += txout.Value
+		}
+
+		// the uncompressed key pushed by every input is paid for
+		if fee := 60000 - out; fee < int64(tx.SerializeSize()) {
+			t.Fatalf("compressed %v: fee %d below size %d", compressed, fee, tx.SerializeSize())
+		}
+
+		segwit, err := NewWallet(wif.String(), NetTypeMainNet, WithAddressType(AddressTypeP2WPKH))
+
+		if compressed != (err == nil) {
+			t.Fatalf("compressed %v: unexpected segwit wallet error %v", compressed, err)
+		}
+
+		if compressed && segwit.serializePublicKey()[0] == 0x04 {
+			t.Fatal("expect compressed segwit public key")
+		}
+	}
+
+	if _, err := NewWallet("not a wif", NetTypeMainNet); err == nil {
+		t.Fatal("expect invalid wif error")
+	}
+}
+
+func TestWalletClose(t *testing.T) {
+	priv, _ := btcec.PrivKeyFromBytes(btcec.S256(), bytes.Repeat([]byte{0x02}, 32))
+
+	wallet, err := newWallet(priv, priv.PubKey(), true, AddressTypeP2PKH, NetTypeMainNet)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	wallet.Close()
+
+	if priv.D.Sign() != 0 {
+		t.Fatal("expect private key wiped")
+	}
+
+	if err := wallet.Pay(nil, wallet.Address.EncodeAddress(), 1000, 1, nil); err != ErrWalletClosed {
+		t.Fatalf("expect wallet closed got %v", err)
+	}
+}
@@ -0,0 +1,97 @@
+package eth
+
+import (
+	"fmt"
+
+	"github.com/goany/bip39"
+	"github.com/goany/hdkey"
+)
+
+// LegacyDerivationPath derivation path recorded in backups of legacy mode wallets
+const LegacyDerivationPath = "legacy"
+
+// ExportBackup encrypt wallet mnemonic, bip39 passphrase hint and derivation
+// path into bip39 backup, wallets not restored from mnemonic are exported in
+// legacy mode. The bip39 passphrase itself is never stored
+func (wallet *Wallet) ExportBackup(password string, passphraseHint string, kdf bip39.KDF) ([]byte, error) {
+	backup := &bip39.Backup{
+		PassphraseHint: passphraseHint,
+	}
+
+	if wallet.mnemonic != nil && wallet.mode == MnemonicModeBIP44 {
+		backup.Mnemonic = wallet.mnemonic.String()
+		backup.DerivationPath = fmt.Sprintf(BIP44Path, wallet.index)
+	} else {
+		mnemonic, err := wallet.Mnemonic()
+
+		if err != nil {
+			return nil, err
+		}
+
+		backup.Mnemonic = mnemonic
+		backup.DerivationPath = LegacyDerivationPath
+	}
+
+	wordlist, err := bip39.DetectWordlist(backup.Mnemonic)
+
+	if err != nil {
+		return nil, err
+	}
+
+	backup.Language = wordlist.Language()
+
+	return bip39.EncryptBackup(backup, password, kdf)
+}
+
+// WalletFromBackup restore wallet from bip39 backup, passphrase is the bip39
+// passphrase the backup hint refers to
+func WalletFromBackup(data []byte, password string, passphrase string) (*Wallet, error) {
+	backup, err := bip39.DecryptBackup(data, password)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if backup.DerivationPath == LegacyDerivationPath {
+		return WalletFromMnemonicWithPassphrase(backup.Mnemonic, passphrase, MnemonicModeLegacy, 0)
+	}
+
+	index, err := bip44Index(backup.DerivationPath)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return WalletFromMnemonicWithPassphrase(backup.Mnemonic, passphrase, MnemonicModeBIP44, index)
+}
+
+// bip44Index get account index of eth bip44 path, empty path is index 0
+func bip44Index(path string) (uint32, error) {
+	if path == "" {
+		return 0, nil
+	}
+
+	indexes, err := hdkey.ParsePath(path)
+
+	if err != nil {
+		return 0, err
+	}
+
+	prefix := []uint32{44, 60, 0, 0}
+
+	if len(indexes) != len(prefix)+1 || indexes[4] >= hdkey.HardenedKeyStart {
+		return 0, fmt.Errorf("unsupported eth derivation path :%s", path)
+	}
+
+	for i, index := range prefix {
+		if i < 3 {
+			index += hdkey.HardenedKeyStart
+		}
+
+		if indexes[i] != index {
+			return 0, fmt.Errorf("unsupported eth derivation path :%s", path)
+		}
+	}
+
+	return indexes[4], nil
+}
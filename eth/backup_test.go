@@ -0,0 +1,65 @@
+package eth
+
+import (
+	"testing"
+
+	"github.com/goany/bip39"
+)
+
+func TestWalletBackup(t *testing.T) {
+	wallet, err := WalletFromMnemonicWithPassphrase(testMnemonic, "secret", MnemonicModeBIP44, 1)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := wallet.ExportBackup("password", "favorite color", bip39.KDFArgon2id)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	backup, err := bip39.DecryptBackup(data, "password")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if backup.PassphraseHint != "favorite color" || backup.DerivationPath != "m/44'/60'/0'/0/1" {
+		t.Fatalf("unexpected backup metadata %v", backup)
+	}
+
+	restored, err := WalletFromBackup(data, "password", "secret")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if restored.Address() != wallet.Address() {
+		t.Fatalf("expect %s got %s", wallet.Address(), restored.Address())
+	}
+}
+
+func TestLegacyWalletBackup(t *testing.T) {
+	wallet, err := NewWallet()
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := wallet.ExportBackup("password", "", bip39.KDFScrypt)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	restored, err := WalletFromBackup(data, "password", "")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if restored.Address() != wallet.Address() {
+		t.Fatalf("expect %s got %s", wallet.Address(), restored.Address())
+	}
+}
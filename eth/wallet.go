@@ -3,9 +3,9 @@ package eth
 import (
 	"crypto/ecdsa"
 	"crypto/rand"
+	"errors"
 	"fmt"
 	"math/big"
-	"runtime"
 	"strings"
 
 	"github.com/ethereum/go-ethereum/accounts/keystore"
@@ -15,30 +15,76 @@ import (
 	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/ethereum/go-ethereum/rlp"
 	"github.com/goany/bip39"
+	"github.com/goany/hdkey"
+	"github.com/goany/secure"
 	"github.com/goany/slf4go"
+	"github.com/goany/slip39"
 )
 
 var logger = slf4go.Get("eth")
 
+// ErrWalletClosed wallet keys are wiped by Close
+var ErrWalletClosed = errors.New("wallet is closed")
+
 // Wallet .
 type Wallet struct {
 	slf4go.Logger
-	key *keystore.Key
+	key      *keystore.Key
+	mnemonic *WalletMnemonic // set when the wallet is restored from mnemonic
+	mode     MnemonicMode
+	index    uint32
 }
 
-// WalletFromMnemonic create wallet from mnemonic words
+// MnemonicMode mnemonic to private key conversion mode
+type MnemonicMode int
+
+// Mnemonic modes, the zero value is the default legacy mode
+const (
+	// MnemonicModeLegacy mnemonic entropy used as private key directly,
+	// the format exported by Wallet.Mnemonic
+	MnemonicModeLegacy MnemonicMode = iota
+	// MnemonicModeBIP44 standard bip39 seed with bip44 path m/44'/60'/0'/0/{index},
+	// compatible with MetaMask, Ledger and other bip39 wallets
+	MnemonicModeBIP44
+)
+
+// BIP44Path eth bip44 derivation path format, the last element is the account index
+const BIP44Path = "m/44'/60'/0'/0/%d"
+
+// WalletFromMnemonic create wallet from mnemonic words in legacy mode, the
+// format exported by Wallet.Mnemonic. Use WalletFromMnemonicWithMode and
+// MnemonicModeBIP44 for mnemonics of other bip39 wallets
 func WalletFromMnemonic(mnemonic string) (*Wallet, error) {
-	walletMnemonic := OpenWalletMnemonic(mnemonic)
+	return WalletFromMnemonicWithMode(mnemonic, MnemonicModeLegacy, 0)
+}
 
-	key, err := walletMnemonic.ToKey()
+// WalletFromMnemonicWithMode create wallet from mnemonic words with special mode,
+// index is the bip44 account index and is ignored in legacy mode
+func WalletFromMnemonicWithMode(mnemonic string, mode MnemonicMode, index uint32) (*Wallet, error) {
+	return walletFromMnemonic(OpenWalletMnemonic(mnemonic), mode, index)
+}
+
+// WalletFromMnemonicWithPassphrase create wallet from mnemonic words protected by
+// bip39 passphrase with special mode, an empty passphrase is the same as
+// WalletFromMnemonicWithMode. Legacy mode keys do not depend on a seed, so
+// only the empty passphrase is accepted in legacy mode
+func WalletFromMnemonicWithPassphrase(mnemonic string, passphrase string, mode MnemonicMode, index uint32) (*Wallet, error) {
+	return walletFromMnemonic(OpenWalletMnemonicWithPassphrase(mnemonic, passphrase), mode, index)
+}
+
+func walletFromMnemonic(walletMnemonic *WalletMnemonic, mode MnemonicMode, index uint32) (*Wallet, error) {
+	key, err := walletMnemonic.ToKeyWithMode(mode, index)
 
 	if err != nil {
 		return nil, err
 	}
 
 	return &Wallet{
-		Logger: slf4go.Get("wallet"),
-		key:    key,
+		Logger:   slf4go.Get("wallet"),
+		key:      key,
+		mnemonic: walletMnemonic,
+		mode:     mode,
+		index:    index,
 	}, nil
 }
 
@@ -61,13 +107,38 @@ func WalletFromPrivateKey(privatekey []byte) (*Wallet, error) {
 	}, nil
 }
 
+// WalletFromSeed create wallet from bip39 seed and bip32 derivation path, e.g. m/44'/60'/0'/0/0
+func WalletFromSeed(seed []byte, path string) (*Wallet, error) {
+	privateKey, err := derivePrivateKey(seed, path)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer privateKey.Wipe()
+
+	return WalletFromPrivateKey(privateKey.Bytes())
+}
+
+// WalletFromShares create wallet from slip39 mnemonic shares and passphrase
+// with bip44 account index, the recovered master secret is used as bip32 seed
+func WalletFromShares(shares []string, passphrase string, index uint32) (*Wallet, error) {
+	seed, err := slip39.CombineMnemonics(shares, passphrase)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer secure.Wipe(seed)
+
+	return WalletFromSeed(seed, fmt.Sprintf(BIP44Path, index))
+}
+
 // OpenWallet .
 func OpenWallet(wallet []byte, password string) (*Wallet, error) {
 
 	key, err := keystore.DecryptKey(wallet, password)
 
-	runtime.GC()
-
 	if err != nil {
 		return nil, err
 	}
@@ -95,13 +166,39 @@ func NewWallet() (*Wallet, error) {
 	}, err
 }
 
+// Close wipe wallet private key and drop the mnemonic, the wallet can not
+// sign or export keys afterwards
+func (wallet *Wallet) Close() {
+	secure.WipePrivateKey(wallet.key.PrivateKey)
+
+	wallet.key.PrivateKey = nil
+	wallet.mnemonic = nil
+}
+
+func (wallet *Wallet) closed() bool {
+	return wallet.key.PrivateKey == nil
+}
+
 // Encrypt encrypt wallet into json format
 func (wallet *Wallet) Encrypt(password string) ([]byte, error) {
+	if wallet.closed() {
+		return nil, ErrWalletClosed
+	}
+
 	return keystore.EncryptKey(wallet.key, password, keystore.LightScryptN, keystore.LightScryptP)
 }
 
-// Mnemonic .
+// Mnemonic get wallet's mnemonic words, returns the original words for wallets
+// restored from mnemonic, otherwise the key is exported in legacy mode
 func (wallet *Wallet) Mnemonic() (string, error) {
+	if wallet.closed() {
+		return "", ErrWalletClosed
+	}
+
+	if wallet.mnemonic != nil {
+		return wallet.mnemonic.String(), nil
+	}
+
 	mnemonic, err := NewWalletMnemonic(wallet.key)
 
 	if err != nil {
@@ -119,6 +216,10 @@ func (wallet *Wallet) TransferCurrency(
 	to string,
 	amount string) ([]byte, error) {
 
+	if wallet.closed() {
+		return nil, ErrWalletClosed
+	}
+
 	logger.DebugF("try get nonce from server ..")
 
 	var count hexutil.Big
@@ -168,6 +269,10 @@ func (wallet *Wallet) TransferToken(
 	contract string,
 	data []byte) ([]byte, error) {
 
+	if wallet.closed() {
+		return nil, ErrWalletClosed
+	}
+
 	var bytes hexutil.Bytes
 
 	bytes.UnmarshalText(data)
@@ -198,7 +303,8 @@ func (wallet *Wallet) TransferToken(
 
 // WalletMnemonic .
 type WalletMnemonic struct {
-	mnemonic string
+	mnemonic   string
+	passphrase string
 }
 
 // OpenWalletMnemonic .
@@ -208,11 +314,21 @@ func OpenWalletMnemonic(mnemonic string) *WalletMnemonic {
 	}
 }
 
-// NewWalletMnemonic .
+// OpenWalletMnemonicWithPassphrase open mnemonic protected by bip39 passphrase
+func OpenWalletMnemonicWithPassphrase(mnemonic string, passphrase string) *WalletMnemonic {
+	return &WalletMnemonic{
+		mnemonic:   mnemonic,
+		passphrase: passphrase,
+	}
+}
+
+// NewWalletMnemonic export key as legacy mode mnemonic
 func NewWalletMnemonic(key *keystore.Key) (*WalletMnemonic, error) {
 
 	privateKey := crypto.FromECDSA(key.PrivateKey)
 
+	defer secure.Wipe(privateKey)
+
 	mnemonic, err := bip39.NewMnemonic(privateKey)
 
 	return &WalletMnemonic{
@@ -220,18 +336,35 @@ func NewWalletMnemonic(key *keystore.Key) (*WalletMnemonic, error) {
 	}, err
 }
 
-// ToKey convert mnemonic to keystore.Key
+// ToKey convert mnemonic to keystore.Key in legacy mode
 func (wm *WalletMnemonic) ToKey() (*keystore.Key, error) {
+	return wm.ToKeyWithMode(MnemonicModeLegacy, 0)
+}
 
-	privateKey, err := bip39.MnemonicToByteArray(wm.mnemonic)
+// ToKeyWithMode convert mnemonic to keystore.Key with special mode,
+// index is the bip44 account index and is ignored in legacy mode
+func (wm *WalletMnemonic) ToKeyWithMode(mode MnemonicMode, index uint32) (*keystore.Key, error) {
+	var (
+		privateKey *secure.SecretBytes
+		err        error
+	)
+
+	switch mode {
+	case MnemonicModeBIP44:
+		privateKey, err = wm.bip44PrivateKey(index)
+	case MnemonicModeLegacy:
+		privateKey, err = wm.legacyPrivateKey()
+	default:
+		return nil, fmt.Errorf("unknown mnemonic mode :%d", mode)
+	}
 
 	if err != nil {
 		return nil, err
 	}
 
-	privateKey = privateKey[1 : len(privateKey)-1]
+	defer privateKey.Wipe()
 
-	privateKeyECDSA, err := crypto.ToECDSA(privateKey)
+	privateKeyECDSA, err := crypto.ToECDSA(privateKey.Bytes())
 
 	if err != nil {
 		return nil, err
@@ -245,6 +378,60 @@ func (wm *WalletMnemonic) ToKey() (*keystore.Key, error) {
 	return key, err
 }
 
+func (wm *WalletMnemonic) bip44PrivateKey(index uint32) (*secure.SecretBytes, error) {
+	seed, err := bip39.NewSecretSeed(wm.mnemonic, wm.passphrase)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer seed.Wipe()
+
+	return derivePrivateKey(seed.Bytes(), fmt.Sprintf(BIP44Path, index))
+}
+
+func (wm *WalletMnemonic) legacyPrivateKey() (*secure.SecretBytes, error) {
+	if wm.passphrase != "" {
+		return nil, fmt.Errorf("passphrase is not supported in legacy mnemonic mode")
+	}
+
+	entropy, err := bip39.EntropyFromMnemonic(wm.mnemonic)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return secure.SecretBytesFrom(entropy), nil
+}
+
+// derivePrivateKey derive private key of bip32 path from seed, the
+// intermediate keys are wiped
+func derivePrivateKey(seed []byte, path string) (*secure.SecretBytes, error) {
+	master, err := hdkey.NewMasterKey(seed)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer master.Wipe()
+
+	key, err := master.Derive(path)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer key.Wipe()
+
+	privateKey, err := key.PrivateKeyBytes()
+
+	if err != nil {
+		return nil, err
+	}
+
+	return secure.SecretBytesFrom(privateKey), nil
+}
+
 func (wm *WalletMnemonic) String() string {
 	return wm.mnemonic
 }
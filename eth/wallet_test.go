@@ -0,0 +1,153 @@
+package eth
+
+import (
+	"strings"
+	"testing"
+)
+
+const testMnemonic = "abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about"
+
+func TestWalletFromMnemonicBIP44(t *testing.T) {
+	expect := []string{
+		"0x9858EfFD232B4033E47d90003D41EC34EcaEda94",
+		"0x6Fac4D18c912343BF86fa7049364Dd4E424Ab9C0",
+	}
+
+	for i, address := range expect {
+		wallet, err := WalletFromMnemonicWithMode(testMnemonic, MnemonicModeBIP44, uint32(i))
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !strings.EqualFold(wallet.Address(), address) {
+			t.Fatalf("index %d expect %s got %s", i, address, wallet.Address())
+		}
+	}
+}
+
+func TestWalletFromMnemonicLegacy(t *testing.T) {
+	wallet, err := NewWallet()
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mnemonic, err := wallet.Mnemonic()
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	restored, err := WalletFromMnemonic(mnemonic)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if restored.Address() != wallet.Address() {
+		t.Fatalf("expect %s got %s", wallet.Address(), restored.Address())
+	}
+
+	key, err := OpenWalletMnemonic(mnemonic).ToKey()
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if key.Address.Hex() != wallet.Address() {
+		t.Fatalf("expect %s got %s", wallet.Address(), key.Address.Hex())
+	}
+}
+
+func TestWalletFromMnemonicWithPassphrase(t *testing.T) {
+	legacy, err := WalletFromMnemonic(testMnemonic)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	empty, err := WalletFromMnemonicWithPassphrase(testMnemonic, "", MnemonicModeLegacy, 0)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if empty.Address() != legacy.Address() {
+		t.Fatalf("empty legacy passphrase expect %s got %s", legacy.Address(), empty.Address())
+	}
+
+	wallet, err := WalletFromMnemonicWithMode(testMnemonic, MnemonicModeBIP44, 1)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	empty, err = WalletFromMnemonicWithPassphrase(testMnemonic, "", MnemonicModeBIP44, 1)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if empty.Address() != wallet.Address() {
+		t.Fatalf("empty passphrase expect %s got %s", wallet.Address(), empty.Address())
+	}
+
+	protected, err := WalletFromMnemonicWithPassphrase(testMnemonic, "TREZOR", MnemonicModeBIP44, 1)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if protected.Address() == wallet.Address() {
+		t.Fatal("passphrase must change the derived address")
+	}
+
+	if _, err := WalletFromMnemonicWithPassphrase(testMnemonic, "TREZOR", MnemonicModeLegacy, 0); err == nil {
+		t.Fatal("expect legacy mode to reject passphrase")
+	}
+}
+
+func TestMnemonicModeZeroValue(t *testing.T) {
+	var mode MnemonicMode
+
+	wallet, err := WalletFromMnemonicWithMode(testMnemonic, mode, 0)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	legacy, err := WalletFromMnemonic(testMnemonic)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if wallet.Address() != legacy.Address() {
+		t.Fatalf("expect zero mode to be legacy %s got %s", legacy.Address(), wallet.Address())
+	}
+}
+
+func TestWalletClose(t *testing.T) {
+	wallet, err := WalletFromMnemonic(testMnemonic)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	key := wallet.key.PrivateKey
+
+	wallet.Close()
+
+	if key.D.Sign() != 0 {
+		t.Fatal("expect private key wiped")
+	}
+
+	if _, err := wallet.Mnemonic(); err != ErrWalletClosed {
+		t.Fatalf("expect wallet closed got %v", err)
+	}
+
+	if _, err := wallet.Encrypt("password"); err != ErrWalletClosed {
+		t.Fatalf("expect wallet closed got %v", err)
+	}
+}
@@ -0,0 +1,462 @@
+package hdkey
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha512"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/btcsuite/btcd/btcec"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcutil"
+	"github.com/btcsuite/btcutil/base58"
+	"github.com/goany/secure"
+)
+
+// HardenedKeyStart first hardened child index, 2^31
+const HardenedKeyStart uint32 = 0x80000000
+
+// BIP32 seed length bounds in bytes
+const (
+	MinSeedBytes = 16
+	MaxSeedBytes = 64
+)
+
+// serializedKeyLen version(4) || depth(1) || fingerprint(4) || child(4) || chaincode(32) || key(33)
+const serializedKeyLen = 4 + 1 + 4 + 4 + 32 + 33
+
+// Extended key version bytes
+var (
+	MainNetPrivate = []byte{0x04, 0x88, 0xad, 0xe4} // xprv
+	MainNetPublic  = []byte{0x04, 0x88, 0xb2, 0x1e} // xpub
+	TestNetPrivate = []byte{0x04, 0x35, 0x83, 0x94} // tprv
+	TestNetPublic  = []byte{0x04, 0x35, 0x87, 0xcf} // tpub
+)
+
+// hdkey errors
+var (
+	ErrInvalidSeedLen       = fmt.Errorf("seed length must be between %d and %d bytes", MinSeedBytes, MaxSeedBytes)
+	ErrUnusableSeed         = errors.New("unusable seed, derived master key is invalid")
+	ErrInvalidChild         = errors.New("derived child key is invalid, use next index")
+	ErrDeriveHardFromPublic = errors.New("cannot derive hardened child from public key")
+	ErrNotPrivate           = errors.New("extended key is not a private key")
+	ErrInvalidKeyLen        = errors.New("serialized extended key has invalid length")
+	ErrBadChecksum          = errors.New("serialized extended key has bad checksum")
+	ErrUnknownVersion       = errors.New("serialized extended key has unknown version")
+	ErrDepthExceeded        = errors.New("cannot derive beyond max depth 255")
+)
+
+var masterKey = []byte("Bitcoin seed")
+
+// Key BIP32 extended key, private or public
+type Key struct {
+	version   []byte
+	depth     uint8
+	parentFP  []byte
+	childNum  uint32
+	chainCode []byte
+	key       []byte // 32 bytes private key or 33 bytes compressed public key
+	private   bool
+}
+
+// NewMasterKey create mainnet master key from seed
+func NewMasterKey(seed []byte) (*Key, error) {
+	return NewMasterKeyWithVersion(seed, MainNetPrivate)
+}
+
+// NewMasterKeyWithVersion create master key from seed with special private version bytes
+func NewMasterKeyWithVersion(seed []byte, version []byte) (*Key, error) {
+	if len(seed) < MinSeedBytes || len(seed) > MaxSeedBytes {
+		return nil, ErrInvalidSeedLen
+	}
+
+	if _, ok := publicVersion(version); !ok {
+		return nil, ErrUnknownVersion
+	}
+
+	mac := hmac.New(sha512.New, masterKey)
+	mac.Write(seed)
+	sum := mac.Sum(nil)
+
+	secret := sum[:32]
+
+	if !validPrivateKey(secret) {
+		return nil, ErrUnusableSeed
+	}
+
+	return &Key{
+		version:   version,
+		parentFP:  []byte{0, 0, 0, 0},
+		chainCode: sum[32:],
+		key:       secret,
+		private:   true,
+	}, nil
+}
+
+// NewPrivateMasterKey create master key from raw chain code and private key
+func NewPrivateMasterKey(chainCode []byte, key []byte, version []byte) (*Key, error) {
+	if len(chainCode) != 32 || len(key) != 32 {
+		return nil, ErrInvalidKeyLen
+	}
+
+	if _, ok := publicVersion(version); !ok {
+		return nil, ErrUnknownVersion
+	}
+
+	if !validPrivateKey(key) {
+		return nil, ErrUnusableSeed
+	}
+
+	return &Key{
+		version:   version,
+		parentFP:  []byte{0, 0, 0, 0},
+		chainCode: append([]byte(nil), chainCode...),
+		key:       append([]byte(nil), key...),
+		private:   true,
+	}, nil
+}
+
+// IsPrivate check if key is a private extended key
+func (k *Key) IsPrivate() bool {
+	return k.private
+}
+
+// Depth key depth, master key is 0
+func (k *Key) Depth() uint8 {
+	return k.depth
+}
+
+// ChildIndex index of this key in its parent
+func (k *Key) ChildIndex() uint32 {
+	return k.childNum
+}
+
+// ParentFingerprint parent key fingerprint, 0 for master key
+func (k *Key) ParentFingerprint() uint32 {
+	return binary.BigEndian.Uint32(k.parentFP)
+}
+
+// Fingerprint first 4 bytes of hash160 of the compressed public key
+func (k *Key) Fingerprint() uint32 {
+	return binary.BigEndian.Uint32(btcutil.Hash160(k.PublicKeyBytes())[:4])
+}
+
+// ChainCode key chain code
+func (k *Key) ChainCode() []byte {
+	return append([]byte(nil), k.chainCode...)
+}
+
+// PublicKeyBytes compressed public key bytes
+func (k *Key) PublicKeyBytes() []byte {
+	if !k.private {
+		return append([]byte(nil), k.key...)
+	}
+
+	_, pub := btcec.PrivKeyFromBytes(btcec.S256(), k.key)
+
+	return pub.SerializeCompressed()
+}
+
+// PrivateKeyBytes 32 bytes private key
+func (k *Key) PrivateKeyBytes() ([]byte, error) {
+	if !k.private {
+		return nil, ErrNotPrivate
+	}
+
+	return append([]byte(nil), k.key...), nil
+}
+
+// ECPrivKey convert to btcec private key
+func (k *Key) ECPrivKey() (*btcec.PrivateKey, error) {
+	if !k.private {
+		return nil, ErrNotPrivate
+	}
+
+	priv, _ := btcec.PrivKeyFromBytes(btcec.S256(), k.key)
+
+	return priv, nil
+}
+
+// ECPubKey convert to btcec public key
+func (k *Key) ECPubKey() (*btcec.PublicKey, error) {
+	return btcec.ParsePubKey(k.PublicKeyBytes(), btcec.S256())
+}
+
+// Child derive child key with index i, index >= HardenedKeyStart derive hardened child.
+// ErrInvalidChild is returned in the rare case the derived key is invalid,
+// the caller should then proceed with the next index
+func (k *Key) Child(i uint32) (*Key, error) {
+	if k.depth == 255 {
+		return nil, ErrDepthExceeded
+	}
+
+	hardened := i >= HardenedKeyStart
+
+	if hardened && !k.private {
+		return nil, ErrDeriveHardFromPublic
+	}
+
+	data := make([]byte, 0, 37)
+
+	if hardened {
+		data = append(data, 0x00)
+		data = append(data, k.key...)
+	} else {
+		data = append(data, k.PublicKeyBytes()...)
+	}
+
+	var index [4]byte
+	binary.BigEndian.PutUint32(index[:], i)
+	data = append(data, index[:]...)
+
+	mac := hmac.New(sha512.New, k.chainCode)
+	mac.Write(data)
+	sum := mac.Sum(nil)
+
+	secure.Wipe(data)
+
+	il := sum[:32]
+	chainCode := sum[32:]
+
+	ilNum := new(big.Int).SetBytes(il)
+
+	defer secure.Wipe(il)
+	defer secure.WipeBigInt(ilNum)
+
+	curve := btcec.S256()
+
+	if ilNum.Cmp(curve.N) >= 0 {
+		return nil, ErrInvalidChild
+	}
+
+	var childKey []byte
+
+	if k.private {
+		keyNum := new(big.Int).SetBytes(k.key)
+		keyNum.Add(keyNum, ilNum)
+		keyNum.Mod(keyNum, curve.N)
+
+		if keyNum.Sign() == 0 {
+			return nil, ErrInvalidChild
+		}
+
+		childKey = paddedBytes(keyNum, 32)
+
+		secure.WipeBigInt(keyNum)
+	} else {
+		if ilNum.Sign() == 0 {
+			return nil, ErrInvalidChild
+		}
+
+		pub, err := btcec.ParsePubKey(k.key, curve)
+
+		if err != nil {
+			return nil, err
+		}
+
+		ilx, ily := curve.ScalarBaseMult(il)
+		x, y := curve.Add(ilx, ily, pub.X, pub.Y)
+
+		if x.Sign() == 0 && y.Sign() == 0 {
+			return nil, ErrInvalidChild
+		}
+
+		childKey = (&btcec.PublicKey{Curve: curve, X: x, Y: y}).SerializeCompressed()
+	}
+
+	return &Key{
+		version:   k.version,
+		depth:     k.depth + 1,
+		parentFP:  btcutil.Hash160(k.PublicKeyBytes())[:4],
+		childNum:  i,
+		chainCode: chainCode,
+		key:       childKey,
+		private:   k.private,
+	}, nil
+}
+
+// Neuter get the public extended key of this key
+func (k *Key) Neuter() (*Key, error) {
+	if !k.private {
+		return k, nil
+	}
+
+	version, ok := publicVersion(k.version)
+
+	if !ok {
+		return nil, ErrUnknownVersion
+	}
+
+	return &Key{
+		version:   version,
+		depth:     k.depth,
+		parentFP:  k.parentFP,
+		childNum:  k.childNum,
+		chainCode: append([]byte(nil), k.chainCode...),
+		key:       k.PublicKeyBytes(),
+		private:   false,
+	}, nil
+}
+
+// Derive derive descendant key by path, e.g. m/44'/60'/0'/0/5
+func (k *Key) Derive(path string) (*Key, error) {
+	indexes, err := ParsePath(path)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return k.DerivePath(indexes)
+}
+
+// DerivePath derive descendant key by child index list, intermediate keys are wiped
+func (k *Key) DerivePath(indexes []uint32) (*Key, error) {
+	key := k
+
+	for _, i := range indexes {
+		child, err := key.Child(i)
+
+		if key != k {
+			key.Wipe()
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		key = child
+	}
+
+	return key, nil
+}
+
+// Wipe zero private key and chain code, the key can not be used afterwards
+func (k *Key) Wipe() {
+	if k.private {
+		secure.Wipe(k.key)
+	}
+
+	secure.Wipe(k.chainCode)
+}
+
+// Serialize serialize key into 78 bytes BIP32 format without checksum
+func (k *Key) Serialize() []byte {
+	buff := make([]byte, 0, serializedKeyLen)
+
+	buff = append(buff, k.version...)
+	buff = append(buff, k.depth)
+	buff = append(buff, k.parentFP...)
+
+	var index [4]byte
+	binary.BigEndian.PutUint32(index[:], k.childNum)
+	buff = append(buff, index[:]...)
+
+	buff = append(buff, k.chainCode...)
+
+	if k.private {
+		buff = append(buff, 0x00)
+	}
+
+	buff = append(buff, k.key...)
+
+	return buff
+}
+
+// String base58 check encoded key, e.g. xprv... or xpub...
+func (k *Key) String() string {
+	data := k.Serialize()
+
+	return base58.Encode(append(data, checksum(data)...))
+}
+
+// ParseKey parse base58 encoded extended key
+func ParseKey(key string) (*Key, error) {
+	decoded := base58.Decode(key)
+
+	if len(decoded) != serializedKeyLen+4 {
+		return nil, ErrInvalidKeyLen
+	}
+
+	payload := decoded[:serializedKeyLen]
+
+	if !bytes.Equal(checksum(payload), decoded[serializedKeyLen:]) {
+		return nil, ErrBadChecksum
+	}
+
+	version := payload[:4]
+
+	_, isPrivate := publicVersion(version)
+
+	if !isPrivate && !isPublicVersion(version) {
+		return nil, ErrUnknownVersion
+	}
+
+	k := &Key{
+		version:   append([]byte(nil), version...),
+		depth:     payload[4],
+		parentFP:  append([]byte(nil), payload[5:9]...),
+		childNum:  binary.BigEndian.Uint32(payload[9:13]),
+		chainCode: append([]byte(nil), payload[13:45]...),
+		private:   isPrivate,
+	}
+
+	if k.depth == 0 && (k.childNum != 0 || k.ParentFingerprint() != 0) {
+		return nil, fmt.Errorf("master key with non-zero parent fingerprint or index")
+	}
+
+	keyData := payload[45:]
+
+	if isPrivate {
+		if keyData[0] != 0x00 || !validPrivateKey(keyData[1:]) {
+			return nil, fmt.Errorf("invalid extended private key data")
+		}
+
+		k.key = append([]byte(nil), keyData[1:]...)
+	} else {
+		if _, err := btcec.ParsePubKey(keyData, btcec.S256()); err != nil {
+			return nil, fmt.Errorf("invalid extended public key data: %s", err)
+		}
+
+		k.key = append([]byte(nil), keyData...)
+	}
+
+	return k, nil
+}
+
+func publicVersion(version []byte) ([]byte, bool) {
+	switch {
+	case bytes.Equal(version, MainNetPrivate):
+		return MainNetPublic, true
+	case bytes.Equal(version, TestNetPrivate):
+		return TestNetPublic, true
+	}
+
+	return nil, false
+}
+
+func isPublicVersion(version []byte) bool {
+	return bytes.Equal(version, MainNetPublic) || bytes.Equal(version, TestNetPublic)
+}
+
+func validPrivateKey(key []byte) bool {
+	num := new(big.Int).SetBytes(key)
+
+	return num.Sign() != 0 && num.Cmp(btcec.S256().N) < 0
+}
+
+func checksum(data []byte) []byte {
+	return chainhash.DoubleHashB(data)[:4]
+}
+
+func paddedBytes(num *big.Int, size int) []byte {
+	buff := num.Bytes()
+
+	if len(buff) >= size {
+		return buff
+	}
+
+	return append(make([]byte, size-len(buff)), buff...)
+}
@@ -0,0 +1,206 @@
+package hdkey
+
+import (
+	"encoding/hex"
+	"testing"
+)
+
+type testVector struct {
+	path string
+	xpub string
+	xprv string
+}
+
+var testSeeds = []struct {
+	seed    string
+	vectors []testVector
+}{
+	{
+		seed: "000102030405060708090a0b0c0d0e0f",
+		vectors: []testVector{
+			{
+				"m",
+				"xpub661MyMwAqRbcFtXgS5sYJABqqG9YLmC4Q1Rdap9gSE8NqtwybGhePY2gZ29ESFjqJoCu1Rupje8YtGqsefD265TMg7usUDFdp6W1EGMcet8",
+				"xprv9s21ZrQH143K3QTDL4LXw2F7HEK3wJUD2nW2nRk4stbPy6cq3jPPqjiChkVvvNKmPGJxWUtg6LnF5kejMRNNU3TGtRBeJgk33yuGBxrMPHi",
+			},
+			{
+				"m/0H",
+				"xpub68Gmy5EdvgibQVfPdqkBBCHxA5htiqg55crXYuXoQRKfDBFA1WEjWgP6LHhwBZeNK1VTsfTFUHCdrfp1bgwQ9xv5ski8PX9rL2dZXvgGDnw",
+				"xprv9uHRZZhk6KAJC1avXpDAp4MDc3sQKNxDiPvvkX8Br5ngLNv1TxvUxt4cV1rGL5hj6KCesnDYUhd7oWgT11eZG7XnxHrnYeSvkzY7d2bhkJ7",
+			},
+			{
+				"m/0H/1",
+				"xpub6ASuArnXKPbfEwhqN6e3mwBcDTgzisQN1wXN9BJcM47sSikHjJf3UFHKkNAWbWMiGj7Wf5uMash7SyYq527Hqck2AxYysAA7xmALppuCkwQ",
+				"xprv9wTYmMFdV23N2TdNG573QoEsfRrWKQgWeibmLntzniatZvR9BmLnvSxqu53Kw1UmYPxLgboyZQaXwTCg8MSY3H2EU4pWcQDnRnrVA1xe8fs",
+			},
+			{
+				"m/0H/1/2H",
+				"xpub6D4BDPcP2GT577Vvch3R8wDkScZWzQzMMUm3PWbmWvVJrZwQY4VUNgqFJPMM3No2dFDFGTsxxpG5uJh7n7epu4trkrX7x7DogT5Uv6fcLW5",
+				"xprv9z4pot5VBttmtdRTWfWQmoH1taj2axGVzFqSb8C9xaxKymcFzXBDptWmT7FwuEzG3ryjH4ktypQSAewRiNMjANTtpgP4mLTj34bhnZX7UiM",
+			},
+			{
+				"m/0H/1/2H/2",
+				"xpub6FHa3pjLCk84BayeJxFW2SP4XRrFd1JYnxeLeU8EqN3vDfZmbqBqaGJAyiLjTAwm6ZLRQUMv1ZACTj37sR62cfN7fe5JnJ7dh8zL4fiyLHV",
+				"xprvA2JDeKCSNNZky6uBCviVfJSKyQ1mDYahRjijr5idH2WwLsEd4Hsb2Tyh8RfQMuPh7f7RtyzTtdrbdqqsunu5Mm3wDvUAKRHSC34sJ7in334",
+			},
+			{
+				"m/0H/1/2H/2/1000000000",
+				"xpub6H1LXWLaKsWFhvm6RVpEL9P4KfRZSW7abD2ttkWP3SSQvnyA8FSVqNTEcYFgJS2UaFcxupHiYkro49S8yGasTvXEYBVPamhGW6cFJodrTHy",
+				"xprvA41z7zogVVwxVSgdKUHDy1SKmdb533PjDz7J6N6mV6uS3ze1ai8FHa8kmHScGpWmj4WggLyQjgPie1rFSruoUihUZREPSL39UNdE3BBDu76",
+			},
+		},
+	},
+	{
+		seed: "fffcf9f6f3f0edeae7e4e1dedbd8d5d2cfccc9c6c3c0bdbab7b4b1aeaba8a5a29f9c999693908d8a8784817e7b7875726f6c696663605d5a5754514e4b484542",
+		vectors: []testVector{
+			{
+				"m",
+				"xpub661MyMwAqRbcFW31YEwpkMuc5THy2PSt5bDMsktWQcFF8syAmRUapSCGu8ED9W6oDMSgv6Zz8idoc4a6mr8BDzTJY47LJhkJ8UB7WEGuduB",
+				"xprv9s21ZrQH143K31xYSDQpPDxsXRTUcvj2iNHm5NUtrGiGG5e2DtALGdso3pGz6ssrdK4PFmM8NSpSBHNqPqm55Qn3LqFtT2emdEXVYsCzC2U",
+			},
+			{
+				"m/0",
+				"xpub69H7F5d8KSRgmmdJg2KhpAK8SR3DjMwAdkxj3ZuxV27CprR9LgpeyGmXUbC6wb7ERfvrnKZjXoUmmDznezpbZb7ap6r1D3tgFxHmwMkQTPH",
+				"xprv9vHkqa6EV4sPZHYqZznhT2NPtPCjKuDKGY38FBWLvgaDx45zo9WQRUT3dKYnjwih2yJD9mkrocEZXo1ex8G81dwSM1fwqWpWkeS3v86pgKt",
+			},
+		},
+	},
+	{
+		// leading zeros of private key must be retained
+		seed: "4b381541583be4423346c643850da4b320e46a87ae3d2a4e6da11eba819cd4acba45d239319ac14f863b8d5ab5a0d0c64d2e8a1e7d1457df2e5a3c51c73235be",
+		vectors: []testVector{
+			{
+				"m/0H",
+				"xpub68NZiKmJWnxxS6aaHmn81bvJeTESw724CRDs6HbuccFQN9Ku14VQrADWgqbhhTHBaohPX4CjNLf9fq9MYo6oDaPPLPxSb7gwQN3ih19Zm4Y",
+				"xprv9uPDJpEQgRQfDcW7BkF7eTya6RPxXeJCqCJGHuCJ4GiRVLzkTXBAJMu2qaMWPrS7AANYqdq6vcBcBUdJCVVFceUvJFjaPdGZ2y9WACViL4L",
+			},
+		},
+	},
+}
+
+func TestVectors(t *testing.T) {
+	for _, s := range testSeeds {
+		seed, err := hex.DecodeString(s.seed)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		master, err := NewMasterKey(seed)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		for _, v := range s.vectors {
+			key, err := master.Derive(v.path)
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if key.String() != v.xprv {
+				t.Fatalf("%s xprv expect %s got %s", v.path, v.xprv, key.String())
+			}
+
+			pub, err := key.Neuter()
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if pub.String() != v.xpub {
+				t.Fatalf("%s xpub expect %s got %s", v.path, v.xpub, pub.String())
+			}
+		}
+	}
+}
+
+func TestPublicDerivation(t *testing.T) {
+	seed, _ := hex.DecodeString(testSeeds[0].seed)
+
+	master, err := NewMasterKey(seed)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	parent, err := master.Derive("m/0H/1/2H")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	xpub, err := parent.Neuter()
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	child, err := xpub.Derive("2/1000000000")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if child.String() != testSeeds[0].vectors[5].xpub {
+		t.Fatalf("expect %s got %s", testSeeds[0].vectors[5].xpub, child.String())
+	}
+
+	if _, err := xpub.Child(HardenedKeyStart); err != ErrDeriveHardFromPublic {
+		t.Fatalf("expect ErrDeriveHardFromPublic got %v", err)
+	}
+}
+
+func TestParseKey(t *testing.T) {
+	for _, v := range testSeeds[0].vectors {
+		for _, encoded := range []string{v.xprv, v.xpub} {
+			key, err := ParseKey(encoded)
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if key.String() != encoded {
+				t.Fatalf("expect %s got %s", encoded, key.String())
+			}
+		}
+	}
+
+	bad := []byte(testSeeds[0].vectors[0].xprv)
+	bad[len(bad)-1] = 'j'
+
+	if _, err := ParseKey(string(bad)); err != ErrBadChecksum {
+		t.Fatalf("expect ErrBadChecksum got %v", err)
+	}
+}
+
+func TestParsePath(t *testing.T) {
+	indexes, err := ParsePath("m/44'/60'/0'/0/5")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expect := []uint32{HardenedKeyStart + 44, HardenedKeyStart + 60, HardenedKeyStart, 0, 5}
+
+	if len(indexes) != len(expect) {
+		t.Fatalf("expect %v got %v", expect, indexes)
+	}
+
+	for i := range expect {
+		if indexes[i] != expect[i] {
+			t.Fatalf("expect %v got %v", expect, indexes)
+		}
+	}
+
+	if FormatPath(indexes) != "m/44'/60'/0'/0/5" {
+		t.Fatalf("format path mismatch: %s", FormatPath(indexes))
+	}
+
+	for _, path := range []string{"", "m/", "m/x", "m/1//2", "m/2147483648"} {
+		if _, err := ParsePath(path); err == nil {
+			t.Fatalf("expect error for path %q", path)
+		}
+	}
+}
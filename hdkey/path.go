@@ -0,0 +1,62 @@
+package hdkey
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ParsePath parse derivation path like m/44'/60'/0'/0/5 into child index list,
+// hardened index can be marked with ', h or H
+func ParsePath(path string) ([]uint32, error) {
+	elements := strings.Split(strings.TrimSpace(path), "/")
+
+	if elements[0] == "m" || elements[0] == "M" {
+		elements = elements[1:]
+	}
+
+	indexes := make([]uint32, 0, len(elements))
+
+	for _, element := range elements {
+		if element == "" {
+			return nil, fmt.Errorf("invalid derivation path %s: empty element", path)
+		}
+
+		hardened := false
+
+		switch element[len(element)-1] {
+		case '\'', 'h', 'H':
+			hardened = true
+			element = element[:len(element)-1]
+		}
+
+		index, err := strconv.ParseUint(element, 10, 32)
+
+		if err != nil || index >= uint64(HardenedKeyStart) {
+			return nil, fmt.Errorf("invalid derivation path %s: bad index %s", path, element)
+		}
+
+		if hardened {
+			index += uint64(HardenedKeyStart)
+		}
+
+		indexes = append(indexes, uint32(index))
+	}
+
+	return indexes, nil
+}
+
+// FormatPath format child index list as derivation path string
+func FormatPath(indexes []uint32) string {
+	elements := []string{"m"}
+
+	for _, index := range indexes {
+		if index >= HardenedKeyStart {
+			elements = append(elements, fmt.Sprintf("%d'", index-HardenedKeyStart))
+		} else {
+			elements = append(elements, fmt.Sprintf("%d", index))
+		}
+	}
+
+	return strings.Join(elements, "/")
+}
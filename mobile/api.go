@@ -1,10 +1,14 @@
 package unichain
 
 import (
+	"bytes"
 	"encoding/hex"
 	"fmt"
+	"image/png"
+	"strings"
 
 	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/goany/bip39"
 	"github.com/goany/eth"
 )
 
@@ -13,7 +17,8 @@ type ETHWallet struct {
 	impl *eth.Wallet
 }
 
-// ETHWalletFromMnemonic create eth wallet from mnemonic
+// ETHWalletFromMnemonic create eth wallet from mnemonic exported by ETHWallet.Mnemonic,
+// use ETHWalletFromMnemonicWithIndex for mnemonics of other bip39 wallets
 func ETHWalletFromMnemonic(mnemonic string) (*ETHWallet, error) {
 
 	wallet, err := eth.WalletFromMnemonic(mnemonic)
@@ -27,6 +32,43 @@ func ETHWalletFromMnemonic(mnemonic string) (*ETHWallet, error) {
 	}, nil
 }
 
+// ETHWalletFromMnemonicWithIndex create eth wallet from mnemonic with bip44 account index
+func ETHWalletFromMnemonicWithIndex(mnemonic string, index int) (*ETHWallet, error) {
+
+	wallet, err := eth.WalletFromMnemonicWithMode(mnemonic, eth.MnemonicModeBIP44, uint32(index))
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &ETHWallet{
+		impl: wallet,
+	}, nil
+}
+
+// ETH mnemonic modes of ETHWalletFromMnemonicWithPassphrase
+const (
+	ETHMnemonicModeLegacy = int(eth.MnemonicModeLegacy)
+	ETHMnemonicModeBIP44  = int(eth.MnemonicModeBIP44)
+)
+
+// ETHWalletFromMnemonicWithPassphrase create eth wallet from mnemonic protected by
+// bip39 passphrase, mode is one of ETHMnemonicMode* constants and index is the
+// bip44 account index. An empty passphrase in legacy mode is the same as
+// ETHWalletFromMnemonic, in bip44 mode the same as ETHWalletFromMnemonicWithIndex
+func ETHWalletFromMnemonicWithPassphrase(mnemonic string, passphrase string, mode int, index int) (*ETHWallet, error) {
+
+	wallet, err := eth.WalletFromMnemonicWithPassphrase(mnemonic, passphrase, eth.MnemonicMode(mode), uint32(index))
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &ETHWallet{
+		impl: wallet,
+	}, nil
+}
+
 // OpenETHWallet create eth wallet from json metadata
 func OpenETHWallet(json []byte, password string) (*ETHWallet, error) {
 
@@ -158,3 +200,207 @@ func (wallet *ETHWallet) TransferToken(
 func (wallet *ETHWallet) Address() string {
 	return wallet.impl.Address()
 }
+
+// Close wipe wallet private key, the wallet can not sign afterwards
+func (wallet *ETHWallet) Close() {
+	wallet.impl.Close()
+}
+
+// Mnemonic word status returned by MnemonicCheck.Status
+const (
+	MnemonicWordValid      = int(bip39.WordValid)
+	MnemonicWordExpanded   = int(bip39.WordExpanded)
+	MnemonicWordIncomplete = int(bip39.WordIncomplete)
+	MnemonicWordUnknown    = int(bip39.WordUnknown)
+	MnemonicWordNone       = -1 // position out of range
+)
+
+// CompleteMnemonicWord get space separated words starting with prefix,
+// language is bip39 word list name, empty for english
+func CompleteMnemonicWord(prefix string, language string, limit int) (string, error) {
+
+	wordlist, err := mnemonicWordlist(language)
+
+	if err != nil {
+		return "", err
+	}
+
+	return strings.Join(wordlist.Complete(prefix, limit), " "), nil
+}
+
+// MnemonicCheck mnemonic entry validation facade
+type MnemonicCheck struct {
+	impl *bip39.MnemonicCheck
+}
+
+// CheckMnemonic validate mnemonic word by word, words may be typed in the
+// 4-letter unique prefix form
+func CheckMnemonic(mnemonic string, language string) (*MnemonicCheck, error) {
+
+	wordlist, err := mnemonicWordlist(language)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &MnemonicCheck{
+		impl: bip39.CheckMnemonic(mnemonic, wordlist),
+	}, nil
+}
+
+// Valid check if mnemonic is complete and has valid checksum
+func (check *MnemonicCheck) Valid() bool {
+	return check.impl.Valid()
+}
+
+// Reason get the reason why mnemonic is invalid, empty if valid
+func (check *MnemonicCheck) Reason() string {
+	if check.impl.Err == nil {
+		return ""
+	}
+
+	return check.impl.Err.Error()
+}
+
+// Mnemonic get mnemonic with prefixes expanded to full words
+func (check *MnemonicCheck) Mnemonic() string {
+	return check.impl.Mnemonic()
+}
+
+// Count get mnemonic word count
+func (check *MnemonicCheck) Count() int {
+	return len(check.impl.Words)
+}
+
+// Word get resolved word at position, empty if the word is incomplete or unknown
+func (check *MnemonicCheck) Word(position int) string {
+	return check.word(position).Word
+}
+
+// Status get word status at position, one of MnemonicWord* constants
+func (check *MnemonicCheck) Status(position int) int {
+	return int(check.word(position).Status)
+}
+
+// StatusReason get readable word status at position
+func (check *MnemonicCheck) StatusReason(position int) string {
+	return check.word(position).Status.String()
+}
+
+// Completions get space separated candidates of incomplete word at position
+func (check *MnemonicCheck) Completions(position int) string {
+	return strings.Join(check.word(position).Completions, " ")
+}
+
+// word get word check at position, status MnemonicWordNone if position is
+// out of range
+func (check *MnemonicCheck) word(position int) bip39.WordCheck {
+	if position < 0 || position >= len(check.impl.Words) {
+		return bip39.WordCheck{Position: position, Status: bip39.WordStatus(MnemonicWordNone)}
+	}
+
+	return check.impl.Words[position]
+}
+
+func mnemonicWordlist(language string) (*bip39.Wordlist, error) {
+	if language == "" {
+		return bip39.WordlistEnglish, nil
+	}
+
+	return bip39.GetWordlist(bip39.Language(language))
+}
+
+// SeedQRDigits encode english mnemonic as SeedQR digit stream
+func SeedQRDigits(mnemonic string) (string, error) {
+	return bip39.SeedQRDigits(mnemonic, bip39.WordlistEnglish)
+}
+
+// SeedQRImage render english mnemonic as SeedQR or CompactSeedQR png image
+// with scale pixels per module, at most qrcode.MaxScale
+func SeedQRImage(mnemonic string, compact bool, scale int) ([]byte, error) {
+
+	code, err := bip39.SeedQRCode(mnemonic, compact, bip39.WordlistEnglish)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return code.PNG(scale)
+}
+
+// MnemonicFromSeedQR decode raw SeedQR or CompactSeedQR payload returned by
+// the platform qr scanner
+func MnemonicFromSeedQR(payload []byte) (string, error) {
+	return bip39.MnemonicFromSeedQR(payload, bip39.WordlistEnglish)
+}
+
+// MnemonicFromSeedQRImage decode upright SeedQR or CompactSeedQR png image
+func MnemonicFromSeedQRImage(image []byte) (string, error) {
+
+	img, err := png.Decode(bytes.NewReader(image))
+
+	if err != nil {
+		return "", err
+	}
+
+	return bip39.MnemonicFromSeedQRImage(img, bip39.WordlistEnglish)
+}
+
+// ExportBackup encrypt wallet mnemonic into argon2id protected backup, the
+// passphrase hint is shown before the bip39 passphrase is asked on restore
+func (wallet *ETHWallet) ExportBackup(password string, passphraseHint string) ([]byte, error) {
+	return wallet.impl.ExportBackup(password, passphraseHint, bip39.KDFArgon2id)
+}
+
+// ETHWalletFromBackup restore eth wallet from mnemonic backup and bip39 passphrase
+func ETHWalletFromBackup(data []byte, password string, passphrase string) (*ETHWallet, error) {
+
+	wallet, err := eth.WalletFromBackup(data, password, passphrase)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &ETHWallet{
+		impl: wallet,
+	}, nil
+}
+
+// MnemonicBackup decrypted mnemonic backup facade
+type MnemonicBackup struct {
+	impl *bip39.Backup
+}
+
+// OpenMnemonicBackup decrypt mnemonic backup of any supported version
+func OpenMnemonicBackup(data []byte, password string) (*MnemonicBackup, error) {
+
+	backup, err := bip39.DecryptBackup(data, password)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &MnemonicBackup{
+		impl: backup,
+	}, nil
+}
+
+// MigrateMnemonicBackup re-encrypt mnemonic backup with the current backup version
+func MigrateMnemonicBackup(data []byte, password string) ([]byte, error) {
+	return bip39.MigrateBackup(data, password)
+}
+
+// Mnemonic get backup mnemonic words
+func (backup *MnemonicBackup) Mnemonic() string {
+	return backup.impl.Mnemonic
+}
+
+// PassphraseHint get bip39 passphrase hint
+func (backup *MnemonicBackup) PassphraseHint() string {
+	return backup.impl.PassphraseHint
+}
+
+// DerivationPath get wallet derivation path
+func (backup *MnemonicBackup) DerivationPath() string {
+	return backup.impl.DerivationPath
+}
@@ -0,0 +1,271 @@
+package qrcode
+
+import "math/bits"
+
+const alphanumericChars = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZ $%*+-./:"
+
+// Decode read data segments from module matrix indexed by row then column,
+// without quiet zone. Damaged codewords are corrected when possible
+func Decode(matrix [][]bool) ([]Segment, error) {
+	size := len(matrix)
+
+	if size < 21 || size%4 != 1 || (size-17)/4 > MaxVersion {
+		return nil, ErrInvalidSize
+	}
+
+	for _, row := range matrix {
+		if len(row) != size {
+			return nil, ErrInvalidSize
+		}
+	}
+
+	version := (size - 17) / 4
+
+	scanned := &Code{Size: size, modules: make([]bool, size*size)}
+
+	for y, row := range matrix {
+		copy(scanned.modules[y*size:], row)
+	}
+
+	level, mask, err := scanned.readFormat()
+
+	if err != nil {
+		return nil, err
+	}
+
+	code := newCode(version, level)
+	code.Mask = mask
+
+	copy(code.modules, scanned.modules)
+
+	code.applyMask(mask)
+
+	codewords := make([]byte, totalCodewords[version])
+
+	i := 0
+
+	code.forEachDataModule(func(x, y int) {
+		if i < len(codewords)*8 && code.Black(x, y) {
+			codewords[i/8] |= 0x80 >> uint(i%8)
+		}
+
+		i++
+	})
+
+	data, err := deinterleave(codewords, level, version)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return parseSegments(data)
+}
+
+// readFormat read level and mask from the best matching format information copy
+func (code *Code) readFormat() (Level, int, error) {
+	var first, second uint
+
+	for i := 0; i < 15; i++ {
+		x1, y1, x2, y2 := code.formatPositions(i)
+
+		if code.Black(x1, y1) {
+			first |= 1 << uint(i)
+		}
+
+		if code.Black(x2, y2) {
+			second |= 1 << uint(i)
+		}
+	}
+
+	bestDistance := 4
+
+	var (
+		bestLevel Level
+		bestMask  int
+	)
+
+	for level := LevelL; level <= LevelH; level++ {
+		for mask := 0; mask < 8; mask++ {
+			expect := formatBits(level, mask)
+
+			for _, read := range []uint{first, second} {
+				distance := bits.OnesCount(read ^ expect)
+
+				if distance < bestDistance {
+					bestDistance = distance
+					bestLevel = level
+					bestMask = mask
+				}
+			}
+		}
+	}
+
+	if bestDistance > 3 {
+		return 0, 0, ErrFormatInfo
+	}
+
+	return bestLevel, bestMask, nil
+}
+
+// deinterleave split codewords into blocks, correct errors and join data codewords
+func deinterleave(codewords []byte, level Level, version int) ([]byte, error) {
+	degree := ecCodewordsPerBlock[level][version]
+	lens := blockDataLens(level, version)
+
+	blocks := make([][]byte, len(lens))
+
+	for i, l := range lens {
+		blocks[i] = make([]byte, 0, l+degree)
+	}
+
+	offset := 0
+
+	for i := 0; i < lens[len(lens)-1]; i++ {
+		for j := range blocks {
+			if i < lens[j] {
+				blocks[j] = append(blocks[j], codewords[offset])
+				offset++
+			}
+		}
+	}
+
+	for i := 0; i < degree; i++ {
+		for j := range blocks {
+			blocks[j] = append(blocks[j], codewords[offset])
+			offset++
+		}
+	}
+
+	data := make([]byte, 0, dataCodewords(level, version))
+
+	for i, block := range blocks {
+		if err := rsDecode(block, degree); err != nil {
+			return nil, err
+		}
+
+		data = append(data, block[:lens[i]]...)
+	}
+
+	return data, nil
+}
+
+// bitReader big endian bit reader
+type bitReader struct {
+	data   []byte
+	offset int
+}
+
+func (reader *bitReader) available() int {
+	return len(reader.data)*8 - reader.offset
+}
+
+func (reader *bitReader) read(length int) uint {
+	value := uint(0)
+
+	for i := 0; i < length; i++ {
+		bit := (reader.data[reader.offset/8] >> uint(7-reader.offset%8)) & 1
+
+		value = value<<1 | uint(bit)
+
+		reader.offset++
+	}
+
+	return value
+}
+
+func parseSegments(data []byte) ([]Segment, error) {
+	reader := &bitReader{data: data}
+
+	var segments []Segment
+
+	for reader.available() >= 4 {
+		mode := Mode(reader.read(4))
+
+		if mode == 0 {
+			break
+		}
+
+		if mode != ModeNumeric && mode != ModeAlphanumeric && mode != ModeByte {
+			return nil, ErrInvalidData
+		}
+
+		countBits := int(charCountBits(mode))
+
+		if reader.available() < countBits {
+			return nil, ErrInvalidData
+		}
+
+		count := int(reader.read(countBits))
+
+		segment := Segment{Mode: mode, Data: make([]byte, 0, count)}
+
+		switch mode {
+		case ModeNumeric:
+			for remain := count; remain > 0; remain -= 3 {
+				digits := min(3, remain)
+				length := digits*3 + 1
+
+				if reader.available() < length {
+					return nil, ErrInvalidData
+				}
+
+				value := reader.read(length)
+
+				if value >= []uint{0, 10, 100, 1000}[digits] {
+					return nil, ErrInvalidData
+				}
+
+				for i := digits - 1; i >= 0; i-- {
+					segment.Data = append(segment.Data, byte('0'+value/pow10(i)%10))
+				}
+			}
+		case ModeAlphanumeric:
+			for remain := count; remain > 0; remain -= 2 {
+				chars := min(2, remain)
+				length := chars*5 + 1
+
+				if reader.available() < length {
+					return nil, ErrInvalidData
+				}
+
+				value := reader.read(length)
+
+				if chars == 2 {
+					if value >= 45*45 {
+						return nil, ErrInvalidData
+					}
+
+					segment.Data = append(segment.Data, alphanumericChars[value/45], alphanumericChars[value%45])
+				} else {
+					if value >= 45 {
+						return nil, ErrInvalidData
+					}
+
+					segment.Data = append(segment.Data, alphanumericChars[value])
+				}
+			}
+		default:
+			if reader.available() < count*8 {
+				return nil, ErrInvalidData
+			}
+
+			for i := 0; i < count; i++ {
+				segment.Data = append(segment.Data, byte(reader.read(8)))
+			}
+		}
+
+		segments = append(segments, segment)
+	}
+
+	return segments, nil
+}
+
+func pow10(n int) uint {
+	result := uint(1)
+
+	for i := 0; i < n; i++ {
+		result *= 10
+	}
+
+	return result
+}
@@ -0,0 +1,260 @@
+package qrcode
+
+import "fmt"
+
+// bitBuffer big endian bit writer
+type bitBuffer struct {
+	bits []bool
+}
+
+func (buff *bitBuffer) append(value uint, length uint) {
+	for i := int(length) - 1; i >= 0; i-- {
+		buff.bits = append(buff.bits, (value>>uint(i))&1 == 1)
+	}
+}
+
+func (buff *bitBuffer) bytes() []byte {
+	result := make([]byte, (len(buff.bits)+7)/8)
+
+	for i, bit := range buff.bits {
+		if bit {
+			result[i/8] |= 0x80 >> uint(i%8)
+		}
+	}
+
+	return result
+}
+
+// Encode create qr code of the smallest version holding segments with level
+func Encode(level Level, segments ...Segment) (*Code, error) {
+	buff := &bitBuffer{}
+
+	for _, segment := range segments {
+		if err := encodeSegment(buff, segment); err != nil {
+			return nil, err
+		}
+	}
+
+	version := MinVersion
+
+	for ; version <= MaxVersion; version++ {
+		if len(buff.bits) <= dataCodewords(level, version)*8 {
+			break
+		}
+	}
+
+	if version > MaxVersion {
+		return nil, ErrDataTooLong
+	}
+
+	capacity := dataCodewords(level, version) * 8
+
+	buff.append(0, uint(min(4, capacity-len(buff.bits))))
+	buff.append(0, uint((8-len(buff.bits)%8)%8))
+
+	for pad := uint(0xec); len(buff.bits) < capacity; pad ^= 0xec ^ 0x11 {
+		buff.append(pad, 8)
+	}
+
+	code := newCode(version, level)
+
+	code.drawCodewords(interleave(buff.bytes(), level, version))
+
+	code.Mask = -1
+
+	minPenalty := 0
+
+	for mask := 0; mask < 8; mask++ {
+		code.applyMask(mask)
+		code.drawFormatBits(formatBits(level, mask))
+
+		penalty := code.penalty()
+
+		if code.Mask < 0 || penalty < minPenalty {
+			code.Mask = mask
+			minPenalty = penalty
+		}
+
+		code.applyMask(mask)
+	}
+
+	code.applyMask(code.Mask)
+	code.drawFormatBits(formatBits(level, code.Mask))
+
+	return code, nil
+}
+
+func encodeSegment(buff *bitBuffer, segment Segment) error {
+	if len(segment.Data) >= 1<<charCountBits(segment.Mode) {
+		return ErrDataTooLong
+	}
+
+	switch segment.Mode {
+	case ModeNumeric:
+		buff.append(uint(ModeNumeric), 4)
+		buff.append(uint(len(segment.Data)), charCountBits(ModeNumeric))
+
+		for i := 0; i < len(segment.Data); i += 3 {
+			group := segment.Data[i:min(i+3, len(segment.Data))]
+
+			value := uint(0)
+
+			for _, c := range group {
+				if c < '0' || c > '9' {
+					return fmt.Errorf("invalid numeric segment character %q", c)
+				}
+
+				value = value*10 + uint(c-'0')
+			}
+
+			buff.append(value, uint(len(group))*3+1)
+		}
+	case ModeByte:
+		buff.append(uint(ModeByte), 4)
+		buff.append(uint(len(segment.Data)), charCountBits(ModeByte))
+
+		for _, b := range segment.Data {
+			buff.append(uint(b), 8)
+		}
+	default:
+		return fmt.Errorf("unsupported segment mode %d", segment.Mode)
+	}
+
+	return nil
+}
+
+// interleave split data into blocks, append error correction codewords and
+// interleave the blocks
+func interleave(data []byte, level Level, version int) []byte {
+	degree := ecCodewordsPerBlock[level][version]
+	lens := blockDataLens(level, version)
+
+	blocks := make([][]byte, len(lens))
+	ecs := make([][]byte, len(lens))
+
+	offset := 0
+
+	for i, l := range lens {
+		blocks[i] = data[offset : offset+l]
+		ecs[i] = rsEncode(blocks[i], degree)
+		offset += l
+	}
+
+	result := make([]byte, 0, totalCodewords[version])
+
+	for i := 0; i < lens[len(lens)-1]; i++ {
+		for _, block := range blocks {
+			if i < len(block) {
+				result = append(result, block[i])
+			}
+		}
+	}
+
+	for i := 0; i < degree; i++ {
+		for _, ec := range ecs {
+			result = append(result, ec[i])
+		}
+	}
+
+	return result
+}
+
+func (code *Code) drawCodewords(codewords []byte) {
+	i := 0
+
+	code.forEachDataModule(func(x, y int) {
+		if i < len(codewords)*8 {
+			code.set(x, y, (codewords[i/8]>>uint(7-i%8))&1 == 1)
+		}
+
+		i++
+	})
+}
+
+// penalty mask evaluation score, lower is better
+func (code *Code) penalty() int {
+	result := 0
+
+	size := code.Size
+
+	for horizontal := 0; horizontal < 2; horizontal++ {
+		at := func(i, j int) bool {
+			if horizontal == 0 {
+				return code.Black(j, i)
+			}
+
+			return code.Black(i, j)
+		}
+
+		for i := 0; i < size; i++ {
+			run := 1
+
+			for j := 1; j <= size; j++ {
+				if j < size && at(i, j) == at(i, j-1) {
+					run++
+					continue
+				}
+
+				if run >= 5 {
+					result += run - 2
+				}
+
+				run = 1
+			}
+
+			for j := 0; j+7 <= size; j++ {
+				if !(at(i, j) && !at(i, j+1) && at(i, j+2) && at(i, j+3) && at(i, j+4) && !at(i, j+5) && at(i, j+6)) {
+					continue
+				}
+
+				if code.lightRun(at, i, j-4, j) || code.lightRun(at, i, j+7, j+11) {
+					result += 40
+				}
+			}
+		}
+	}
+
+	dark := 0
+
+	for y := 0; y < size; y++ {
+		for x := 0; x < size; x++ {
+			if code.Black(x, y) {
+				dark++
+			}
+
+			if x+1 < size && y+1 < size {
+				c := code.Black(x, y)
+
+				if c == code.Black(x+1, y) && c == code.Black(x, y+1) && c == code.Black(x+1, y+1) {
+					result += 3
+				}
+			}
+		}
+	}
+
+	total := size * size
+
+	result += abs(dark*20-total*10) / total * 10
+
+	return result
+}
+
+// lightRun check modules from begin to end of line i are light, modules
+// outside the symbol count as light
+func (code *Code) lightRun(at func(i, j int) bool, i, begin, end int) bool {
+	for j := begin; j < end; j++ {
+		if j >= 0 && j < code.Size && at(i, j) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func min(a, b int) int {
+	if a < b {
+		return a
+	}
+
+	return b
+}
@@ -0,0 +1,144 @@
+package qrcode
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/color"
+	"image/png"
+)
+
+// QuietZone light border width in modules around rendered symbols
+const QuietZone = 4
+
+// MaxScale largest rendered pixels per module, bounds the image allocation
+const MaxScale = 64
+
+// ErrNoCode no qr code found in image
+var ErrNoCode = errors.New("no qr code found in image")
+
+// Image render code with scale pixels per module and quiet zone, scale is
+// clamped to [1, MaxScale]
+func (code *Code) Image(scale int) image.Image {
+	if scale < 1 {
+		scale = 1
+	}
+
+	if scale > MaxScale {
+		scale = MaxScale
+	}
+
+	width := (code.Size + 2*QuietZone) * scale
+
+	img := image.NewGray(image.Rect(0, 0, width, width))
+
+	for i := range img.Pix {
+		img.Pix[i] = 0xff
+	}
+
+	for y := 0; y < code.Size; y++ {
+		for x := 0; x < code.Size; x++ {
+			if !code.Black(x, y) {
+				continue
+			}
+
+			for dy := 0; dy < scale; dy++ {
+				for dx := 0; dx < scale; dx++ {
+					img.SetGray((x+QuietZone)*scale+dx, (y+QuietZone)*scale+dy, color.Gray{})
+				}
+			}
+		}
+	}
+
+	return img
+}
+
+// PNG render code as png image, see Image
+func (code *Code) PNG(scale int) ([]byte, error) {
+	var buff bytes.Buffer
+
+	if err := png.Encode(&buff, code.Image(scale)); err != nil {
+		return nil, err
+	}
+
+	return buff.Bytes(), nil
+}
+
+// DecodeImage read data segments from an upright, unrotated image of a qr code
+// such as a screenshot or a rendered png. Photos must be rectified by the caller
+func DecodeImage(img image.Image) ([]Segment, error) {
+	bounds := img.Bounds()
+
+	dark := func(x, y int) bool {
+		gray := color.GrayModel.Convert(img.At(x, y)).(color.Gray)
+
+		return gray.Y < 0x80
+	}
+
+	left, top, right, bottom := bounds.Max.X, bounds.Max.Y, bounds.Min.X-1, bounds.Min.Y-1
+
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			if !dark(x, y) {
+				continue
+			}
+
+			left, right = min(left, x), max(right, x)
+			top, bottom = min(top, y), max(bottom, y)
+		}
+	}
+
+	if right < left {
+		return nil, ErrNoCode
+	}
+
+	// top left finder pattern is 7 modules wide
+	finder := 0
+
+	for x := left; x <= right && dark(x, top); x++ {
+		finder++
+	}
+
+	width := float64(right - left + 1)
+	moduleSize := float64(finder) / 7
+
+	// the module count comes from untrusted pixels, bound it before the
+	// matrix is allocated
+	if moduleSize == 0 || width/moduleSize > 17+4*MaxVersion+1 {
+		return nil, ErrNoCode
+	}
+
+	size := int(width/moduleSize + 0.5)
+
+	if size < 21 || size > 17+4*MaxVersion || size%4 != 1 {
+		return nil, ErrNoCode
+	}
+
+	moduleSize = width / float64(size)
+
+	matrix := make([][]bool, size)
+
+	for row := range matrix {
+		matrix[row] = make([]bool, size)
+
+		for col := range matrix[row] {
+			x := left + int((float64(col)+0.5)*moduleSize)
+			y := top + int((float64(row)+0.5)*moduleSize)
+
+			matrix[row][col] = dark(x, y)
+		}
+	}
+
+	return Decode(matrix)
+}
+
+// DecodePNG read data segments from png image, see DecodeImage
+func DecodePNG(data []byte) ([]Segment, error) {
+	img, err := png.Decode(bytes.NewReader(data))
+
+	if err != nil {
+		return nil, err
+	}
+
+	return DecodeImage(img)
+}
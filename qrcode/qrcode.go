@@ -0,0 +1,337 @@
+package qrcode
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Level error correction level
+type Level int
+
+// Error correction levels
+const (
+	LevelL Level = iota // about 7% of codewords can be restored
+	LevelM              // about 15%
+	LevelQ              // about 25%
+	LevelH              // about 30%
+)
+
+// Supported symbol versions, larger versions need version information
+// blocks and are not required by mnemonic payloads
+const (
+	MinVersion = 1
+	MaxVersion = 6
+)
+
+// Mode data segment encoding mode
+type Mode int
+
+// Segment modes
+const (
+	ModeNumeric      Mode = 1
+	ModeAlphanumeric Mode = 2
+	ModeByte         Mode = 4
+)
+
+// qrcode errors
+var (
+	ErrDataTooLong   = fmt.Errorf("data does not fit in version %d qr code", MaxVersion)
+	ErrInvalidSize   = errors.New("qr code matrix size is not supported")
+	ErrFormatInfo    = errors.New("qr code format information can not be read")
+	ErrTooManyErrors = errors.New("qr code has too many errors to correct")
+	ErrInvalidData   = errors.New("qr code contains invalid data segment")
+)
+
+// Segment data segment of qr code
+type Segment struct {
+	Mode Mode
+	Data []byte
+}
+
+// NumericSegment create numeric mode segment of decimal digits
+func NumericSegment(digits string) Segment {
+	return Segment{Mode: ModeNumeric, Data: []byte(digits)}
+}
+
+// ByteSegment create byte mode segment of binary data
+func ByteSegment(data []byte) Segment {
+	return Segment{Mode: ModeByte, Data: data}
+}
+
+// formatLevelBits level bits of format information
+var formatLevelBits = [4]uint{1, 0, 3, 2}
+
+// totalCodewords codewords of each version, index 0 unused
+var totalCodewords = [MaxVersion + 1]int{0, 26, 44, 70, 100, 134, 172}
+
+// ecCodewordsPerBlock error correction codewords per block of each level and version
+var ecCodewordsPerBlock = [4][MaxVersion + 1]int{
+	{0, 7, 10, 15, 20, 26, 18},
+	{0, 10, 16, 26, 18, 24, 16},
+	{0, 13, 22, 18, 26, 18, 24},
+	{0, 17, 28, 22, 16, 22, 28},
+}
+
+// errorCorrectionBlocks block count of each level and version
+var errorCorrectionBlocks = [4][MaxVersion + 1]int{
+	{0, 1, 1, 1, 1, 1, 2},
+	{0, 1, 1, 1, 2, 2, 4},
+	{0, 1, 1, 2, 2, 4, 4},
+	{0, 1, 1, 2, 4, 4, 4},
+}
+
+// dataCodewords data codewords of level and version
+func dataCodewords(level Level, version int) int {
+	return totalCodewords[version] - ecCodewordsPerBlock[level][version]*errorCorrectionBlocks[level][version]
+}
+
+// blockDataLens data codewords of each block, short blocks come first
+func blockDataLens(level Level, version int) []int {
+	blocks := errorCorrectionBlocks[level][version]
+	data := dataCodewords(level, version)
+
+	lens := make([]int, blocks)
+
+	for i := range lens {
+		lens[i] = data / blocks
+
+		if i >= blocks-data%blocks {
+			lens[i]++
+		}
+	}
+
+	return lens
+}
+
+// charCountBits character count indicator length for version 1 to 9
+func charCountBits(mode Mode) uint {
+	switch mode {
+	case ModeNumeric:
+		return 10
+	case ModeAlphanumeric:
+		return 9
+	}
+
+	return 8
+}
+
+// Code qr code symbol, modules are addressed by column x and row y
+type Code struct {
+	Version int
+	Level   Level
+	Mask    int
+	Size    int
+
+	modules  []bool // true for dark module
+	function []bool // true for function pattern module
+}
+
+func newCode(version int, level Level) *Code {
+	size := version*4 + 17
+
+	code := &Code{
+		Version:  version,
+		Level:    level,
+		Size:     size,
+		modules:  make([]bool, size*size),
+		function: make([]bool, size*size),
+	}
+
+	code.drawFunctionPatterns()
+
+	return code
+}
+
+// Black check if module at column x and row y is dark
+func (code *Code) Black(x, y int) bool {
+	return code.modules[y*code.Size+x]
+}
+
+// Matrix get module matrix indexed by row then column
+func (code *Code) Matrix() [][]bool {
+	matrix := make([][]bool, code.Size)
+
+	for y := range matrix {
+		matrix[y] = append([]bool(nil), code.modules[y*code.Size:(y+1)*code.Size]...)
+	}
+
+	return matrix
+}
+
+func (code *Code) set(x, y int, dark bool) {
+	code.modules[y*code.Size+x] = dark
+}
+
+func (code *Code) setFunction(x, y int, dark bool) {
+	code.modules[y*code.Size+x] = dark
+	code.function[y*code.Size+x] = true
+}
+
+func (code *Code) isFunction(x, y int) bool {
+	return code.function[y*code.Size+x]
+}
+
+func (code *Code) drawFunctionPatterns() {
+	for i := 0; i < code.Size; i++ {
+		code.setFunction(6, i, i%2 == 0)
+		code.setFunction(i, 6, i%2 == 0)
+	}
+
+	code.drawFinder(3, 3)
+	code.drawFinder(code.Size-4, 3)
+	code.drawFinder(3, code.Size-4)
+
+	if code.Version >= 2 {
+		code.drawAlignment(code.Size-7, code.Size-7)
+	}
+
+	// reserve format information area
+	code.drawFormatBits(0)
+}
+
+func (code *Code) drawFinder(cx, cy int) {
+	for dy := -4; dy <= 4; dy++ {
+		for dx := -4; dx <= 4; dx++ {
+			x, y := cx+dx, cy+dy
+
+			if x < 0 || x >= code.Size || y < 0 || y >= code.Size {
+				continue
+			}
+
+			dist := max(abs(dx), abs(dy))
+
+			code.setFunction(x, y, dist != 2 && dist != 4)
+		}
+	}
+}
+
+func (code *Code) drawAlignment(cx, cy int) {
+	for dy := -2; dy <= 2; dy++ {
+		for dx := -2; dx <= 2; dx++ {
+			code.setFunction(cx+dx, cy+dy, max(abs(dx), abs(dy)) != 1)
+		}
+	}
+}
+
+// formatBits 15 bits format information of level and mask
+func formatBits(level Level, mask int) uint {
+	data := formatLevelBits[level]<<3 | uint(mask)
+
+	rem := data
+
+	for i := 0; i < 10; i++ {
+		rem = rem<<1 ^ (rem>>9)*0x537
+	}
+
+	return (data<<10 | rem) ^ 0x5412
+}
+
+// formatPositions module positions of format bit i, first and second copy
+func (code *Code) formatPositions(i int) (x1, y1, x2, y2 int) {
+	switch {
+	case i < 6:
+		x1, y1 = 8, i
+	case i < 8:
+		x1, y1 = 8, i+1
+	case i == 8:
+		x1, y1 = 7, 8
+	default:
+		x1, y1 = 14-i, 8
+	}
+
+	if i < 8 {
+		x2, y2 = code.Size-1-i, 8
+	} else {
+		x2, y2 = 8, code.Size-15+i
+	}
+
+	return
+}
+
+func (code *Code) drawFormatBits(bits uint) {
+	for i := 0; i < 15; i++ {
+		dark := (bits>>uint(i))&1 == 1
+
+		x1, y1, x2, y2 := code.formatPositions(i)
+
+		code.setFunction(x1, y1, dark)
+		code.setFunction(x2, y2, dark)
+	}
+
+	code.setFunction(8, code.Size-8, true)
+}
+
+// forEachDataModule visit data modules in codeword placement order
+func (code *Code) forEachDataModule(visit func(x, y int)) {
+	for right := code.Size - 1; right >= 1; right -= 2 {
+		if right == 6 {
+			right = 5
+		}
+
+		upward := (right+1)&2 == 0
+
+		for vert := 0; vert < code.Size; vert++ {
+			y := vert
+
+			if upward {
+				y = code.Size - 1 - vert
+			}
+
+			for j := 0; j < 2; j++ {
+				x := right - j
+
+				if !code.isFunction(x, y) {
+					visit(x, y)
+				}
+			}
+		}
+	}
+}
+
+func maskBit(mask int, x, y int) bool {
+	switch mask {
+	case 0:
+		return (x+y)%2 == 0
+	case 1:
+		return y%2 == 0
+	case 2:
+		return x%3 == 0
+	case 3:
+		return (x+y)%3 == 0
+	case 4:
+		return (x/3+y/2)%2 == 0
+	case 5:
+		return x*y%2+x*y%3 == 0
+	case 6:
+		return (x*y%2+x*y%3)%2 == 0
+	default:
+		return ((x+y)%2+x*y%3)%2 == 0
+	}
+}
+
+// applyMask xor data modules with mask pattern, applying twice undoes it
+func (code *Code) applyMask(mask int) {
+	for y := 0; y < code.Size; y++ {
+		for x := 0; x < code.Size; x++ {
+			if !code.isFunction(x, y) && maskBit(mask, x, y) {
+				code.set(x, y, !code.Black(x, y))
+			}
+		}
+	}
+}
+
+func abs(x int) int {
+	if x < 0 {
+		return -x
+	}
+
+	return x
+}
+
+func max(a, b int) int {
+	if a > b {
+		return a
+	}
+
+	return b
+}
@@ -0,0 +1,249 @@
+package qrcode
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"testing"
+)
+
+func TestEncodeDecode(t *testing.T) {
+	tests := []struct {
+		segment Segment
+		level   Level
+		version int
+	}{
+		{NumericSegment("01234567"), LevelH, 1},
+		{NumericSegment("192402230235000208231352122203800000078905820100"), LevelL, 2},
+		{ByteSegment(bytes.Repeat([]byte{0x00, 0xff, 0x5a}, 10)), LevelL, 2},
+		{ByteSegment(bytes.Repeat([]byte{0x42}, 100)), LevelM, 6},
+		{ByteSegment([]byte("hello world")), LevelQ, 1},
+	}
+
+	for _, test := range tests {
+		code, err := Encode(test.level, test.segment)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if code.Version != test.version {
+			t.Fatalf("expect version %d got %d", test.version, code.Version)
+		}
+
+		segments, err := Decode(code.Matrix())
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(segments) != 1 || segments[0].Mode != test.segment.Mode || !bytes.Equal(segments[0].Data, test.segment.Data) {
+			t.Fatalf("expect %s got %v", test.segment.Data, segments)
+		}
+	}
+
+	if _, err := Encode(LevelH, ByteSegment(make([]byte, 200))); err != ErrDataTooLong {
+		t.Fatalf("expect data too long got %v", err)
+	}
+}
+
+func TestEncodeReference(t *testing.T) {
+	// ISO/IEC 18004 annex example 1-M and a SeedQR payload, the expected
+	// matrices come from an independent encoder with the same mask
+	tests := []struct {
+		segment Segment
+		level   Level
+		mask    int
+		rows    []string
+	}{
+		{NumericSegment("01234567"), LevelM, 2, []string{
+			"#######..#.##.#######",
+			"#.....#..####.#.....#",
+			"#.###.#.#.....#.###.#",
+			"#.###.#.##....#.###.#",
+			"#.###.#.#.###.#.###.#",
+			"#.....#.#...#.#.....#",
+			"#######.#.#.#.#######",
+			"........#..##........",
+			"#.#####..#..#.#####..",
+			"...#.#.##.#.#..#.##..",
+			"..#...##.#.#.#..#####",
+			"....#....#.....####..",
+			"...######..#.#..#....",
+			"........#.#####..##..",
+			"#######..##.#.##.....",
+			"#.....#.#.#####...#.#",
+			"#.###.#.#...#..#.##..",
+			"#.###.#.##..#..#.....",
+			"#.###.#.#.##.#..#.#..",
+			"#.....#........##.##.",
+			"#######.####.#..#.#..",
+		}},
+		{NumericSegment("192402230235000208231352122203800000078905820100"), LevelL, 2, []string{
+			"#######...#.#.....#######",
+			"#.....#.#.#..###..#.....#",
+			"#.###.#.....#.#.#.#.###.#",
+			"#.###.#.###.##....#.###.#",
+			"#.###.#..#....#.#.#.###.#",
+			"#.....#.##.##.#...#.....#",
+			"#######.#.#.#.#.#.#######",
+			".........###...##........",
+			"#####.####.#...#.#.#.#.#.",
+			".##.#..#..#.####.###.##.#",
+			"#..######.#....#...###.##",
+			"..##...#....#.##..##..#..",
+			".###..##.##.#..#..#.#####",
+			"##..##..#......#..###...#",
+			"#...#.#.##.##..#.##..##.#",
+			"#.##.#.#..##...#.#...#..#",
+			"#.#..#####.#...######.##.",
+			"........###.##.##...###.#",
+			"#######.#.#..##.#.#.###.#",
+			"#.....#...#.##.##...#.#.#",
+			"#.###.#.#.#.#.########.#.",
+			"#.###.#.###.....#....#..#",
+			"#.###.#.#.###...#..#.#..#",
+			"#.....#.#.##..###.####.##",
+			"#######.#.##...#.#...#..#",
+		}},
+	}
+
+	for _, test := range tests {
+		code, err := Encode(test.level, test.segment)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if code.Mask != test.mask || code.Size != len(test.rows) {
+			t.Fatalf("expect mask %d size %d got mask %d size %d", test.mask, len(test.rows), code.Mask, code.Size)
+		}
+
+		for y, row := range test.rows {
+			for x, module := range row {
+				if code.Black(x, y) != (module == '#') {
+					t.Fatalf("%s: module (%d, %d) differs from reference", test.segment.Data, x, y)
+				}
+			}
+		}
+	}
+}
+
+func TestDecodeDamaged(t *testing.T) {
+	data := []byte("damaged qr code payload")
+
+	code, err := Encode(LevelM, ByteSegment(data))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	matrix := code.Matrix()
+
+	// flip a few data modules in the bottom right corner
+	for i := 0; i < 6; i++ {
+		matrix[code.Size-1-i][code.Size-1] = !matrix[code.Size-1-i][code.Size-1]
+	}
+
+	segments, err := Decode(matrix)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Equal(segments[0].Data, data) {
+		t.Fatalf("expect %s got %s", data, segments[0].Data)
+	}
+}
+
+func TestReedSolomon(t *testing.T) {
+	// HELLO WORLD version 1-M example of ISO/IEC 18004
+	data := []byte{0x20, 0x5b, 0x0b, 0x78, 0xd1, 0x72, 0xdc, 0x4d, 0x43, 0x40, 0xec, 0x11, 0xec, 0x11, 0xec, 0x11}
+
+	ec := rsEncode(data, 10)
+
+	expect := []byte{0xc4, 0x23, 0x27, 0x77, 0xeb, 0xd7, 0xe7, 0xe2, 0x5d, 0x17}
+
+	if !bytes.Equal(ec, expect) {
+		t.Fatalf("expect %x got %x", expect, ec)
+	}
+
+	block := append(append([]byte(nil), data...), ec...)
+
+	for _, i := range []int{0, 7, 12, 25, 3} {
+		block[i] ^= byte(i*37 + 1)
+	}
+
+	if err := rsDecode(block, 10); err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Equal(block[:len(data)], data) {
+		t.Fatalf("expect %x got %x", data, block[:len(data)])
+	}
+
+	block[1] ^= 1
+	block[2] ^= 1
+	block[4] ^= 1
+	block[5] ^= 1
+	block[6] ^= 1
+	block[8] ^= 1
+
+	if err := rsDecode(block, 10); err == nil {
+		t.Fatal("expect too many errors")
+	}
+}
+
+func TestDecodePNG(t *testing.T) {
+	code, err := Encode(LevelL, NumericSegment("0123456789"))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, scale := range []int{1, 3, 8} {
+		png, err := code.PNG(scale)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		segments, err := DecodePNG(png)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if string(segments[0].Data) != "0123456789" {
+			t.Fatalf("expect 0123456789 got %s", segments[0].Data)
+		}
+	}
+}
+
+func TestImageScale(t *testing.T) {
+	code, err := Encode(LevelL, NumericSegment("0123456789"))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for scale, expect := range map[int]int{-1: 1, 0: 1, 5: 5, MaxScale + 1: MaxScale, 1 << 30: MaxScale} {
+		if width := code.Image(scale).Bounds().Dx(); width != (code.Size+2*QuietZone)*expect {
+			t.Fatalf("scale %d: expect %d pixels per module got width %d", scale, expect, width)
+		}
+	}
+}
+
+func TestDecodeImageOversized(t *testing.T) {
+	// one pixel finder pattern spanning 3999 pixels claims a version 6994
+	// code of 27993 modules
+	img := image.NewGray(image.Rect(0, 0, 3999, 1))
+
+	for x := 1; x < 3998; x++ {
+		img.SetGray(x, 0, color.Gray{Y: 0xff})
+	}
+
+	if _, err := DecodeImage(img); err != ErrNoCode {
+		t.Fatalf("expect no code error got %v", err)
+	}
+}
@@ -0,0 +1,223 @@
+package qrcode
+
+// GF(256) arithmetic with qr code polynomial x^8 + x^4 + x^3 + x^2 + 1
+var (
+	gfExp [512]byte
+	gfLog [256]int
+)
+
+func init() {
+	x := 1
+
+	for i := 0; i < 255; i++ {
+		gfExp[i] = byte(x)
+		gfLog[x] = i
+
+		x <<= 1
+
+		if x&0x100 != 0 {
+			x ^= 0x11d
+		}
+	}
+
+	for i := 255; i < len(gfExp); i++ {
+		gfExp[i] = gfExp[i-255]
+	}
+}
+
+func gfMul(a, b byte) byte {
+	if a == 0 || b == 0 {
+		return 0
+	}
+
+	return gfExp[gfLog[a]+gfLog[b]]
+}
+
+func gfDiv(a, b byte) byte {
+	if a == 0 {
+		return 0
+	}
+
+	return gfExp[gfLog[a]+255-gfLog[b]]
+}
+
+// gfPow alpha^e
+func gfPow(e int) byte {
+	e %= 255
+
+	if e < 0 {
+		e += 255
+	}
+
+	return gfExp[e]
+}
+
+// polyEval evaluate polynomial with lowest degree coefficient first
+func polyEval(poly []byte, x byte) byte {
+	var result byte
+
+	for i := len(poly) - 1; i >= 0; i-- {
+		result = gfMul(result, x) ^ poly[i]
+	}
+
+	return result
+}
+
+// rsGenerator generator polynomial of degree, highest degree coefficient
+// first with the leading 1 omitted
+func rsGenerator(degree int) []byte {
+	result := make([]byte, degree)
+	result[degree-1] = 1
+
+	root := byte(1)
+
+	for i := 0; i < degree; i++ {
+		for j := range result {
+			result[j] = gfMul(result[j], root)
+
+			if j+1 < len(result) {
+				result[j] ^= result[j+1]
+			}
+		}
+
+		root = gfMul(root, 2)
+	}
+
+	return result
+}
+
+// rsEncode error correction codewords of data
+func rsEncode(data []byte, degree int) []byte {
+	generator := rsGenerator(degree)
+
+	result := make([]byte, degree)
+
+	for _, b := range data {
+		factor := b ^ result[0]
+
+		copy(result, result[1:])
+		result[degree-1] = 0
+
+		for i := range result {
+			result[i] ^= gfMul(generator[i], factor)
+		}
+	}
+
+	return result
+}
+
+// rsDecode correct codeword block in place, the block is data followed by
+// degree error correction codewords
+func rsDecode(block []byte, degree int) error {
+	n := len(block)
+
+	syndromes := make([]byte, degree)
+	clean := true
+
+	for i := range syndromes {
+		var s byte
+
+		x := gfPow(i)
+
+		for _, b := range block {
+			s = gfMul(s, x) ^ b
+		}
+
+		syndromes[i] = s
+
+		if s != 0 {
+			clean = false
+		}
+	}
+
+	if clean {
+		return nil
+	}
+
+	// Berlekamp-Massey, polynomials with lowest degree coefficient first
+	locator := []byte{1}
+	prev := []byte{1}
+	errs := 0
+	shift := 1
+	lastDiscrepancy := byte(1)
+
+	for k := 0; k < degree; k++ {
+		d := syndromes[k]
+
+		for i := 1; i <= errs && i < len(locator); i++ {
+			d ^= gfMul(locator[i], syndromes[k-i])
+		}
+
+		if d == 0 {
+			shift++
+			continue
+		}
+
+		coef := gfDiv(d, lastDiscrepancy)
+
+		next := make([]byte, max(len(locator), len(prev)+shift))
+		copy(next, locator)
+
+		for i, p := range prev {
+			next[i+shift] ^= gfMul(coef, p)
+		}
+
+		if 2*errs <= k {
+			prev = locator
+			errs = k + 1 - errs
+			lastDiscrepancy = d
+			shift = 1
+		} else {
+			shift++
+		}
+
+		locator = next
+	}
+
+	if 2*errs > degree {
+		return ErrTooManyErrors
+	}
+
+	// omega = syndromes * locator mod x^degree
+	omega := make([]byte, degree)
+
+	for i := range omega {
+		for j := 0; j <= i && j < len(locator); j++ {
+			omega[i] ^= gfMul(locator[j], syndromes[i-j])
+		}
+	}
+
+	// formal derivative of locator, only odd powers survive in GF(2^8)
+	derivative := make([]byte, len(locator))
+
+	for i := 1; i < len(locator); i += 2 {
+		derivative[i-1] = locator[i]
+	}
+
+	found := 0
+
+	for pos := 0; pos < n; pos++ {
+		power := n - 1 - pos
+		xInv := gfPow(-power)
+
+		if polyEval(locator, xInv) != 0 {
+			continue
+		}
+
+		denominator := polyEval(derivative, xInv)
+
+		if denominator == 0 {
+			return ErrTooManyErrors
+		}
+
+		block[pos] ^= gfMul(gfPow(power), gfDiv(polyEval(omega, xInv), denominator))
+
+		found++
+	}
+
+	if found != errs {
+		return ErrTooManyErrors
+	}
+
+	return nil
+}
@@ -0,0 +1,22 @@
+//go:build linux
+// +build linux
+
+package secure
+
+import "syscall"
+
+func mlock(data []byte) error {
+	if len(data) == 0 {
+		return nil
+	}
+
+	return syscall.Mlock(data)
+}
+
+func munlock(data []byte) error {
+	if len(data) == 0 {
+		return nil
+	}
+
+	return syscall.Munlock(data)
+}
@@ -0,0 +1,17 @@
+//go:build !linux
+// +build !linux
+
+package secure
+
+import "errors"
+
+var errNotSupported = errors.New("mlock is not supported on this platform")
+
+// mlock is only supported on linux, secrets are still wiped on other platforms
+func mlock(data []byte) error {
+	return errNotSupported
+}
+
+func munlock(data []byte) error {
+	return nil
+}
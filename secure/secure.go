@@ -0,0 +1,119 @@
+package secure
+
+import (
+	"crypto/ecdsa"
+	"fmt"
+	"math/big"
+)
+
+const redacted = "[REDACTED]"
+
+// SecretBytes key material container, the memory is locked into RAM where
+// supported, explicitly wiped by Wipe and never printed by fmt or loggers
+type SecretBytes struct {
+	data   []byte
+	locked bool
+}
+
+// NewSecretBytes allocate zeroed secret of size bytes
+func NewSecretBytes(size int) *SecretBytes {
+	secret := &SecretBytes{
+		data: make([]byte, size),
+	}
+
+	secret.locked = mlock(secret.data) == nil
+
+	return secret
+}
+
+// SecretBytesFrom move data into a new secret, data is wiped
+func SecretBytesFrom(data []byte) *SecretBytes {
+	secret := NewSecretBytes(len(data))
+
+	copy(secret.data, data)
+
+	Wipe(data)
+
+	return secret
+}
+
+// Bytes get the secret data, the slice is owned by the secret and is wiped with it
+func (secret *SecretBytes) Bytes() []byte {
+	return secret.data
+}
+
+// Len secret length in bytes, 0 after Wipe
+func (secret *SecretBytes) Len() int {
+	return len(secret.data)
+}
+
+// Locked check if the secret memory is locked into RAM
+func (secret *SecretBytes) Locked() bool {
+	return secret.locked
+}
+
+// Wipe zero and unlock the secret memory, safe to call more than once
+func (secret *SecretBytes) Wipe() {
+	if secret == nil || secret.data == nil {
+		return
+	}
+
+	Wipe(secret.data)
+
+	if secret.locked {
+		munlock(secret.data)
+		secret.locked = false
+	}
+
+	secret.data = nil
+}
+
+func (secret *SecretBytes) String() string {
+	return redacted
+}
+
+// GoString hide secret from %#v
+func (secret *SecretBytes) GoString() string {
+	return redacted
+}
+
+// Format hide secret from every fmt verb, e.g. %x and %v
+func (secret *SecretBytes) Format(f fmt.State, verb rune) {
+	f.Write([]byte(redacted))
+}
+
+// MarshalText hide secret from text and json encoders used by loggers
+func (secret *SecretBytes) MarshalText() ([]byte, error) {
+	return []byte(redacted), nil
+}
+
+// Wipe zero byte slice
+func Wipe(data []byte) {
+	for i := range data {
+		data[i] = 0
+	}
+}
+
+// WipeBigInt zero the words of n and set it to 0
+func WipeBigInt(n *big.Int) {
+	if n == nil {
+		return
+	}
+
+	words := n.Bits()
+
+	for i := range words {
+		words[i] = 0
+	}
+
+	n.SetInt64(0)
+}
+
+// WipePrivateKey zero the private scalar of key
+func WipePrivateKey(key *ecdsa.PrivateKey) {
+	if key == nil {
+		return
+	}
+
+	WipeBigInt(key.D)
+}
@@ -0,0 +1,71 @@
+package secure
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"testing"
+)
+
+func TestSecretBytes(t *testing.T) {
+	source := []byte{0xde, 0xad, 0xbe, 0xef}
+
+	secret := SecretBytesFrom(source)
+
+	if !bytes.Equal(source, make([]byte, 4)) {
+		t.Fatalf("expect source wiped got %x", source)
+	}
+
+	if !bytes.Equal(secret.Bytes(), []byte{0xde, 0xad, 0xbe, 0xef}) {
+		t.Fatalf("unexpected secret %x", secret.Bytes())
+	}
+
+	for _, format := range []string{"%v", "%s", "%x", "%X", "%d", "%+v", "%#v", "%q"} {
+		if printed := fmt.Sprintf(format, secret); printed != redacted {
+			t.Fatalf("%s: secret printed as %s", format, printed)
+		}
+	}
+
+	if printed := fmt.Sprintf("%v", struct{ Key *SecretBytes }{secret}); printed != "{"+redacted+"}" {
+		t.Fatalf("secret printed as %s", printed)
+	}
+
+	data, err := json.Marshal(map[string]*SecretBytes{"key": secret})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if string(data) != `{"key":"`+redacted+`"}` {
+		t.Fatalf("secret marshaled as %s", data)
+	}
+
+	buff := secret.Bytes()
+
+	secret.Wipe()
+	secret.Wipe()
+
+	if secret.Len() != 0 || !bytes.Equal(buff, make([]byte, 4)) {
+		t.Fatalf("expect wiped secret got %x", buff)
+	}
+}
+
+func TestWipePrivateKey(t *testing.T) {
+	key := &ecdsa.PrivateKey{D: new(big.Int).SetBytes(bytes.Repeat([]byte{0xff}, 32))}
+
+	words := key.D.Bits()
+
+	WipePrivateKey(key)
+
+	if key.D.Sign() != 0 {
+		t.Fatal("expect zero private key")
+	}
+
+	for _, word := range words[:cap(words)] {
+		if word != 0 {
+			t.Fatal("expect private key words wiped")
+		}
+	}
+}
@@ -0,0 +1,71 @@
+package slip39
+
+import (
+	"crypto/sha256"
+
+	"golang.org/x/crypto/pbkdf2"
+)
+
+// feistel network parameters
+const (
+	baseIterationCount = 10000
+	roundCount         = 4
+)
+
+// encrypt master secret with passphrase into encrypted master secret
+func encrypt(masterSecret []byte, passphrase string, iterationExponent int, identifier int, extendable bool) []byte {
+	half := len(masterSecret) / 2
+
+	l := append([]byte(nil), masterSecret[:half]...)
+	r := append([]byte(nil), masterSecret[half:]...)
+
+	salt := cipherSalt(identifier, extendable)
+
+	for i := 0; i < roundCount; i++ {
+		l, r = r, xor(l, roundFunction(i, passphrase, iterationExponent, salt, r))
+	}
+
+	return append(r, l...)
+}
+
+// decrypt encrypted master secret with passphrase into master secret
+func decrypt(encrypted []byte, passphrase string, iterationExponent int, identifier int, extendable bool) []byte {
+	half := len(encrypted) / 2
+
+	l := append([]byte(nil), encrypted[:half]...)
+	r := append([]byte(nil), encrypted[half:]...)
+
+	salt := cipherSalt(identifier, extendable)
+
+	for i := roundCount - 1; i >= 0; i-- {
+		l, r = r, xor(l, roundFunction(i, passphrase, iterationExponent, salt, r))
+	}
+
+	return append(r, l...)
+}
+
+func roundFunction(i int, passphrase string, iterationExponent int, salt []byte, r []byte) []byte {
+	password := append([]byte{byte(i)}, passphrase...)
+
+	iterations := (baseIterationCount << uint(iterationExponent)) / roundCount
+
+	return pbkdf2.Key(password, append(append([]byte(nil), salt...), r...), iterations, len(r), sha256.New)
+}
+
+func cipherSalt(identifier int, extendable bool) []byte {
+	if extendable {
+		return nil
+	}
+
+	return append([]byte(customizationString), byte(identifier>>8), byte(identifier))
+}
+
+func xor(a, b []byte) []byte {
+	result := make([]byte, len(a))
+
+	for i := range a {
+		result[i] = a[i] ^ b[i]
+	}
+
+	return result
+}
@@ -0,0 +1,198 @@
+package slip39
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"errors"
+)
+
+// reserved x coordinates of the digest and secret points
+const (
+	digestIndex = 254
+	secretIndex = 255
+)
+
+const digestLength = 4
+
+// ErrInvalidDigest recovered secret does not match the share digest
+var ErrInvalidDigest = errors.New("invalid digest of the shared secret")
+
+// log and exp tables of GF(256) with the Rijndael polynomial x^8 + x^4 + x^3 + x + 1
+var (
+	expTable [255]byte
+	logTable [256]byte
+)
+
+func init() {
+	poly := 1
+
+	for i := 0; i < 255; i++ {
+		expTable[i] = byte(poly)
+		logTable[poly] = byte(i)
+
+		// multiply poly by the generator x + 1
+		poly = (poly << 1) ^ poly
+
+		if poly&0x100 != 0 {
+			poly ^= 0x11b
+		}
+	}
+}
+
+type point struct {
+	x    byte
+	data []byte
+}
+
+// interpolate evaluate the polynomial through points at x with lagrange interpolation
+func interpolate(points []point, x byte) ([]byte, error) {
+	seen := make(map[byte]bool)
+
+	for _, p := range points {
+		if seen[p.x] {
+			return nil, errors.New("share indexes must be unique")
+		}
+
+		seen[p.x] = true
+
+		if len(p.data) != len(points[0].data) {
+			return nil, errors.New("all share values must have the same length")
+		}
+	}
+
+	if seen[x] {
+		for _, p := range points {
+			if p.x == x {
+				return append([]byte(nil), p.data...), nil
+			}
+		}
+	}
+
+	// log of the product of (x - x_j) for all points
+	logProd := 0
+
+	for _, p := range points {
+		logProd += int(logTable[p.x^x])
+	}
+
+	result := make([]byte, len(points[0].data))
+
+	for _, p := range points {
+		// log of the lagrange basis polynomial evaluated at x
+		logBasis := logProd - int(logTable[p.x^x])
+
+		for _, q := range points {
+			if q.x != p.x {
+				logBasis -= int(logTable[p.x^q.x])
+			}
+		}
+
+		logBasis = ((logBasis % 255) + 255) % 255
+
+		for i, b := range p.data {
+			if b != 0 {
+				result[i] ^= expTable[(int(logTable[b])+logBasis)%255]
+			}
+		}
+	}
+
+	return result, nil
+}
+
+func splitSecret(threshold int, shareCount int, secret []byte) ([]point, error) {
+	if threshold < 1 || threshold > shareCount {
+		return nil, errors.New("threshold must be between 1 and the share count")
+	}
+
+	if shareCount > maxShareCount {
+		return nil, errors.New("too many shares, at most 16 are allowed")
+	}
+
+	if threshold == 1 {
+		points := make([]point, shareCount)
+
+		for i := range points {
+			points[i] = point{byte(i), append([]byte(nil), secret...)}
+		}
+
+		return points, nil
+	}
+
+	randomCount := threshold - 2
+
+	points := make([]point, 0, shareCount)
+
+	for i := 0; i < randomCount; i++ {
+		data, err := randomBytes(len(secret))
+
+		if err != nil {
+			return nil, err
+		}
+
+		points = append(points, point{byte(i), data})
+	}
+
+	randomPart, err := randomBytes(len(secret) - digestLength)
+
+	if err != nil {
+		return nil, err
+	}
+
+	base := append([]point(nil), points...)
+	base = append(base,
+		point{digestIndex, append(createDigest(randomPart, secret), randomPart...)},
+		point{secretIndex, secret},
+	)
+
+	for i := randomCount; i < shareCount; i++ {
+		data, err := interpolate(base, byte(i))
+
+		if err != nil {
+			return nil, err
+		}
+
+		points = append(points, point{byte(i), data})
+	}
+
+	return points, nil
+}
+
+func recoverSecret(threshold int, points []point) ([]byte, error) {
+	if threshold == 1 {
+		return points[0].data, nil
+	}
+
+	secret, err := interpolate(points, secretIndex)
+
+	if err != nil {
+		return nil, err
+	}
+
+	digestShare, err := interpolate(points, digestIndex)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if !hmac.Equal(digestShare[:digestLength], createDigest(digestShare[digestLength:], secret)) {
+		return nil, ErrInvalidDigest
+	}
+
+	return secret, nil
+}
+
+func createDigest(randomPart []byte, secret []byte) []byte {
+	mac := hmac.New(sha256.New, randomPart)
+	mac.Write(secret)
+
+	return mac.Sum(nil)[:digestLength]
+}
+
+func randomBytes(n int) ([]byte, error) {
+	buff := make([]byte, n)
+
+	_, err := rand.Read(buff)
+
+	return buff, err
+}
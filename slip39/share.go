@@ -0,0 +1,261 @@
+package slip39
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+// share encoding parameters
+const (
+	radixBits           = 10
+	radix               = 1 << radixBits
+	idLengthBits        = 15
+	iterationExpBits    = 4
+	idExpLengthWords    = 2
+	checksumWords       = 3
+	metadataWords       = idExpLengthWords + 2 + checksumWords
+	minStrengthBits     = 128
+	minMnemonicWords    = metadataWords + (minStrengthBits+radixBits-1)/radixBits
+	maxShareCount       = 16
+	customizationString = "shamir"
+	extendableString    = "shamir_extendable"
+)
+
+// share errors
+var (
+	ErrInvalidChecksum      = errors.New("invalid mnemonic checksum")
+	ErrInvalidMnemonicLen   = fmt.Errorf("mnemonic must have at least %d words", minMnemonicWords)
+	ErrInvalidPadding       = errors.New("invalid mnemonic padding")
+	ErrInvalidGroupSettings = errors.New("group threshold can not be greater than group count")
+)
+
+// WordError mnemonic word not found in the word list
+type WordError struct {
+	Position int
+	Word     string
+}
+
+func (e *WordError) Error() string {
+	return fmt.Sprintf("word `%v` at position %d not found in slip39 word list", e.Word, e.Position)
+}
+
+// Share decoded slip39 mnemonic share
+type Share struct {
+	Identifier        int
+	Extendable        bool
+	IterationExponent int
+	GroupIndex        int
+	GroupThreshold    int
+	GroupCount        int
+	MemberIndex       int
+	MemberThreshold   int
+	Value             []byte
+}
+
+// ParseShare decode mnemonic into share, the checksum is verified
+func ParseShare(mnemonic string) (*Share, error) {
+	words := strings.Fields(strings.ToLower(mnemonic))
+
+	if len(words) < minMnemonicWords {
+		return nil, ErrInvalidMnemonicLen
+	}
+
+	data := make([]int, len(words))
+
+	for i, word := range words {
+		index, ok := ReverseWordMap[word]
+
+		if !ok {
+			return nil, &WordError{Position: i, Word: word}
+		}
+
+		data[i] = index
+	}
+
+	paddingLen := (radixBits * (len(data) - metadataWords)) % 16
+
+	if paddingLen > 8 {
+		return nil, ErrInvalidMnemonicLen
+	}
+
+	idExp := intFromIndexes(data[:idExpLengthWords])
+
+	share := &Share{
+		Identifier:        idExp >> (iterationExpBits + 1),
+		Extendable:        (idExp>>iterationExpBits)&1 == 1,
+		IterationExponent: idExp & (1<<iterationExpBits - 1),
+	}
+
+	if !verifyChecksum(data, share.Extendable) {
+		return nil, ErrInvalidChecksum
+	}
+
+	params := intFromIndexes(data[idExpLengthWords : idExpLengthWords+2])
+
+	share.GroupIndex = params >> 16
+	share.GroupThreshold = (params>>12)&0xf + 1
+	share.GroupCount = (params>>8)&0xf + 1
+	share.MemberIndex = (params >> 4) & 0xf
+	share.MemberThreshold = params&0xf + 1
+
+	if share.GroupThreshold > share.GroupCount {
+		return nil, ErrInvalidGroupSettings
+	}
+
+	valueData := data[idExpLengthWords+2 : len(data)-checksumWords]
+
+	if valueData[0] >= 1<<uint(radixBits-paddingLen) {
+		return nil, ErrInvalidPadding
+	}
+
+	value := big.NewInt(0)
+
+	for _, index := range valueData {
+		value.Lsh(value, radixBits)
+		value.Or(value, big.NewInt(int64(index)))
+	}
+
+	valueLen := (radixBits*len(valueData) - paddingLen) / 8
+
+	share.Value = padBytes(value.Bytes(), valueLen)
+
+	return share, nil
+}
+
+// Mnemonic encode share into mnemonic words
+func (share *Share) Mnemonic() string {
+	data := make([]int, 0, metadataWords+len(share.Value))
+
+	idExp := share.Identifier << (iterationExpBits + 1)
+
+	if share.Extendable {
+		idExp |= 1 << iterationExpBits
+	}
+
+	idExp |= share.IterationExponent
+
+	data = append(data, indexesFromInt(idExp, idExpLengthWords)...)
+
+	params := share.GroupIndex
+	params = params<<4 | (share.GroupThreshold - 1)
+	params = params<<4 | (share.GroupCount - 1)
+	params = params<<4 | share.MemberIndex
+	params = params<<4 | (share.MemberThreshold - 1)
+
+	data = append(data, indexesFromInt(params, 2)...)
+
+	valueWords := (len(share.Value)*8 + radixBits - 1) / radixBits
+
+	value := new(big.Int).SetBytes(share.Value)
+	mask := big.NewInt(radix - 1)
+
+	valueData := make([]int, valueWords)
+
+	for i := valueWords - 1; i >= 0; i-- {
+		valueData[i] = int(new(big.Int).And(value, mask).Int64())
+		value.Rsh(value, radixBits)
+	}
+
+	data = append(data, valueData...)
+	data = append(data, createChecksum(data, share.Extendable)...)
+
+	words := make([]string, len(data))
+
+	for i, index := range data {
+		words[i] = WordList[index]
+	}
+
+	return strings.Join(words, " ")
+}
+
+func customization(extendable bool) string {
+	if extendable {
+		return extendableString
+	}
+
+	return customizationString
+}
+
+var rs1024Generator = [10]uint32{
+	0xe0e040, 0x1c1c080, 0x3838100, 0x7070200, 0xe0e0009,
+	0x1c0c2412, 0x38086c24, 0x3090fc48, 0x21b1f890, 0x3f3f120,
+}
+
+func rs1024Polymod(values []int) uint32 {
+	chk := uint32(1)
+
+	for _, v := range values {
+		b := chk >> 20
+		chk = (chk&0xfffff)<<10 ^ uint32(v)
+
+		for i := uint(0); i < 10; i++ {
+			if (b>>i)&1 == 1 {
+				chk ^= rs1024Generator[i]
+			}
+		}
+	}
+
+	return chk
+}
+
+func customizationValues(extendable bool) []int {
+	custom := customization(extendable)
+
+	values := make([]int, len(custom))
+
+	for i := range custom {
+		values[i] = int(custom[i])
+	}
+
+	return values
+}
+
+func createChecksum(data []int, extendable bool) []int {
+	values := append(customizationValues(extendable), data...)
+	values = append(values, 0, 0, 0)
+
+	polymod := rs1024Polymod(values) ^ 1
+
+	checksum := make([]int, checksumWords)
+
+	for i := range checksum {
+		checksum[i] = int(polymod>>uint(radixBits*(checksumWords-1-i))) & (radix - 1)
+	}
+
+	return checksum
+}
+
+func verifyChecksum(data []int, extendable bool) bool {
+	return rs1024Polymod(append(customizationValues(extendable), data...)) == 1
+}
+
+func intFromIndexes(indexes []int) int {
+	value := 0
+
+	for _, index := range indexes {
+		value = value<<radixBits | index
+	}
+
+	return value
+}
+
+func indexesFromInt(value int, words int) []int {
+	indexes := make([]int, words)
+
+	for i := words - 1; i >= 0; i-- {
+		indexes[i] = value & (radix - 1)
+		value >>= radixBits
+	}
+
+	return indexes
+}
+
+func padBytes(buff []byte, size int) []byte {
+	if len(buff) >= size {
+		return buff
+	}
+
+	return append(make([]byte, size-len(buff)), buff...)
+}
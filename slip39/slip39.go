@@ -0,0 +1,202 @@
+package slip39
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"sort"
+)
+
+// MaxIterationExponent max iteration exponent of the passphrase encryption
+const MaxIterationExponent = 1<<iterationExpBits - 1
+
+// combine errors
+var (
+	ErrNoShares             = errors.New("no mnemonic shares")
+	ErrMismatchedShares     = errors.New("mnemonic shares do not belong to the same secret")
+	ErrInsufficientGroups   = errors.New("insufficient number of mnemonic groups")
+	ErrInsufficientMembers  = errors.New("insufficient number of mnemonics in group")
+	ErrInvalidMasterSecret  = errors.New("master secret must be at least 16 bytes and have even length")
+	ErrInvalidMemberSetting = errors.New("member threshold 1 is only allowed with member count 1")
+)
+
+// Group member share settings of one group
+type Group struct {
+	Threshold int
+	Count     int
+}
+
+// GenerateMnemonics split master secret into groups of mnemonic shares,
+// groupThreshold groups are required to recover the secret, which is
+// encrypted with passphrase and 10000 * 2^iterationExponent pbkdf2 iterations
+func GenerateMnemonics(groupThreshold int, groups []Group, masterSecret []byte, passphrase string, iterationExponent int) ([][]string, error) {
+	return generateMnemonics(groupThreshold, groups, masterSecret, passphrase, iterationExponent, true)
+}
+
+func generateMnemonics(groupThreshold int, groups []Group, masterSecret []byte, passphrase string, iterationExponent int, extendable bool) ([][]string, error) {
+	if len(masterSecret)*8 < minStrengthBits || len(masterSecret)%2 != 0 {
+		return nil, ErrInvalidMasterSecret
+	}
+
+	if groupThreshold < 1 || groupThreshold > len(groups) {
+		return nil, ErrInvalidGroupSettings
+	}
+
+	if iterationExponent < 0 || iterationExponent > MaxIterationExponent {
+		return nil, fmt.Errorf("iteration exponent must be between 0 and %d", MaxIterationExponent)
+	}
+
+	for _, r := range passphrase {
+		if r < 32 || r > 126 {
+			return nil, errors.New("passphrase must contain only printable ASCII characters")
+		}
+	}
+
+	for _, group := range groups {
+		if group.Threshold == 1 && group.Count > 1 {
+			return nil, ErrInvalidMemberSetting
+		}
+	}
+
+	var id [2]byte
+
+	if _, err := rand.Read(id[:]); err != nil {
+		return nil, err
+	}
+
+	identifier := int(binary.BigEndian.Uint16(id[:]) & (1<<idLengthBits - 1))
+
+	encrypted := encrypt(masterSecret, passphrase, iterationExponent, identifier, extendable)
+
+	groupPoints, err := splitSecret(groupThreshold, len(groups), encrypted)
+
+	if err != nil {
+		return nil, err
+	}
+
+	mnemonics := make([][]string, len(groups))
+
+	for i, groupPoint := range groupPoints {
+		memberPoints, err := splitSecret(groups[i].Threshold, groups[i].Count, groupPoint.data)
+
+		if err != nil {
+			return nil, err
+		}
+
+		for _, memberPoint := range memberPoints {
+			share := &Share{
+				Identifier:        identifier,
+				Extendable:        extendable,
+				IterationExponent: iterationExponent,
+				GroupIndex:        int(groupPoint.x),
+				GroupThreshold:    groupThreshold,
+				GroupCount:        len(groups),
+				MemberIndex:       int(memberPoint.x),
+				MemberThreshold:   groups[i].Threshold,
+				Value:             memberPoint.data,
+			}
+
+			mnemonics[i] = append(mnemonics[i], share.Mnemonic())
+		}
+	}
+
+	return mnemonics, nil
+}
+
+// CombineMnemonics recover master secret from mnemonic shares and passphrase.
+// A wrong passphrase is not detected and gives a different master secret
+func CombineMnemonics(mnemonics []string, passphrase string) ([]byte, error) {
+	if len(mnemonics) == 0 {
+		return nil, ErrNoShares
+	}
+
+	var first *Share
+
+	groups := make(map[int][]*Share)
+
+	for _, mnemonic := range mnemonics {
+		share, err := ParseShare(mnemonic)
+
+		if err != nil {
+			return nil, err
+		}
+
+		if first == nil {
+			first = share
+		}
+
+		if share.Identifier != first.Identifier ||
+			share.Extendable != first.Extendable ||
+			share.IterationExponent != first.IterationExponent ||
+			share.GroupThreshold != first.GroupThreshold ||
+			share.GroupCount != first.GroupCount ||
+			len(share.Value) != len(first.Value) {
+			return nil, ErrMismatchedShares
+		}
+
+		groups[share.GroupIndex] = append(groups[share.GroupIndex], share)
+	}
+
+	if len(groups) < first.GroupThreshold {
+		return nil, ErrInsufficientGroups
+	}
+
+	indexes := make([]int, 0, len(groups))
+
+	for index := range groups {
+		indexes = append(indexes, index)
+	}
+
+	sort.Ints(indexes)
+
+	var groupPoints []point
+
+	for _, index := range indexes {
+		members := groups[index]
+
+		threshold := members[0].MemberThreshold
+
+		if len(members) < threshold {
+			continue
+		}
+
+		memberPoints := make([]point, 0, threshold)
+
+		for _, member := range members[:threshold] {
+			if member.MemberThreshold != threshold {
+				return nil, ErrMismatchedShares
+			}
+
+			memberPoints = append(memberPoints, point{byte(member.MemberIndex), member.Value})
+		}
+
+		secret, err := recoverSecret(threshold, memberPoints)
+
+		if err != nil {
+			return nil, err
+		}
+
+		groupPoints = append(groupPoints, point{byte(index), secret})
+
+		if len(groupPoints) == first.GroupThreshold {
+			break
+		}
+	}
+
+	if len(groupPoints) < first.GroupThreshold {
+		if len(groupPoints) == 0 {
+			return nil, ErrInsufficientMembers
+		}
+
+		return nil, ErrInsufficientGroups
+	}
+
+	encrypted, err := recoverSecret(first.GroupThreshold, groupPoints)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return decrypt(encrypted, passphrase, first.IterationExponent, first.Identifier, first.Extendable), nil
+}
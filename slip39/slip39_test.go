@@ -0,0 +1,130 @@
+package slip39
+
+import (
+	"bytes"
+	"encoding/hex"
+	"testing"
+)
+
+// official slip39 test vectors, secrets are encrypted with passphrase "TREZOR"
+var testVectors = []struct {
+	mnemonics []string
+	secret    string
+}{
+	{
+		[]string{
+			"duckling enlarge academic academic agency result length solution fridge kidney coal piece deal husband erode duke ajar critical decision keyboard",
+		},
+		"bb54aac4b89dc868ba37d9cc21b2cece",
+	},
+	{
+		[]string{
+			"shadow pistol academic always adequate wildlife fancy gross oasis cylinder mustang wrist rescue view short owner flip making coding armed",
+			"shadow pistol academic acid actress prayer class unknown daughter sweater depict flip twice unkind craft early superior advocate guest smoking",
+		},
+		"b43ceb7e57a0ea8766221624d01b0864",
+	},
+	{
+		[]string{
+			"eraser senior beard romp adorn nuclear spill corner cradle style ancient family general leader ambition exchange unusual garlic promise voice",
+			"eraser senior ceramic snake clay various huge numb argue hesitate auction category timber browser greatest hanger petition script leaf pickup",
+			"eraser senior ceramic shaft dynamic become junior wrist silver peasant force math alto coal amazing segment yelp velvet image paces",
+			"eraser senior ceramic round column hawk trust auction smug shame alive greatest sheriff living perfect corner chest sled fumes adequate",
+		},
+		"7c3397a292a5941682d7a4ae2d898d11",
+	},
+	{
+		[]string{
+			"theory painting academic academic armed sweater year military elder discuss acne wildlife boring employer fused large satoshi bundle carbon diagnose anatomy hamster leaves tracks paces beyond phantom capital marvel lips brave detect luck",
+		},
+		"989baf9dcaad5b10ca33dfd8cc75e42477025dce88ae83e75a230086a0e00e92",
+	},
+}
+
+func TestVectors(t *testing.T) {
+	for _, vector := range testVectors {
+		secret, err := CombineMnemonics(vector.mnemonics, "TREZOR")
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if hex.EncodeToString(secret) != vector.secret {
+			t.Fatalf("expect %s got %x", vector.secret, secret)
+		}
+
+		for _, mnemonic := range vector.mnemonics {
+			share, err := ParseShare(mnemonic)
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if share.Mnemonic() != mnemonic {
+				t.Fatalf("expect %s got %s", mnemonic, share.Mnemonic())
+			}
+		}
+	}
+}
+
+func TestInvalidMnemonics(t *testing.T) {
+	_, err := ParseShare("duckling enlarge academic academic agency result length solution fridge kidney coal piece deal husband erode duke ajar critical decision kidney")
+
+	if err != ErrInvalidChecksum {
+		t.Fatalf("expect ErrInvalidChecksum got %v", err)
+	}
+
+	_, err = CombineMnemonics(testVectors[1].mnemonics[:1], "TREZOR")
+
+	if err != ErrInsufficientMembers {
+		t.Fatalf("expect ErrInsufficientMembers got %v", err)
+	}
+
+	_, err = CombineMnemonics([]string{testVectors[0].mnemonics[0], testVectors[1].mnemonics[0]}, "TREZOR")
+
+	if err != ErrMismatchedShares {
+		t.Fatalf("expect ErrMismatchedShares got %v", err)
+	}
+}
+
+func TestGenerateMnemonics(t *testing.T) {
+	secret := bytes.Repeat([]byte{0x5a, 0x3c}, 16)
+
+	for _, extendable := range []bool{false, true} {
+		groups, err := generateMnemonics(2, []Group{{1, 1}, {2, 3}, {3, 5}}, secret, "TREZOR", 0, extendable)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(groups) != 3 || len(groups[1]) != 3 || len(groups[2]) != 5 {
+			t.Fatalf("unexpected share layout")
+		}
+
+		shares := append([]string{groups[0][0]}, groups[2][1], groups[2][3], groups[2][4])
+
+		recovered, err := CombineMnemonics(shares, "TREZOR")
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !bytes.Equal(recovered, secret) {
+			t.Fatalf("expect %x got %x", secret, recovered)
+		}
+
+		recovered, err = CombineMnemonics([]string{groups[1][2], groups[1][0], groups[0][0]}, "")
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if bytes.Equal(recovered, secret) {
+			t.Fatal("wrong passphrase must give a different secret")
+		}
+	}
+
+	if _, err := GenerateMnemonics(1, []Group{{1, 2}}, secret, "", 0); err != ErrInvalidMemberSetting {
+		t.Fatalf("expect ErrInvalidMemberSetting got %v", err)
+	}
+}